@@ -0,0 +1,41 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+const mockSendKeys = vi.fn();
+
+vi.mock("@/lib/sessions", () => ({
+  getSessionManager: () => ({
+    sendKeys: mockSendKeys,
+  }),
+}));
+
+import { POST } from "./route";
+import { NextRequest } from "next/server";
+
+beforeEach(() => {
+  mockSendKeys.mockReset();
+});
+
+function post(body: unknown) {
+  const req = new NextRequest("http://localhost/api/sessions/my-sess/input", {
+    method: "POST",
+    body: JSON.stringify(body),
+  });
+  return POST(req, { params: Promise.resolve({ name: "my-sess" }) });
+}
+
+describe("POST /api/sessions/[name]/input", () => {
+  it("types the data into the session", async () => {
+    mockSendKeys.mockResolvedValue(undefined);
+    const res = await post({ data: "continue\r" });
+
+    expect(res.status).toBe(200);
+    expect(mockSendKeys).toHaveBeenCalledWith("my-sess", "continue\r", "local");
+  });
+
+  it("returns 400 without data", async () => {
+    const res = await post({});
+
+    expect(res.status).toBe(400);
+    expect(mockSendKeys).not.toHaveBeenCalled();
+  });
+});
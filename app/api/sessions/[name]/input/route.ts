@@ -0,0 +1,24 @@
+import { NextRequest, NextResponse } from "next/server";
+import { getSessionManager } from "@/lib/sessions";
+import { getAuthUser } from "@/lib/auth";
+
+export async function POST(
+  req: NextRequest,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  const user = await getAuthUser(req);
+  if (!user) return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+
+  const { name } = await params;
+  const { data } = await req.json();
+  if (typeof data !== "string") {
+    return NextResponse.json({ error: "data must be a string" }, { status: 400 });
+  }
+  try {
+    await getSessionManager().sendKeys(name, data, user.userId);
+  } catch (e: any) {
+    const status = e.message === "Not found" ? 404 : 400;
+    return NextResponse.json({ error: e.message }, { status });
+  }
+  return NextResponse.json({ ok: true });
+}
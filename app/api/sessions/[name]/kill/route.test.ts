@@ -0,0 +1,36 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+const mockKill = vi.fn();
+
+vi.mock("@/lib/sessions", () => ({
+  getSessionManager: () => ({
+    kill: mockKill,
+  }),
+}));
+
+import { POST } from "./route";
+import { NextRequest } from "next/server";
+
+beforeEach(() => {
+  mockKill.mockReset();
+});
+
+describe("POST /api/sessions/[name]/kill", () => {
+  it("kills the session", async () => {
+    mockKill.mockResolvedValue(undefined);
+    const req = new NextRequest("http://localhost/api/sessions/my-sess/kill", { method: "POST" });
+    const res = await POST(req, { params: Promise.resolve({ name: "my-sess" }) });
+
+    expect(res.status).toBe(200);
+    expect(mockKill).toHaveBeenCalledWith("my-sess", "local");
+  });
+
+  it("returns 404 for a session the user doesn't own", async () => {
+    mockKill.mockRejectedValue(new Error("Not found"));
+    const req = new NextRequest("http://localhost/api/sessions/nope/kill", { method: "POST" });
+    const res = await POST(req, { params: Promise.resolve({ name: "nope" }) });
+
+    expect(res.status).toBe(404);
+    expect(await res.json()).toEqual({ error: "Not found" });
+  });
+});
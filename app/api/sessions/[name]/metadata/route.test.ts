@@ -0,0 +1,47 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+const mockUpdateMetadata = vi.fn();
+
+vi.mock("@/lib/sessions", () => ({
+  getSessionManager: () => ({
+    updateMetadata: mockUpdateMetadata,
+  }),
+}));
+
+import { PATCH } from "./route";
+import { NextRequest } from "next/server";
+
+beforeEach(() => {
+  mockUpdateMetadata.mockReset();
+});
+
+function patch(body: unknown) {
+  const req = new NextRequest("http://localhost/api/sessions/my-sess/metadata", {
+    method: "PATCH",
+    body: JSON.stringify(body),
+  });
+  return PATCH(req, { params: Promise.resolve({ name: "my-sess" }) });
+}
+
+describe("PATCH /api/sessions/[name]/metadata", () => {
+  it("replaces the tags", async () => {
+    const res = await patch({ tags: ["bug", "urgent"] });
+
+    expect(res.status).toBe(200);
+    expect(mockUpdateMetadata).toHaveBeenCalledWith("my-sess", { tags: ["bug", "urgent"], project: undefined }, "local");
+  });
+
+  it("sets the project", async () => {
+    const res = await patch({ project: "billing" });
+
+    expect(res.status).toBe(200);
+    expect(mockUpdateMetadata).toHaveBeenCalledWith("my-sess", { tags: undefined, project: "billing" }, "local");
+  });
+
+  it("returns 400 for tags that aren't strings", async () => {
+    const res = await patch({ tags: [1, 2] });
+
+    expect(res.status).toBe(400);
+    expect(mockUpdateMetadata).not.toHaveBeenCalled();
+  });
+});
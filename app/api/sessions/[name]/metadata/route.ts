@@ -0,0 +1,29 @@
+import { NextRequest, NextResponse } from "next/server";
+import { getSessionManager } from "@/lib/sessions";
+import { getAuthUser } from "@/lib/auth";
+
+// Replaces the session's tags and/or project; either can be left out to
+// keep it.
+export async function PATCH(
+  req: NextRequest,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  const user = await getAuthUser(req);
+  if (!user) return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+
+  const { name } = await params;
+  const { tags, project } = await req.json();
+  if (tags !== undefined && (!Array.isArray(tags) || !tags.every((t: unknown) => typeof t === "string"))) {
+    return NextResponse.json({ error: "tags must be an array of strings" }, { status: 400 });
+  }
+  if (project !== undefined && typeof project !== "string") {
+    return NextResponse.json({ error: "project must be a string" }, { status: 400 });
+  }
+  try {
+    getSessionManager().updateMetadata(name, { tags, project }, user.userId);
+  } catch (e: any) {
+    const status = e.message === "Not found" ? 404 : 400;
+    return NextResponse.json({ error: e.message }, { status });
+  }
+  return NextResponse.json({ ok: true });
+}
@@ -0,0 +1,36 @@
+import { describe, it, expect, vi, beforeEach } from "vitest";
+
+const mockRestart = vi.fn();
+
+vi.mock("@/lib/sessions", () => ({
+  getSessionManager: () => ({
+    restart: mockRestart,
+  }),
+}));
+
+import { POST } from "./route";
+import { NextRequest } from "next/server";
+
+beforeEach(() => {
+  mockRestart.mockReset();
+});
+
+describe("POST /api/sessions/[name]/restart", () => {
+  it("restarts the session", async () => {
+    mockRestart.mockResolvedValue(undefined);
+    const req = new NextRequest("http://localhost/api/sessions/my-sess/restart", { method: "POST" });
+    const res = await POST(req, { params: Promise.resolve({ name: "my-sess" }) });
+
+    expect(res.status).toBe(200);
+    expect(mockRestart).toHaveBeenCalledWith("my-sess", "local");
+  });
+
+  it("returns 404 for a session the user doesn't own", async () => {
+    mockRestart.mockRejectedValue(new Error("Not found"));
+    const req = new NextRequest("http://localhost/api/sessions/nope/restart", { method: "POST" });
+    const res = await POST(req, { params: Promise.resolve({ name: "nope" }) });
+
+    expect(res.status).toBe(404);
+    expect(await res.json()).toEqual({ error: "Not found" });
+  });
+});
@@ -1,20 +1,34 @@
 import { describe, it, expect, vi, beforeEach } from "vitest";
 
 const mockDelete = vi.fn();
+const mockRename = vi.fn();
+const mockUpdateDescription = vi.fn();
 
 vi.mock("@/lib/sessions", () => ({
   getSessionManager: () => ({
     delete: mockDelete,
+    rename: mockRename,
+    updateDescription: mockUpdateDescription,
   }),
 }));
 
-import { DELETE } from "./route";
+import { DELETE, PATCH } from "./route";
 import { NextRequest } from "next/server";
 
 beforeEach(() => {
   mockDelete.mockReset();
+  mockRename.mockReset();
+  mockUpdateDescription.mockReset();
 });
 
+function patch(name: string, body: unknown) {
+  const req = new NextRequest(`http://localhost/api/sessions/${name}`, {
+    method: "PATCH",
+    body: JSON.stringify(body),
+  });
+  return PATCH(req, { params: Promise.resolve({ name }) });
+}
+
 describe("DELETE /api/sessions/[name]", () => {
   it("deletes a session and returns 204", async () => {
     const req = new NextRequest("http://localhost/api/sessions/my-sess", {
@@ -26,3 +40,35 @@ describe("DELETE /api/sessions/[name]", () => {
     expect(mockDelete).toHaveBeenCalledWith("my-sess", "local");
   });
 });
+
+describe("PATCH /api/sessions/[name]", () => {
+  it("renames a session", async () => {
+    const res = await patch("my-sess", { name: "auth" });
+
+    expect(res.status).toBe(200);
+    expect(mockRename).toHaveBeenCalledWith("my-sess", "auth", "local");
+    expect(mockUpdateDescription).not.toHaveBeenCalled();
+  });
+
+  it("replaces the description", async () => {
+    const res = await patch("my-sess", { description: "fix login" });
+
+    expect(res.status).toBe(200);
+    expect(mockUpdateDescription).toHaveBeenCalledWith("my-sess", "fix login", "local");
+    expect(mockRename).not.toHaveBeenCalled();
+  });
+
+  it("returns 409 when the new name is taken", async () => {
+    mockRename.mockRejectedValue(new Error('Session "auth" already exists'));
+    const res = await patch("my-sess", { name: "auth" });
+
+    expect(res.status).toBe(409);
+  });
+
+  it("returns 404 for a session the user doesn't own", async () => {
+    mockUpdateDescription.mockImplementation(() => { throw new Error("Not found"); });
+    const res = await patch("nope", { description: "x" });
+
+    expect(res.status).toBe(404);
+  });
+});
@@ -14,3 +14,29 @@ export async function DELETE(
   await getSessionManager().delete(name, user.userId);
   return new NextResponse(null, { status: 204 });
 }
+
+// Renames a session and/or replaces its description.
+export async function PATCH(
+  req: NextRequest,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  const user = await getAuthUser(req);
+  if (!user) return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+
+  const { name } = await params;
+  const { name: newName, description } = await req.json();
+  const mgr = getSessionManager();
+  try {
+    if (description !== undefined) mgr.updateDescription(name, String(description), user.userId);
+    if (newName !== undefined) await mgr.rename(name, String(newName), user.userId);
+  } catch (e: any) {
+    return NextResponse.json({ error: e.message }, { status: errorStatus(e) });
+  }
+  return NextResponse.json({ ok: true });
+}
+
+function errorStatus(e: Error): number {
+  if (e.message === "Not found") return 404;
+  if (e.message.endsWith("already exists")) return 409;
+  return 400;
+}
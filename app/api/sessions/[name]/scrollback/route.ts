@@ -0,0 +1,23 @@
+import { NextRequest, NextResponse } from "next/server";
+import { getSessionManager } from "@/lib/sessions";
+import { getAuthUser } from "@/lib/auth";
+
+const DEFAULT_LINES = 2000;
+const MAX_LINES = 50000;
+
+export async function GET(
+  req: NextRequest,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  const user = await getAuthUser(req);
+  if (!user) return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+
+  const { name } = await params;
+  const raw = req.nextUrl.searchParams.get("lines");
+  const lines = raw === null ? DEFAULT_LINES : Number(raw);
+  if (!Number.isInteger(lines) || lines <= 0) {
+    return NextResponse.json({ error: "lines must be a positive integer" }, { status: 400 });
+  }
+  const text = await getSessionManager().snapshot(name, user.userId, Math.min(lines, MAX_LINES));
+  return NextResponse.json({ text });
+}
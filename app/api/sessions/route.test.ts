@@ -31,6 +31,14 @@ describe("GET /api/sessions", () => {
     expect(await res.json()).toEqual(sessions);
   });
 
+  it("keeps exited sessions with ?all=1", async () => {
+    mockList.mockReturnValue([]);
+    await GET(new NextRequest("http://localhost/api/sessions"));
+    expect(mockList).toHaveBeenLastCalledWith("local", false);
+    await GET(new NextRequest("http://localhost/api/sessions?all=1"));
+    expect(mockList).toHaveBeenLastCalledWith("local", true);
+  });
+
   it("returns empty array when no sessions", async () => {
     mockList.mockReturnValue([]);
     const res = await GET(new NextRequest("http://localhost/api/sessions"));
@@ -51,7 +59,7 @@ describe("POST /api/sessions", () => {
 
     expect(res.status).toBe(201);
     expect(await res.json()).toEqual(created);
-    expect(mockCreate).toHaveBeenCalledWith("test", "bash", undefined, undefined, "local");
+    expect(mockCreate).toHaveBeenCalledWith("test", "bash", undefined, undefined, "local", {});
   });
 
   it("creates a session with no description", async () => {
@@ -65,7 +73,32 @@ describe("POST /api/sessions", () => {
     const res = await POST(req);
 
     expect(res.status).toBe(201);
-    expect(mockCreate).toHaveBeenCalledWith(undefined, undefined, undefined, undefined, "local");
+    expect(mockCreate).toHaveBeenCalledWith(undefined, undefined, undefined, undefined, "local", {});
+  });
+
+  it("passes cwd, env and project through", async () => {
+    mockCreate.mockReturnValue({ name: "calm-falcon", alive: true });
+
+    const opts = { cwd: "~/src/app", env: { DEBUG: "1" }, project: "billing" };
+    const req = new NextRequest("http://localhost/api/sessions", {
+      method: "POST",
+      body: JSON.stringify({ command: "bash", ...opts }),
+    });
+    const res = await POST(req);
+
+    expect(res.status).toBe(201);
+    expect(mockCreate).toHaveBeenCalledWith(undefined, "bash", undefined, undefined, "local", opts);
+  });
+
+  it("returns 400 for an env that isn't all strings", async () => {
+    const req = new NextRequest("http://localhost/api/sessions", {
+      method: "POST",
+      body: JSON.stringify({ env: { PORT: 3000 } }),
+    });
+    const res = await POST(req);
+
+    expect(res.status).toBe(400);
+    expect(mockCreate).not.toHaveBeenCalled();
   });
 
   it("returns 400 when create throws", async () => {
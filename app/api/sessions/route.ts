@@ -6,7 +6,9 @@ export async function GET(req: NextRequest) {
   const user = await getAuthUser(req);
   if (!user) return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
 
-  return NextResponse.json(getSessionManager().list(user.userId));
+  // ?all=1 lists exited sessions too, with alive=false.
+  const all = req.nextUrl.searchParams.get("all") === "1";
+  return NextResponse.json(getSessionManager().list(user.userId, all));
 }
 
 export async function POST(req: NextRequest) {
@@ -14,8 +16,18 @@ export async function POST(req: NextRequest) {
   if (!user) return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
 
   try {
-    const { description, command, executor, mode } = await req.json();
-    const session = await getSessionManager().create(description, command, executor, mode, user.userId);
+    const { description, command, executor, mode, cwd, env, project } = await req.json();
+    if (cwd !== undefined && typeof cwd !== "string") {
+      return NextResponse.json({ error: "cwd must be a string" }, { status: 400 });
+    }
+    if (project !== undefined && typeof project !== "string") {
+      return NextResponse.json({ error: "project must be a string" }, { status: 400 });
+    }
+    if (env !== undefined && (typeof env !== "object" || env === null || Array.isArray(env) ||
+        !Object.values(env).every((v) => typeof v === "string"))) {
+      return NextResponse.json({ error: "env must map names to strings" }, { status: 400 });
+    }
+    const session = await getSessionManager().create(description, command, executor, mode, user.userId, { cwd, env, project });
     return NextResponse.json(session, { status: 201 });
   } catch (e: any) {
     return NextResponse.json({ error: e.message }, { status: 400 });
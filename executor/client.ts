@@ -154,6 +154,18 @@ export class ExecutorClient {
           break;
         }
 
+        case "rename_session": {
+          this.runner.renameSession(msg.name, msg.newName);
+          this.send({ type: "response", id, ok: true });
+          break;
+        }
+
+        case "send_keys": {
+          this.runner.sendKeys(msg.name, msg.data);
+          this.send({ type: "response", id, ok: true });
+          break;
+        }
+
         case "fork_session": {
           const result = this.runner.forkSession(msg.opts);
           this.send({ type: "response", id, ok: true, data: result });
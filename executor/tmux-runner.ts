@@ -7,6 +7,7 @@ import { spawnSync, execSync, spawn } from "child_process";
 import { randomUUID } from "crypto";
 import { existsSync, mkdirSync, writeFileSync, readFileSync, readdirSync, rmSync } from "fs";
 import { join, resolve, dirname } from "path";
+import { homedir } from "os";
 import { fileURLToPath } from "url";
 import type { CreateSessionOpts, CreateJobOpts, ForkSessionOpts, CreateRichSessionOpts, SessionLiveness, SessionAnalysis } from "../shared/types";
 import { snapshotRichEvents } from "../shared/rich-snapshot";
@@ -59,7 +60,7 @@ export class TmuxRunner {
   // PARALLEL: rich equivalent is createRichSession() below.
   // Changes here (e.g. tmux options, validation, env vars) may need mirroring.
   createSession(opts: CreateSessionOpts): { name: string; command: string } {
-    const { name, command = "claude" } = opts;
+    const { name, command = "claude", env = {} } = opts;
 
     if (!/^[a-zA-Z0-9_-]+$/.test(name)) {
       throw new Error("Name must be alphanumeric, hyphens, underscores only");
@@ -70,8 +71,9 @@ export class TmuxRunner {
     }
 
     const tmuxArgs = ["new-session", "-d", "-s", name, "-x", "200", "-y", "50"];
-    const cwd = process.cwd();
+    const cwd = opts.cwd ? opts.cwd.replace(/^~(?=$|\/)/, homedir()) : process.cwd();
     tmuxArgs.push("-c", cwd);
+    for (const [k, v] of Object.entries(env)) tmuxArgs.push("-e", `${k}=${v}`);
 
     const r = spawnSync(TMUX, tmuxArgs, { stdio: "pipe" });
     if (r.status !== 0) {
@@ -176,6 +178,25 @@ export class TmuxRunner {
     }
   }
 
+  renameSession(name: string, newName: string): void {
+    if (!/^[a-zA-Z0-9_-]+$/.test(newName)) {
+      throw new Error("Name must be alphanumeric, hyphens, underscores only");
+    }
+    if (!this.tmuxExists(name)) return;
+    const r = spawnSync(TMUX, ["rename-session", "-t", name, newName], { stdio: "pipe" });
+    if (r.status !== 0) {
+      throw new Error(`Failed to rename tmux session: ${r.stderr?.toString()}`);
+    }
+  }
+
+  // Types data into the session literally, as if typed at its terminal.
+  sendKeys(name: string, data: string): void {
+    const r = spawnSync(TMUX, ["send-keys", "-t", name, "-l", data], { stdio: "pipe" });
+    if (r.status !== 0) {
+      throw new Error(`Failed to send keys: ${r.stderr?.toString()}`);
+    }
+  }
+
   // PARALLEL: terminal equivalent is deleteSession() above.
   deleteRichSession(name: string): void {
     const tName = `rich-${name}`;
@@ -1,4 +1,5 @@
 import { betterAuth } from "better-auth";
+import { bearer } from "better-auth/plugins";
 import Database from "better-sqlite3";
 import { join } from "path";
 import type { IncomingMessage } from "http";
@@ -32,6 +33,8 @@ export const auth = betterAuth({
     enabled: hasCredentials,
     requireEmailVerification: false,
   },
+  // Accept `Authorization: Bearer <session token>` for non-browser clients (TUI)
+  plugins: [bearer()],
   socialProviders: hasGitHub
     ? {
         github: {
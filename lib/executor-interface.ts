@@ -53,6 +53,14 @@ export class LocalExecutor implements ExecutorInterface {
     this.runner.deleteRichSession(name);
   }
 
+  async renameSession(name: string, newName: string): Promise<void> {
+    this.runner.renameSession(name, newName);
+  }
+
+  async sendKeys(name: string, data: string): Promise<void> {
+    this.runner.sendKeys(name, data);
+  }
+
   async forkSession(opts: ForkSessionOpts): Promise<{ name: string; command: string }> {
     return this.runner.forkSession(opts);
   }
@@ -140,6 +148,14 @@ export class RemoteExecutor implements ExecutorInterface {
     await this.rpc("delete_rich_session", { name });
   }
 
+  async renameSession(name: string, newName: string): Promise<void> {
+    await this.rpc("rename_session", { name, newName });
+  }
+
+  async sendKeys(name: string, data: string): Promise<void> {
+    await this.rpc("send_keys", { name, data });
+  }
+
   async forkSession(opts: ForkSessionOpts): Promise<{ name: string; command: string }> {
     return this.rpc("fork_session", { opts });
   }
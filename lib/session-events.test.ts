@@ -0,0 +1,53 @@
+import { describe, it, expect } from "vitest";
+import { diffSessions } from "./session-events";
+import type { Session } from "../shared/types";
+
+function session(name: string, overrides: Partial<Session> = {}): Session {
+  return {
+    name,
+    created_at: `2025-01-01 00:00:0${name.length % 10}`,
+    description: "",
+    command: "claude",
+    mode: "terminal",
+    parent: null,
+    executor: "local",
+    last_activity: 0,
+    alive: true,
+    job_prompt: null,
+    job_max_iterations: null,
+    needs_input: false,
+    ...overrides,
+  };
+}
+
+describe("diffSessions", () => {
+  it("reports nothing for an unchanged list", () => {
+    const list = [session("a"), session("bb")];
+    expect(diffSessions(list, list)).toEqual([]);
+  });
+
+  it("reports created and deleted sessions", () => {
+    const a = session("a", { created_at: "2025-01-01 00:00:01" });
+    const b = session("b", { created_at: "2025-01-01 00:00:02" });
+    expect(diffSessions([a], [b])).toEqual([
+      { type: "created", name: "b" },
+      { type: "deleted", name: "a" },
+    ]);
+  });
+
+  it("pairs a vanished and an appeared session with the same creation time as a rename", () => {
+    const before = session("old", { created_at: "2025-01-01 00:00:01" });
+    const after = { ...before, name: "new" };
+    expect(diffSessions([before], [after])).toEqual([{ type: "renamed", name: "new", from: "old" }]);
+  });
+
+  it("reports a session whose process ended as exited", () => {
+    expect(diffSessions([session("a")], [session("a", { alive: false })])).toEqual([{ type: "exited", name: "a" }]);
+  });
+
+  it("reports description changes as updates", () => {
+    expect(diffSessions([session("a")], [session("a", { description: "fixing tests" })])).toEqual([
+      { type: "updated", name: "a" },
+    ]);
+  });
+});
@@ -0,0 +1,61 @@
+import type { WebSocket } from "ws";
+import type { Session } from "../shared/types";
+
+// Events pushed to clients subscribed to /ws/events, so they can refresh
+// their session list on change instead of polling it.
+export type SessionEvent =
+  | { type: "created"; name: string }
+  | { type: "deleted"; name: string }
+  | { type: "renamed"; name: string; from: string }
+  | { type: "exited"; name: string }
+  | { type: "updated"; name: string };
+
+// How often a subscription re-reads the session list. Listing is an
+// in-process DB query plus tmux has-session checks, far cheaper than every
+// client polling over HTTP.
+export const EVENTS_INTERVAL_MS = 1000;
+
+// Compare two listings. A session that disappears while another with the
+// same creation time and command appears is reported as a rename.
+export function diffSessions(prev: Session[], next: Session[]): SessionEvent[] {
+  const before = new Map(prev.map((s) => [s.name, s]));
+  const after = new Map(next.map((s) => [s.name, s]));
+  const events: SessionEvent[] = [];
+  const gone = prev.filter((s) => !after.has(s.name));
+
+  for (const s of next) {
+    const old = before.get(s.name);
+    if (!old) {
+      const i = gone.findIndex((g) => g.created_at === s.created_at && g.command === s.command);
+      if (i !== -1) {
+        events.push({ type: "renamed", name: s.name, from: gone[i].name });
+        gone.splice(i, 1);
+      } else {
+        events.push({ type: "created", name: s.name });
+      }
+    } else if (old.alive && !s.alive) {
+      events.push({ type: "exited", name: s.name });
+    } else if (old.description !== s.description || old.alive !== s.alive) {
+      events.push({ type: "updated", name: s.name });
+    }
+  }
+  for (const s of gone) events.push({ type: "deleted", name: s.name });
+  return events;
+}
+
+// Push change events to ws until it closes.
+export function streamSessionEvents(ws: WebSocket, list: () => Session[], intervalMs = EVENTS_INTERVAL_MS): void {
+  let last = list();
+  const timer = setInterval(() => {
+    let next: Session[];
+    try {
+      next = list();
+    } catch {
+      return;
+    }
+    for (const event of diffSessions(last, next)) ws.send(JSON.stringify(event));
+    last = next;
+  }, intervalMs);
+  ws.on("close", () => clearInterval(timer));
+  ws.on("error", () => clearInterval(timer));
+}
@@ -51,11 +51,23 @@ describe("SessionManager", () => {
       expect(mgr().list("local")).toEqual([]);
     });
 
-    it("auto-cleans dead sessions from DB", async () => {
+    it("leaves dead sessions out without deleting them", async () => {
       // Create a session (tmux mock succeeds for new-session)
       await mgr().create("", "bash");
-      // has-session returns 1 (dead) by default, so list should clean it up
+      // has-session returns 1 (dead) by default, so list leaves it out
       expect(mgr().list("local")).toEqual([]);
+      // ...but its record is still there to list with all and restart
+      expect(mgr().list("local", true)).toHaveLength(1);
+    });
+
+    it("keeps dead sessions with alive=false when all is set", async () => {
+      const created = await mgr().create("", "bash");
+      const sessions = mgr().list("local", true);
+      expect(sessions).toHaveLength(1);
+      expect(sessions[0].name).toBe(created.name);
+      expect(sessions[0].alive).toBe(false);
+      // Still there: listing with all doesn't clean up
+      expect(mgr().list("local", true)).toHaveLength(1);
     });
 
     it("returns alive sessions with alive=true", async () => {
@@ -214,6 +226,141 @@ describe("SessionManager", () => {
     });
   });
 
+  describe("create with cwd and env", () => {
+    it("starts tmux there and lists them back", async () => {
+      const m = mgr();
+      const created = await m.create("", "bash", "local", "terminal", "local", {
+        cwd: "/srv/app",
+        env: { DEBUG: "1" },
+        project: "billing",
+      });
+
+      const newCall = vi.mocked(spawnSync).mock.calls.find(
+        (c) => c[1] && (c[1] as string[]).includes("new-session"),
+      )!;
+      expect(newCall[1]).toEqual(expect.arrayContaining(["-c", "/srv/app", "-e", "DEBUG=1"]));
+      const [s] = m.list("local", true);
+      expect(s.name).toBe(created.name);
+      expect(s.cwd).toBe("/srv/app");
+      expect(s.env).toEqual({ DEBUG: "1" });
+      expect(s.project).toBe("billing");
+    });
+
+    it("restarts in the same cwd with the same env", async () => {
+      const m = mgr();
+      const created = await m.create("", "bash", "local", "terminal", "local", { cwd: "/srv/app", env: { DEBUG: "1" } });
+      vi.mocked(spawnSync).mockClear();
+
+      await m.restart(created.name, "local");
+
+      const newCall = vi.mocked(spawnSync).mock.calls.find(
+        (c) => c[1] && (c[1] as string[]).includes("new-session"),
+      )!;
+      expect(newCall[1]).toEqual(expect.arrayContaining(["-c", "/srv/app", "-e", "DEBUG=1"]));
+    });
+
+    it("rejects them for rich sessions", async () => {
+      await expect(
+        mgr().create("", "claude", "local", "rich", "local", { env: { DEBUG: "1" } }),
+      ).rejects.toThrow("Rich sessions don't take a cwd or env");
+    });
+  });
+
+  describe("restart", () => {
+    it("relaunches the stored command under the same name", async () => {
+      const m = mgr();
+      const created = await m.create("desc", "bash");
+      vi.mocked(spawnSync).mockClear();
+
+      await m.restart(created.name, "local");
+
+      const newCalls = vi.mocked(spawnSync).mock.calls.filter(
+        (c) => c[1] && (c[1] as string[]).includes("new-session"),
+      );
+      expect(newCalls).toHaveLength(1);
+      expect(newCalls[0][1]).toContain(created.name);
+      const sessions = m.list("local", true);
+      expect(sessions).toHaveLength(1);
+      expect(sessions[0].description).toBe("desc");
+    });
+
+    it("rejects sessions the user doesn't own", async () => {
+      await expect(mgr().restart("no-such", "local")).rejects.toThrow("Not found");
+    });
+  });
+
+  describe("rename", () => {
+    it("renames the tmux session and the record", async () => {
+      const m = mgr();
+      const created = await m.create("desc", "bash");
+      vi.mocked(spawnSync).mockImplementation((_cmd, args) => {
+        if (args && (args as string[]).includes("has-session")) return { status: 0 } as any;
+        return { status: 0, stdout: "", stderr: Buffer.from("") } as any;
+      });
+
+      await m.rename(created.name, "auth", "local");
+
+      const renames = vi.mocked(spawnSync).mock.calls.filter(
+        (c) => c[1] && (c[1] as string[]).includes("rename-session"),
+      );
+      expect(renames).toHaveLength(1);
+      expect(renames[0][1]).toEqual(["rename-session", "-t", created.name, "auth"]);
+      expect(m.list("local", true).map((s) => s.name)).toEqual(["auth"]);
+    });
+
+    it("refuses a name that's taken", async () => {
+      const m = mgr();
+      const a = await m.create("", "bash");
+      const b = await m.create("", "bash");
+      await expect(m.rename(a.name, b.name, "local")).rejects.toThrow("already exists");
+    });
+  });
+
+  describe("kill", () => {
+    it("stops the tmux session but keeps the record", async () => {
+      const m = mgr();
+      const created = await m.create("desc", "bash");
+      vi.mocked(spawnSync).mockImplementation((_cmd, args) => {
+        if (args && (args as string[]).includes("has-session")) return { status: 0 } as any;
+        return { status: 0, stdout: "", stderr: Buffer.from("") } as any;
+      });
+
+      await m.kill(created.name, "local");
+
+      const kills = vi.mocked(spawnSync).mock.calls.filter(
+        (c) => c[1] && (c[1] as string[]).includes("kill-session"),
+      );
+      expect(kills).toHaveLength(1);
+      expect(m.list("local", true)[0].description).toBe("desc");
+    });
+  });
+
+  describe("sendKeys", () => {
+    it("types the data literally", async () => {
+      const m = mgr();
+      const created = await m.create("", "bash");
+      vi.mocked(spawnSync).mockClear();
+
+      await m.sendKeys(created.name, "continue\r", "local");
+
+      expect(vi.mocked(spawnSync).mock.calls.map((c) => c[1])).toContainEqual(
+        ["send-keys", "-t", created.name, "-l", "continue\r"],
+      );
+    });
+  });
+
+  describe("updateMetadata", () => {
+    it("sets tags and project, keeping whichever is left out", async () => {
+      const m = mgr();
+      const created = await m.create("", "bash");
+      m.updateMetadata(created.name, { tags: ["bug"], project: " billing " }, "local");
+      m.updateMetadata(created.name, { tags: ["bug", "urgent"] }, "local");
+      const [s] = m.list("local", true);
+      expect(s.tags).toEqual(["bug", "urgent"]);
+      expect(s.project).toBe("billing");
+    });
+  });
+
   describe("config", () => {
     it("returns null for non-existent key", () => {
       expect(mgr().getConfig("missing", "local")).toBeNull();
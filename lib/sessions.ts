@@ -111,6 +111,29 @@ class SessionManager {
     } catch {
       // Column already exists
     }
+    // Migration: add tags (a JSON array) and project to sessions
+    try {
+      this.db.exec(`ALTER TABLE sessions ADD COLUMN tags TEXT DEFAULT '[]'`);
+    } catch {
+      // Column already exists
+    }
+    try {
+      this.db.exec(`ALTER TABLE sessions ADD COLUMN project TEXT DEFAULT ''`);
+    } catch {
+      // Column already exists
+    }
+    // Migration: add cwd and env (a JSON object) to sessions, kept to
+    // restart them the same way
+    try {
+      this.db.exec(`ALTER TABLE sessions ADD COLUMN cwd TEXT DEFAULT ''`);
+    } catch {
+      // Column already exists
+    }
+    try {
+      this.db.exec(`ALTER TABLE sessions ADD COLUMN env TEXT DEFAULT '{}'`);
+    } catch {
+      // Column already exists
+    }
     // Migration: add user_id to executors
     try {
       this.db.exec(`ALTER TABLE executors ADD COLUMN user_id TEXT DEFAULT NULL`);
@@ -247,17 +270,39 @@ class SessionManager {
   }
 
   // NOTE: Liveness logic differs by mode AND executor. Four cases:
-  //   local+terminal  — tmux has-session check, auto-delete dead sessions
+  //   local+terminal  — tmux has-session check
   //   local+rich      — always alive (tmux session is lazily created)
   //   remote+terminal — heartbeat-cached liveness from ExecutorRegistry
   //   remote+rich     — always alive (rich tmux sessions filtered from heartbeat)
   // When modifying liveness logic, ensure all four cases are handled.
-  list(userId: string): Session[] {
-    const rows = this.db
+  // Dead terminal sessions are left out unless `all` asks for them, listed
+  // with alive=false (so they can be restarted). Their rows stay either way;
+  // only delete() removes a record.
+  list(userId: string, all = false): Session[] {
+    const rows = (this.db
       .prepare("SELECT * FROM sessions WHERE user_id = ? ORDER BY position ASC, created_at DESC")
-      .all(userId) as any[];
+      .all(userId) as any[])
+      .map((row) => ({
+        ...row,
+        tags: JSON.parse(row.tags || "[]"),
+        project: row.project || "",
+        env: JSON.parse(row.env || "{}"),
+      }));
     const alive: Session[] = [];
-    const deadNames: string[] = [];
+    const dead = (row: any, executor: string) => {
+      if (!all) return;
+      alive.push({
+        ...row,
+        mode: row.mode || "terminal",
+        parent: row.parent || null,
+        executor,
+        last_activity: row.last_activity || 0,
+        alive: false,
+        job_prompt: row.job_prompt || null,
+        job_max_iterations: row.job_max_iterations || null,
+        needs_input: false,
+      });
+    };
     for (const row of rows) {
       const executor = row.executor || "local";
       const mode = row.mode || "terminal";
@@ -277,7 +322,7 @@ class SessionManager {
           });
           continue;
         }
-        if (!this.localExecutor) { deadNames.push(row.name); continue; }
+        if (!this.localExecutor) { dead(row, "local"); continue; }
         // Direct tmux check (existing behavior)
         if (this.localExecutor.tmuxExists(row.name)) {
           alive.push({
@@ -292,7 +337,7 @@ class SessionManager {
             needs_input: false,
           });
         } else {
-          deadNames.push(row.name);
+          dead(row, "local");
         }
       } else {
         // Rich sessions on remote executors — alive as long as the DB row exists
@@ -346,7 +391,7 @@ class SessionManager {
             const createdAt = new Date(row.created_at).getTime();
             const ageMs = Date.now() - createdAt;
             if (ageMs > 60_000) {
-              deadNames.push(row.name);
+              dead(row, executor);
             } else {
               alive.push({
                 ...row,
@@ -363,17 +408,23 @@ class SessionManager {
         }
       }
     }
-    // Auto-cleanup dead sessions from DB
-    if (deadNames.length > 0) {
-      const del = this.db.prepare("DELETE FROM sessions WHERE name = ?");
-      for (const name of deadNames) del.run(name);
-    }
     return alive;
   }
 
   // NOTE: Branches on mode (terminal vs rich) — changes to one branch likely
   // need mirroring in the other. Also see createJob() (terminal-only).
-  async create(description = "", command = "claude", executor = "local", mode: "terminal" | "rich" = "terminal", userId: string = "local"): Promise<Session> {
+  async create(
+    description = "",
+    command = "claude",
+    executor = "local",
+    mode: "terminal" | "rich" = "terminal",
+    userId: string = "local",
+    opts: { cwd?: string; env?: Record<string, string>; project?: string } = {},
+  ): Promise<Session> {
+    const { cwd = "", env = {}, project = "" } = opts;
+    if (mode === "rich" && (cwd || Object.keys(env).length > 0)) {
+      throw new Error("Rich sessions don't take a cwd or env");
+    }
     const name = this.uniqueName();
 
     // Inject theme settings for claude commands
@@ -387,8 +438,8 @@ class SessionManager {
       await exec.createRichSession({ name, command: finalCommand });
 
       this.db
-        .prepare("INSERT OR REPLACE INTO sessions (name, description, command, executor, mode, position, user_id) VALUES (?, ?, ?, ?, ?, ?, ?)")
-        .run(name, description, finalCommand, executor, "rich", this.nextPosition(), userId);
+        .prepare("INSERT OR REPLACE INTO sessions (name, description, command, executor, mode, position, user_id, project) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+        .run(name, description, finalCommand, executor, "rich", this.nextPosition(), userId, project);
 
       return {
         name,
@@ -403,16 +454,17 @@ class SessionManager {
         job_prompt: null,
         job_max_iterations: null,
         needs_input: false,
+        project,
       };
     }
 
     const exec = this.getExecutor(executor);
 
-    const result = await exec.createSession({ name, description, command: finalCommand });
+    const result = await exec.createSession({ name, description, command: finalCommand, cwd, env });
 
     this.db
-      .prepare("INSERT OR REPLACE INTO sessions (name, description, command, executor, position, user_id) VALUES (?, ?, ?, ?, ?, ?)")
-      .run(name, description, result.command, executor, this.nextPosition(), userId);
+      .prepare("INSERT OR REPLACE INTO sessions (name, description, command, executor, position, user_id, cwd, env, project) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+      .run(name, description, result.command, executor, this.nextPosition(), userId, cwd, JSON.stringify(env), project);
 
     return {
       name,
@@ -427,6 +479,9 @@ class SessionManager {
       job_prompt: null,
       job_max_iterations: null,
       needs_input: false,
+      cwd,
+      env,
+      project,
     };
   }
 
@@ -478,6 +533,75 @@ class SessionManager {
     this.db.prepare("DELETE FROM sessions WHERE name = ? AND user_id = ?").run(name, userId);
   }
 
+  // NOTE: Branches on mode (terminal vs rich), like delete() and create().
+  // Relaunches the session's stored command, in its cwd with its env, under
+  // the same name, keeping its DB row (description, position). A running
+  // tmux session is killed first.
+  async restart(name: string, userId: string): Promise<void> {
+    if (!this.isOwnedBy(name, userId)) throw new Error("Not found");
+    const mode = this.getMode(name);
+    const command = this.getCommand(name);
+    const exec = this.getExecutor(this.getSessionExecutorId(name));
+    if (mode === "rich") {
+      cleanupRichSession(name);
+      await exec.deleteRichSession(name);
+      await exec.createRichSession({ name, command });
+    } else {
+      const row = this.db.prepare("SELECT cwd, env FROM sessions WHERE name = ?").get(name) as
+        { cwd: string | null; env: string | null };
+      await exec.deleteSession(name);
+      await exec.createSession({ name, command, cwd: row.cwd || undefined, env: JSON.parse(row.env || "{}") });
+    }
+  }
+
+  // Renames a session's record and, if it is running, its tmux session.
+  // Rich sessions keep their bridge state and files under their name, so
+  // they can't be renamed.
+  async rename(name: string, newName: string, userId: string): Promise<void> {
+    if (!this.isOwnedBy(name, userId)) throw new Error("Not found");
+    if (newName === name) return;
+    if (this.getMode(name) === "rich") throw new Error("Rich sessions can't be renamed");
+    if (this.db.prepare("SELECT 1 FROM sessions WHERE name = ?").get(newName)) {
+      throw new Error(`Session "${newName}" already exists`);
+    }
+    await this.getExecutor(this.getSessionExecutorId(name)).renameSession(name, newName);
+    this.db.prepare("UPDATE sessions SET name = ? WHERE name = ?").run(newName, name);
+    this.db.prepare("UPDATE sessions SET parent = ? WHERE parent = ?").run(newName, name);
+  }
+
+  updateDescription(name: string, description: string, userId: string): void {
+    if (!this.isOwnedBy(name, userId)) throw new Error("Not found");
+    this.db.prepare("UPDATE sessions SET description = ? WHERE name = ?").run(description, name);
+  }
+
+  // Replaces a session's tags and/or project; either can be left out to
+  // keep it.
+  updateMetadata(name: string, md: { tags?: string[]; project?: string }, userId: string): void {
+    if (!this.isOwnedBy(name, userId)) throw new Error("Not found");
+    if (md.tags !== undefined) {
+      this.db.prepare("UPDATE sessions SET tags = ? WHERE name = ?").run(JSON.stringify(md.tags), name);
+    }
+    if (md.project !== undefined) {
+      this.db.prepare("UPDATE sessions SET project = ? WHERE name = ?").run(md.project.trim(), name);
+    }
+  }
+
+  // Stops a terminal session's tmux session but keeps its DB row, so it
+  // lists as dead (with all) and can be restarted. A rich session has no
+  // process to stop apart from its data, so it can only be deleted.
+  async kill(name: string, userId: string): Promise<void> {
+    if (!this.isOwnedBy(name, userId)) throw new Error("Not found");
+    if (this.getMode(name) === "rich") throw new Error("Rich sessions can't be killed; delete them instead");
+    await this.getExecutor(this.getSessionExecutorId(name)).deleteSession(name);
+  }
+
+  // Types data into a terminal session as if at its keyboard.
+  async sendKeys(name: string, data: string, userId: string): Promise<void> {
+    if (!this.isOwnedBy(name, userId)) throw new Error("Not found");
+    if (this.getMode(name) === "rich") throw new Error("Rich sessions don't take terminal input");
+    await this.getExecutor(this.getSessionExecutorId(name)).sendKeys(name, data);
+  }
+
   getConfig(key: string, userId: string): string | null {
     const row = this.db.prepare("SELECT value FROM user_config WHERE key = ? AND user_id = ?").get(key, userId) as
       | { value: string }
@@ -500,16 +624,17 @@ class SessionManager {
   }
 
   // NOTE: Branches on mode (terminal vs rich) AND executor (local vs remote).
-  async snapshot(name: string, userId: string): Promise<string> {
+  // lines reaches back into scrollback; omitted, it is the executor default.
+  async snapshot(name: string, userId: string, lines?: number): Promise<string> {
     if (!this.isOwnedBy(name, userId)) throw new Error("Not found");
     const mode = this.getMode(name);
     const executor = this.getSessionExecutorId(name);
     const exec = this.getExecutor(executor);
     if (mode === "rich") {
-      if (executor === "local") return this.snapshotRichSession(name);
+      if (executor === "local") return this.snapshotRichSession(name, lines);
       return exec.snapshotRichSession(name);
     }
-    return exec.snapshotSession(name);
+    return exec.snapshotSession(name, lines);
   }
 
   // NOTE: Branches on mode (terminal vs rich) AND executor (local vs remote).
@@ -828,7 +953,7 @@ class SessionManager {
 }
 
 // Singleton — survives Next.js hot reloads in dev
-const SCHEMA_VERSION = 10; // bump to force re-creation after class changes
+const SCHEMA_VERSION = 11; // bump to force re-creation after class changes
 const globalForSessions = globalThis as unknown as {
   __sessions?: SessionManager;
   __sessionsVersion?: number;
@@ -17,6 +17,12 @@ export function middleware(request: NextRequest) {
     return NextResponse.next();
   }
 
+  // Non-browser clients (TUI) send a bearer token; route handlers validate it
+  // via getAuthUser, so let it through here.
+  if (request.headers.get("authorization")?.startsWith("Bearer ")) {
+    return NextResponse.next();
+  }
+
   // Better Auth uses __Secure- prefix on HTTPS, plain name on HTTP
   const sessionCookie =
     request.cookies.get("__Secure-better-auth.session_token")?.value ||
@@ -1,10 +1,12 @@
 import { createServer } from "http";
+import { rmSync } from "fs";
 import next from "next";
 import { WebSocketServer } from "ws";
 import { spawnSync } from "child_process";
 import { getSessionManager } from "./lib/sessions";
 import { ExecutorRegistry } from "./lib/executor-registry";
 import { getAuthUser } from "./lib/auth";
+import { streamSessionEvents } from "./lib/session-events";
 import { TMUX } from "./shared/tmux";
 
 const dev = process.env.NODE_ENV !== "production";
@@ -28,6 +30,15 @@ function resolvePort(): number {
 }
 const port = resolvePort();
 
+// Support --socket <path> (or SOCKET env) to listen on a unix domain socket
+// instead of a TCP port
+function resolveSocket(): string | undefined {
+  const idx = process.argv.indexOf("--socket");
+  if (idx !== -1 && process.argv[idx + 1]) return process.argv[idx + 1];
+  return process.env.SOCKET || undefined;
+}
+const socketPath = resolveSocket();
+
 // Preflight: verify tmux
 let tmuxVersion: string;
 try {
@@ -74,7 +85,12 @@ app.prepare().then(() => {
   );
   sessionManager.setRegistry(registry);
 
-  const wss = new WebSocketServer({ noServer: true });
+  // Compression is negotiated per connection; small frames (keystrokes,
+  // short redraws) are sent uncompressed to keep latency down.
+  const wss = new WebSocketServer({
+    noServer: true,
+    perMessageDeflate: { threshold: 1024 },
+  });
 
   server.on("upgrade", async (req, socket, head) => {
     const { pathname } = new URL(req.url!, "http://localhost");
@@ -114,6 +130,16 @@ app.prepare().then(() => {
       return;
     }
 
+    // --- Session list change events: /ws/events ---
+    if (pathname === "/ws/events") {
+      const user = await getAuthUser(req);
+      if (!user) { socket.write("HTTP/1.1 401 Unauthorized\r\n\r\n"); socket.destroy(); return; }
+      wss.handleUpgrade(req, socket, head, (ws) => {
+        streamSessionEvents(ws, () => sessionManager.list(user.userId));
+      });
+      return;
+    }
+
     // --- Executor control channel: /ws/executor/control ---
     if (pathname === "/ws/executor/control") {
       const authResult = validateExecutorToken(req);
@@ -153,10 +179,17 @@ app.prepare().then(() => {
     if (!dev) socket.destroy();
   });
 
-  server.listen(port, () => {
+  const onListening = (where: string) => () => {
     console.log(`Using ${tmuxVersion}`);
-    console.log(`Claude Host running at http://localhost:${port}`);
+    console.log(`Claude Host running at ${where}`);
     if (EXECUTOR_TOKEN) console.log(`Legacy EXECUTOR_TOKEN configured (migrate to per-user keys)`);
     console.log(`Executor connections via per-user keys`);
-  });
+  };
+  if (socketPath) {
+    // A socket file left behind by an unclean exit would make listen fail
+    rmSync(socketPath, { force: true });
+    server.listen(socketPath, onListening(`unix://${socketPath}`));
+  } else {
+    server.listen(port, onListening(`http://localhost:${port}`));
+  }
 });
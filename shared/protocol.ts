@@ -47,6 +47,20 @@ export interface DeleteSessionRpc {
   name: string;
 }
 
+export interface RenameSessionRpc {
+  type: "rename_session";
+  id: string;
+  name: string;
+  newName: string;
+}
+
+export interface SendKeysRpc {
+  type: "send_keys";
+  id: string;
+  name: string;
+  data: string;
+}
+
 export interface DeleteRichSessionRpc {
   type: "delete_rich_session";
   id: string;
@@ -132,6 +146,8 @@ export type ControlToExecutorMessage =
   | CreateJobRpc
   | DeleteSessionRpc
   | DeleteRichSessionRpc
+  | RenameSessionRpc
+  | SendKeysRpc
   | ForkSessionRpc
   | ListSessionsRpc
   | SnapshotSessionRpc
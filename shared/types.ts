@@ -15,12 +15,18 @@ export interface Session {
   job_prompt: string | null;
   job_max_iterations: number | null;
   needs_input: boolean;
+  tags?: string[];
+  project?: string; // groups sessions in the dashboard
+  cwd?: string;
+  env?: Record<string, string>;
 }
 
 export interface CreateSessionOpts {
   name: string;
   description?: string;
   command?: string;
+  cwd?: string; // defaults to the executor's working directory
+  env?: Record<string, string>;
 }
 
 export interface CreateJobOpts {
@@ -106,4 +112,6 @@ export interface ExecutorInterface {
   summarizeSession(name: string): Promise<string>;
   analyzeSession(name: string): Promise<SessionAnalysis>;
   createJob(opts: CreateJobOpts): Promise<{ name: string; command: string }>;
+  renameSession(name: string, newName: string): Promise<void>;
+  sendKeys(name: string, data: string): Promise<void>;
 }
@@ -2,80 +2,430 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type Session struct {
-	Name        string `json:"name"`
-	CreatedAt   string `json:"created_at"`
-	Description string `json:"description"`
-	Command     string `json:"command"`
-	Alive       bool   `json:"alive"`
+	Name        string            `json:"name"`
+	CreatedAt   string            `json:"created_at"`
+	Description string            `json:"description"`
+	Command     string            `json:"command"`
+	Alive       bool              `json:"alive"`
+	Cwd         string            `json:"cwd,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Project     string            `json:"project,omitempty"`     // groups sessions in the dashboard
+	Archived    bool              `json:"archived,omitempty"`    // hidden from the list unless asked for
+	Owner       string            `json:"owner,omitempty"`       // user the session belongs to, on a multi-user server
+	ExitStatus  *int              `json:"exit_status,omitempty"` // how the process exited, once it has
+	Viewers     int               `json:"viewers,omitempty"`     // clients attached right now
+	Git         *GitInfo          `json:"git,omitempty"`         // the repository Cwd is in, if any
+	Tokens      *TokenUsage       `json:"tokens,omitempty"`      // a Claude session's token use so far
+	Status      string            `json:"status,omitempty"`      // what a Claude session is doing: thinking, tool, permission, input or done
+
+	// Host is the profile the session was listed from, set by an aggregate
+	// client. Name is then qualified as "host/name".
+	Host string `json:"host,omitempty"`
+}
+
+// GitInfo is the state of the git repository a session is working in.
+type GitInfo struct {
+	Repo   string `json:"repo"`   // base name of the repository's top level
+	Branch string `json:"branch"` // or "detached"
+	Dirty  bool   `json:"dirty,omitempty"`
+}
+
+// String is e.g. "claude-host main*", the star marking uncommitted changes.
+func (g GitInfo) String() string {
+	s := g.Repo + " " + g.Branch
+	if g.Dirty {
+		s += "*"
+	}
+	return s
+}
+
+// exitLabel is "exit 1" for a session that exited with a known status,
+// otherwise "exited".
+func (s Session) exitLabel() string {
+	if s.ExitStatus == nil {
+		return "exited"
+	}
+	return fmt.Sprintf("exit %d", *s.ExitStatus)
+}
+
+// BareName is the session's name on its own host.
+func (s Session) BareName() string {
+	if s.Host == "" {
+		return s.Name
+	}
+	return strings.TrimPrefix(s.Name, s.Host+"/")
 }
 
 type APIClient struct {
 	baseURL string
+	token   string // bearer token, "" for none
 	client  *http.Client
+	socket  string // unix socket path, "" for TCP
+	conn    ConnOptions
+	dial    dialFunc   // nil for a direct TCP connection
+	multi   *multiHost // set on a client aggregating several hosts
+
+	snapshots *snapshotCache // for conditional snapshot requests
+
+	allUsers bool // list every user's sessions, see SetAllUsers
+	archived bool // list archived sessions too, see SetShowArchived
 }
 
-func NewAPIClient(baseURL string) *APIClient {
+// Per-call timeouts, applied on top of the caller's context. Summarize
+// shells out to claude on the server, so it gets much longer, as do file
+// transfers.
+const (
+	requestTimeout   = 10 * time.Second
+	summarizeTimeout = 60 * time.Second
+	transferTimeout  = 10 * time.Minute
+)
+
+func NewAPIClient(baseURL, token string, conn ConnOptions) *APIClient {
+	base, socket := splitSocket(strings.TrimRight(baseURL, "/"))
+	dial := newDial(socket, conn.SSH)
 	return &APIClient{
-		baseURL: strings.TrimRight(baseURL, "/"),
-		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: base,
+		token:   token,
+		client:  &http.Client{Transport: newTransport(dial, conn)},
+		socket:  socket,
+		conn:    conn,
+		dial:    dial,
+
+		snapshots: newSnapshotCache(),
+	}
+}
+
+// APIError describes a failed API call. Status is 0 when the server could
+// not be reached at all, in which case Err holds the transport error.
+type APIError struct {
+	Endpoint string // e.g. "DELETE /api/sessions/foo"
+	Status   int
+	Message  string // server's error message, if any
+	Err      error
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.Status == 0:
+		return fmt.Sprintf("cannot reach server (%s): %v", e.Endpoint, e.Err)
+	case e.Message != "":
+		return fmt.Sprintf("%s: %d %s", e.Endpoint, e.Status, e.Message)
+	default:
+		return fmt.Sprintf("%s: %d %s", e.Endpoint, e.Status, http.StatusText(e.Status))
+	}
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+func (e *APIError) Unreachable() bool  { return e.Status == 0 }
+func (e *APIError) Unauthorized() bool { return e.Status == 401 || e.Status == 403 }
+func (e *APIError) NotFound() bool     { return e.Status == 404 }
+
+// BadResponseError is a successful response whose body isn't what the
+// endpoint returns: empty, null, cut short, not JSON, or missing what every
+// such response has. It usually means a proxy in the way or a server that
+// speaks another version of the API.
+type BadResponseError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *BadResponseError) Error() string {
+	return fmt.Sprintf("bad response from server (%s): %v", e.Endpoint, e.Err)
+}
+
+func (e *BadResponseError) Unwrap() error { return e.Err }
+
+// asBadResponse unwraps err to a *BadResponseError, or nil if it isn't one.
+func asBadResponse(err error) *BadResponseError {
+	var bad *BadResponseError
+	if errors.As(err, &bad) {
+		return bad
+	}
+	return nil
+}
+
+// asAPIError unwraps err to an *APIError, or nil if it isn't one.
+func asAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return nil
+}
+
+// do sends a request against the API with auth headers set, returning an
+// *APIError for transport failures and non-2xx responses. A cancelled
+// context is returned as-is so callers can tell it apart from a failure.
+// Idempotent requests are retried according to the client's policy. On
+// success the caller must close the response body.
+func (a *APIClient) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	return a.doHeader(ctx, method, path, body, nil)
+}
+
+// doHeader is do with extra request headers. A conditional request's 304
+// Not Modified counts as success.
+func (a *APIClient) doHeader(ctx context.Context, method, path string, body any, header http.Header) (*http.Response, error) {
+	p := a.conn.Retry
+	for n := 0; ; n++ {
+		resp, err := a.send(ctx, method, path, body, header)
+		if err == nil || n+1 >= p.attempts || !idempotent(method) || !retryable(err) || !p.wait(ctx, n) {
+			return resp, err
+		}
+		endpoint, _, _ := strings.Cut(path, "?")
+		a.log().Debug("retrying request", "server", a.String(), "method", method, "path", endpoint, "attempt", n+2)
+	}
+}
+
+// send makes a single attempt at a request for do.
+func (a *APIClient) send(ctx context.Context, method, path string, body any, header http.Header) (*http.Response, error) {
+	if a.multi != nil {
+		// Only reached with a name that didn't route to a host.
+		endpoint, _, _ := strings.Cut(path, "?")
+		return nil, &APIError{Endpoint: method + " " + endpoint, Status: http.StatusNotFound, Message: "no such host"}
+	}
+	var r io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case rawBody:
+		r, contentType = b.r, b.contentType
+	default:
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+	if body != nil || method != http.MethodGet {
+		// The server refuses changes in anything else, which a web page
+		// could send it.
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	endpoint, _, _ := strings.Cut(path, "?")
+	log := a.log().With("server", a.String(), "method", method, "path", endpoint)
+	endpoint = method + " " + endpoint
+	start := time.Now()
+	resp, err := a.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			log.Debug("request cancelled", "duration", time.Since(start))
+			return nil, context.Canceled
+		}
+		log.Warn("request failed", "duration", time.Since(start), "err", err)
+		return nil, &APIError{Endpoint: endpoint, Err: err}
+	}
+	notModified := resp.StatusCode == http.StatusNotModified && header.Get("If-None-Match") != ""
+	if (resp.StatusCode < 200 || resp.StatusCode >= 300) && !notModified {
+		defer resp.Body.Close()
+		e := &APIError{Endpoint: endpoint, Status: resp.StatusCode, Message: errorMessage(resp.Body)}
+		log.Warn("request failed", "status", resp.StatusCode, "duration", time.Since(start), "err", e.Message)
+		return nil, e
+	}
+	log.Debug("request", "status", resp.StatusCode, "duration", time.Since(start))
+	return resp, nil
+}
+
+// rawBody is a request body for do to send as it is, rather than as JSON.
+type rawBody struct {
+	r           io.Reader
+	contentType string
+}
+
+// errorMessage extracts the server's message from an error response, which
+// is usually {"error": "..."} but may be plain text.
+func errorMessage(body io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(body, 4096))
+	var v struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &v) == nil && v.Error != "" {
+		return v.Error
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// decodeResponse reads a successful response's JSON body into v, returning
+// a *BadResponseError unless all of it is there.
+func decodeResponse(resp *http.Response, v any) error {
+	endpoint := resp.Request.Method + " " + resp.Request.URL.Path
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		return &BadResponseError{Endpoint: endpoint, Err: err}
+	}
+	switch data = bytes.TrimSpace(data); {
+	case len(data) == 0:
+		err = errors.New("empty body")
+	case string(data) == "null":
+		err = errors.New("null body")
+	case data[0] == '<':
+		err = errors.New("HTML, not JSON; is a proxy or login page in the way?")
+	default:
+		err = json.Unmarshal(data, v)
 	}
+	if err != nil {
+		return &BadResponseError{Endpoint: endpoint, Err: err}
+	}
+	return nil
 }
 
-func (a *APIClient) ListSessions() ([]Session, error) {
-	resp, err := a.client.Get(a.baseURL + "/api/sessions")
+// ListSessions returns only sessions whose process is running.
+func (a *APIClient) ListSessions(ctx context.Context) ([]Session, error) {
+	return a.listSessions(ctx, false)
+}
+
+// ListAllSessions includes sessions whose process has exited, which all=1
+// asks the server to list rather than leave out. Archived sessions are left
+// out unless SetShowArchived asks for them.
+func (a *APIClient) ListAllSessions(ctx context.Context) ([]Session, error) {
+	return a.listSessions(ctx, true)
+}
+
+func (a *APIClient) listSessions(ctx context.Context, all bool) ([]Session, error) {
+	if a.multi != nil {
+		return a.multi.list(ctx, all)
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	q := url.Values{}
+	if all {
+		q.Set("all", "1")
+	}
+	if a.allUsers {
+		q.Set("users", "all")
+	}
+	if a.archived {
+		q.Set("archived", "1")
+	}
+	path := "/api/sessions"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	resp, err := a.do(ctx, "GET", path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("cannot reach server at %s", a.baseURL)
+		return nil, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("server error %d: %s", resp.StatusCode, string(body))
-	}
 	var sessions []Session
-	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+	if err := decodeResponse(resp, &sessions); err != nil {
 		return nil, err
 	}
-	alive := sessions[:0]
-	for _, s := range sessions {
-		if s.Alive {
-			alive = append(alive, s)
+	for i, s := range sessions {
+		if s.Name == "" {
+			return nil, &BadResponseError{Endpoint: "GET /api/sessions", Err: fmt.Errorf("session %d has no name", i)}
 		}
 	}
-	return alive, nil
+	return sessions, nil
+}
+
+// CreateOptions are the fields accepted by POST /api/sessions.
+type CreateOptions struct {
+	Description string            `json:"description"`
+	Command     string            `json:"command"`
+	Cwd         string            `json:"cwd,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	Project     string            `json:"project,omitempty"`
+
+	// Host picks where an aggregate client creates the session, by
+	// default its DefaultHost.
+	Host string `json:"-"`
 }
 
-func (a *APIClient) CreateSession(description, command string) (*Session, error) {
-	payload, _ := json.Marshal(map[string]string{
-		"description": description,
-		"command":     command,
-	})
-	resp, err := a.client.Post(a.baseURL+"/api/sessions", "application/json", bytes.NewReader(payload))
+func (a *APIClient) CreateSession(ctx context.Context, opts CreateOptions) (*Session, error) {
+	if a.multi != nil {
+		host := opts.Host
+		if host == "" {
+			host = a.DefaultHost()
+		}
+		p := a.multi.peers[host]
+		if p == nil {
+			return nil, fmt.Errorf("no host %q", host)
+		}
+		s, err := p.CreateSession(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.Host, s.Name = host, host+"/"+s.Name
+		return s, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/sessions", opts)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != 201 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("%s", strings.TrimSpace(string(body)))
-	}
 	var s Session
-	json.NewDecoder(resp.Body).Decode(&s)
+	if err := decodeResponse(resp, &s); err != nil {
+		return nil, err
+	}
+	if s.Name == "" {
+		return nil, &BadResponseError{Endpoint: "POST /api/sessions", Err: errors.New("the new session has no name")}
+	}
 	return &s, nil
 }
 
-func (a *APIClient) DeleteSession(name string) error {
-	req, _ := http.NewRequest("DELETE", a.baseURL+"/api/sessions/"+url.PathEscape(name), nil)
-	resp, err := a.client.Do(req)
+// DeleteSession removes a session entirely: its process, record and
+// transcript.
+func (a *APIClient) DeleteSession(ctx context.Context, name string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "DELETE", "/api/sessions/"+url.PathEscape(name), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// KillSession stops a session's process but keeps the session, exited, so
+// its output can still be read, exported or restarted.
+func (a *APIClient) KillSession(ctx context.Context, name string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/sessions/"+url.PathEscape(name)+"/kill", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// RenameSession changes a session's name. Names must match [a-zA-Z0-9_-]+.
+func (a *APIClient) RenameSession(ctx context.Context, name, newName string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "PATCH", "/api/sessions/"+url.PathEscape(name), map[string]string{"name": newName})
 	if err != nil {
 		return err
 	}
@@ -83,23 +433,144 @@ func (a *APIClient) DeleteSession(name string) error {
 	return nil
 }
 
-func (a *APIClient) GetSnapshot(name string) (string, error) {
-	resp, err := a.client.Get(a.baseURL + "/api/sessions/" + url.PathEscape(name) + "/snapshot")
+// UpdateDescription replaces a session's description.
+func (a *APIClient) UpdateDescription(ctx context.Context, name, description string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "PATCH", "/api/sessions/"+url.PathEscape(name), map[string]string{"description": description})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// RestartSession relaunches a session's command under the same name,
+// keeping its record, description and scrollback. A running session's
+// process is killed first.
+func (a *APIClient) RestartSession(ctx context.Context, name string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/sessions/"+url.PathEscape(name)+"/restart", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SendKeys writes data to the session's terminal as if typed, e.g.
+// "continue\r" or "y".
+func (a *APIClient) SendKeys(ctx context.Context, name, data string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/sessions/"+url.PathEscape(name)+"/input", map[string]string{"data": data})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SessionMetadata is the user-editable metadata stored alongside a session.
+type SessionMetadata struct {
+	Tags []string `json:"tags"`
+}
+
+// SetProject moves a session into a project, or out of any with "".
+func (a *APIClient) SetProject(ctx context.Context, name, project string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "PATCH", "/api/sessions/"+url.PathEscape(name)+"/metadata", map[string]string{"project": project})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// UpdateMetadata replaces a session's metadata.
+func (a *APIClient) UpdateMetadata(ctx context.Context, name string, md SessionMetadata) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	if md.Tags == nil {
+		md.Tags = []string{}
+	}
+	resp, err := a.do(ctx, "PATCH", "/api/sessions/"+url.PathEscape(name)+"/metadata", md)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GetSnapshot returns a session's screen, from the client's cache when
+// the server says it hasn't changed.
+func (a *APIClient) GetSnapshot(ctx context.Context, name string) (string, error) {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	var header http.Header
+	cached, ok := a.snapshots.get(name)
+	if ok {
+		header = http.Header{"If-None-Match": {cached.etag}}
+	}
+	resp, err := a.doHeader(ctx, "GET", "/api/sessions/"+url.PathEscape(name)+"/snapshot", nil, header)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return cached.text, nil
+	}
 	var result struct {
 		Text string `json:"text"`
 	}
-	json.NewDecoder(resp.Body).Decode(&result)
+	if err := decodeResponse(resp, &result); err != nil {
+		return "", err
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		a.snapshots.put(name, cachedSnapshot{etag: etag, text: result.Text})
+	}
 	return result.Text, nil
 }
 
-func (a *APIClient) Summarize(name string) (string, error) {
-	client := &http.Client{Timeout: 60 * time.Second}
-	req, _ := http.NewRequest("POST", a.baseURL+"/api/sessions/"+url.PathEscape(name)+"/summarize", nil)
-	resp, err := client.Do(req)
+// GetScrollback returns up to lines lines of a session's output, reaching
+// back past the visible screen. lines <= 0 asks for the server's default.
+func (a *APIClient) GetScrollback(ctx context.Context, name string, lines int) (string, error) {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	path := "/api/sessions/" + url.PathEscape(name) + "/scrollback"
+	if lines > 0 {
+		path += "?lines=" + strconv.Itoa(lines)
+	}
+	resp, err := a.do(ctx, "GET", path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := decodeResponse(resp, &result); err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// Summarize has the server describe what a session is doing, and returns
+// the new description. The Node server ignores req.
+func (a *APIClient) Summarize(ctx context.Context, name string, req SummarizeRequest) (string, error) {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, summarizeTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/sessions/"+url.PathEscape(name)+"/summarize", req)
 	if err != nil {
 		return "", err
 	}
@@ -107,35 +578,57 @@ func (a *APIClient) Summarize(name string) (string, error) {
 	var result struct {
 		Description string `json:"description"`
 	}
-	json.NewDecoder(resp.Body).Decode(&result)
+	if err := decodeResponse(resp, &result); err != nil {
+		return "", err
+	}
 	return result.Description, nil
 }
 
 func (a *APIClient) WebSocketURL(name string) string {
+	a, name = a.route(name)
+	return a.wsBase() + "/ws/sessions/" + url.PathEscape(name)
+}
+
+// wsBase is the base URL with its scheme switched to ws:// or wss://.
+func (a *APIClient) wsBase() string {
 	base := a.baseURL
 	if strings.HasPrefix(base, "https://") {
 		base = "wss://" + base[len("https://"):]
 	} else if strings.HasPrefix(base, "http://") {
 		base = "ws://" + base[len("http://"):]
 	}
-	return base + "/ws/sessions/" + url.PathEscape(name)
+	return base
 }
 
-func timeAgo(s string) string {
-	var t time.Time
-	var err error
+// WebSocketHeader is the handshake header for a session's WebSocket.
+func (a *APIClient) WebSocketHeader(name string) http.Header {
+	a, _ = a.route(name)
+	h := http.Header{}
+	if a.token != "" {
+		h.Set("Authorization", "Bearer "+a.token)
+	}
+	return h
+}
+
+// parseTime parses the timestamp formats the server has used for
+// created_at, returning the zero time if none match.
+func parseTime(s string) time.Time {
 	for _, layout := range []string{
 		"2006-01-02 15:04:05",
 		time.RFC3339,
 		"2006-01-02T15:04:05.000Z",
 		"2006-01-02T15:04:05Z",
 	} {
-		t, err = time.Parse(layout, s)
-		if err == nil {
-			break
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
 		}
 	}
-	if err != nil {
+	return time.Time{}
+}
+
+func timeAgo(s string) string {
+	t := parseTime(s)
+	if t.IsZero() {
 		return s
 	}
 
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Claude's tool permission prompts, e.g.
+//
+//	Do you want to proceed?
+//	❯ 1. Yes
+//	  2. Yes, and don't ask again for this command
+//	  3. No, and tell Claude what to do differently (esc)
+var defaultApprovePatterns = []string{
+	`Do you want to (proceed|make this edit|create|overwrite|run)`,
+	`❯ 1\. Yes`,
+}
+
+// Only the bottom of the screen is checked, so a prompt that has scrolled
+// up and been answered doesn't count.
+const approveTailLines = 12
+
+// approver recognises sessions waiting on a permission prompt and knows what
+// to type to answer it.
+type approver struct {
+	patterns []*regexp.Regexp
+	yes      string
+	no       string
+}
+
+func newApprover(cfg ApproveConfig) (*approver, error) {
+	a := &approver{yes: "1", no: "\x1b"}
+	if cfg.Yes != "" {
+		a.yes = cfg.Yes
+	}
+	if cfg.No != "" {
+		a.no = cfg.No
+	}
+	patterns := cfg.Patterns
+	if len(patterns) == 0 {
+		patterns = defaultApprovePatterns
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("approve.patterns: %w", err)
+		}
+		a.patterns = append(a.patterns, re)
+	}
+	return a, nil
+}
+
+// waiting reports whether the screen ends in a permission prompt.
+func (a *approver) waiting(screen string) bool {
+	lines := strings.Split(strings.TrimRight(screen, "\n "), "\n")
+	tail := strings.Join(lines[max(0, len(lines)-approveTailLines):], "\n")
+	for _, re := range a.patterns {
+		if re.MatchString(tail) {
+			return true
+		}
+	}
+	return false
+}
+
+// screensMsg carries the current screen of every running session.
+type screensMsg map[string]string
+
+// answer sends the approve or deny keystroke to the session under the
+// cursor, if it is waiting on a prompt.
+func (m DashboardModel) answer(approve bool) (DashboardModel, tea.Cmd) {
+	if m.cursor >= len(m.sessions) || !m.waiting[m.sessions[m.cursor].Name] {
+		return m, nil
+	}
+	name := m.sessions[m.cursor].Name
+	keys := m.approve.no
+	if approve {
+		keys = m.approve.yes
+	}
+	delete(m.waiting, name)
+	ctx, api := m.ctx, m.api
+	refresh := m.fetchSnapshot()
+	return m, func() tea.Msg {
+		if err := api.SendKeys(ctx, name, keys); err != nil {
+			return errMsg{err}
+		}
+		return refresh()
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net/url"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ArchiveSession hides a session from the list, stopping its process if it
+// is running. Its transcript can still be read and exported.
+func (a *APIClient) ArchiveSession(ctx context.Context, name string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/sessions/"+url.PathEscape(name)+"/archive", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// UnarchiveSession brings an archived session back into the list, exited.
+func (a *APIClient) UnarchiveSession(ctx context.Context, name string) error {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/sessions/"+url.PathEscape(name)+"/unarchive", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SetShowArchived makes ListAllSessions include archived sessions.
+func (a *APIClient) SetShowArchived(on bool) {
+	a.archived = on
+	if a.multi != nil {
+		for _, p := range a.multi.peers {
+			p.SetShowArchived(on)
+		}
+	}
+}
+
+// archive archives the cursor session, or brings it back if it already is,
+// then refreshes the list.
+func (m DashboardModel) archive(sess Session) tea.Cmd {
+	ctx, api, list := m.ctx, m.api, m.lister()
+	return func() tea.Msg {
+		op := api.ArchiveSession
+		if sess.Archived {
+			op = api.UnarchiveSession
+		}
+		if err := op(ctx, sess.Name); err != nil {
+			return errMsg{err}
+		}
+		sessions, err := list(ctx)
+		if err != nil {
+			return errMsg{err}
+		}
+		return sessionsMsg(sessions)
+	}
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
+	"time"
+	"unicode/utf8"
 
-	"github.com/gorilla/websocket"
 	"golang.org/x/term"
 )
 
@@ -17,44 +22,304 @@ const (
 	Detached AttachResult = iota
 	Disconnected
 	AttachError
+	Exited // the session's process exited
 )
 
-func RunAttach(api *APIClient, sessionName string) AttachResult {
-	wsURL := api.WebSocketURL(sessionName)
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+// Reconnect policy: exponential backoff from reconnectMinDelay, capped at
+// reconnectMaxDelay, giving up after reconnectAttempts failures.
+const (
+	reconnectMinDelay = 500 * time.Millisecond
+	reconnectMaxDelay = 15 * time.Second
+	reconnectAttempts = 10
+)
+
+// Keepalive: ping every pingInterval so idle connections aren't reaped by
+// proxies, and treat two missed pongs as a dropped connection.
+const (
+	pingInterval = 15 * time.Second
+	pongWait     = 2*pingInterval + 5*time.Second
+)
+
+// AttachOptions are per-attach settings from the command line.
+type AttachOptions struct {
+	// Recorder, if set, records from the start; RunAttach closes it.
+	Recorder *recorder
+	// Clipboard is the OSC 52 handling mode; "" means clipboardTerminal.
+	Clipboard string
+	// Compress offers permessage-deflate when dialing.
+	Compress bool
+	// Predict is the predictive echo mode; "" means predictOff.
+	Predict string
+	// StatusBar shows the status bar from the start.
+	StatusBar bool
+	// Tmux keeps the tmux window this runs in named after the session, for
+	// attaches opened by `claude-host tmux`.
+	Tmux bool
+	// Errors, if set, records failed dials and dropped connections.
+	Errors *errorLog
+}
+
+// RunAttach attaches the terminal to a session until the user detaches or
+// the connection ends. If the session's process exited, it also returns
+// the exit status, when that's known.
+func RunAttach(ctx context.Context, api *APIClient, sessionName string, keys KeyMap, opts AttachOptions) (AttachResult, *int) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if opts.Recorder != nil {
+		defer opts.Recorder.Close()
+	}
+	log := api.log().With("server", api.String())
+	// outMu guards the terminal and what is drawn on it, including bar,
+	// the status bar, which takes the bottom row away from the session
+	// while it's shown.
+	var outMu sync.Mutex
+	bar := &attachBar{on: opts.StatusBar, name: sessionName, host: api.String()}
+	// retitle names the terminal, and under `claude-host tmux` its window,
+	// after the session now attached.
+	retitle := func(name string) {
+		setTitle(name, keys, 0)
+		if opts.Tmux {
+			renameTmuxWindow(name)
+		}
+	}
+	// appSize is the size of the session's part of the terminal.
+	appSize := func() (w, h int, err error) {
+		w, h, err = term.GetSize(int(os.Stdout.Fd()))
+		outMu.Lock()
+		h = bar.rows(h)
+		outMu.Unlock()
+		return w, h, err
+	}
+
+	// Pass the current size on connect so the server-side PTY starts at the
+	// right dimensions, including after a reconnect.
+	dial := func(name string) (*attachConn, *http.Response, error) {
+		w, h, _ := appSize()
+		return dialAttach(ctx, api, name, opts.Compress, w, h)
+	}
+	conn, _, err := dial(sessionName)
 	if err != nil {
-		return AttachError
+		log.Warn("attach failed", "session", sessionName, "err", err)
+		opts.Errors.add(fmt.Errorf("attach %s: %w", sessionName, err))
+		return AttachError, nil
 	}
-	defer conn.Close()
+	log.Info("attached", "session", sessionName, "binary", conn.binary)
 
 	// Raw mode
 	fd := int(os.Stdin.Fd())
 	oldState, err := term.MakeRaw(fd)
 	if err != nil {
-		return AttachError
+		conn.Close()
+		return AttachError, nil
 	}
 	defer term.Restore(fd, oldState)
 
-	// Mutex for concurrent websocket writes
+	// mu guards conn, sessionName and deleting. conn is swapped out on
+	// reconnect or when switching sessions, and nil while reconnecting.
+	// Input typed while disconnected is dropped. deleting is set while we
+	// delete the session ourselves.
 	var mu sync.Mutex
+	deleting := false
+	defer func() {
+		mu.Lock()
+		if conn != nil {
+			conn.Close()
+		}
+		mu.Unlock()
+	}()
 	wsSend := func(data []byte) error {
 		mu.Lock()
 		defer mu.Unlock()
-		return conn.WriteMessage(websocket.TextMessage, data)
+		if conn == nil {
+			return nil
+		}
+		return conn.Send(data)
+	}
+
+	// watch arms the read deadline on a new connection and extends it on
+	// every pong, updating the latency shown in the window title and the
+	// status bar. Control messages go to the status line. Neither is drawn
+	// until later.
+	var statusLine func(string)
+	var showBar func()
+	watch := func(c *attachConn) {
+		c.SetReadDeadline(time.Now().Add(pongWait))
+		c.OnPong(func(data []byte) {
+			c.SetReadDeadline(time.Now().Add(pongWait))
+			sent, err := strconv.ParseInt(string(data), 10, 64)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			name := sessionName
+			mu.Unlock()
+			latency := time.Since(time.Unix(0, sent))
+			setTitle(name, keys, latency)
+			outMu.Lock()
+			bar.latency = latency
+			outMu.Unlock()
+			showBar()
+		})
+		c.OnControl(func(msg controlMessage) {
+			if msg.Type == controlCatchingUp {
+				statusLine("output paused, catching up…")
+			}
+		})
 	}
+	watch(conn)
 
 	// Send terminal size
+	sendSize := func(w, h int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if conn != nil {
+			conn.Resize(w, h)
+		}
+	}
 	sendResize := func() {
-		w, h, err := term.GetSize(int(os.Stdout.Fd()))
+		w, h, err := appSize()
+		if err != nil {
+			return
+		}
+		sendSize(w, h)
+	}
+	sendResize()
+
+	// Nudging the size makes tmux repaint the whole screen, which is how we
+	// restore the display after copy mode has drawn over it.
+	redraw := func() {
+		w, h, err := appSize()
 		if err != nil {
 			return
 		}
-		msg, _ := json.Marshal(map[string][]int{"resize": {w, h}})
+		sendSize(w, max(1, h-1))
+		sendSize(w, h)
+	}
+	// repaint does the same without the resize, on servers that can.
+	repaint := func() {
 		mu.Lock()
-		conn.WriteMessage(websocket.TextMessage, msg)
+		asked := conn != nil && conn.Repaint()
 		mu.Unlock()
+		if !asked {
+			redraw()
+		}
+	}
+
+	// Output is mirrored into a local scrollback buffer for copy mode, and
+	// withheld from the terminal while copy mode is drawing.
+	sb := newScrollback(scrollbackLines)
+	paused := false
+	// pred, guarded by outMu, draws typed characters before they're echoed.
+	pred := newPredictor(opts.Predict)
+	// modes, guarded by outMu, are the terminal modes the session has set.
+	var modes termModes
+
+	// rec, guarded by outMu, records output to an asciicast file while
+	// recording is on (ctrl-a R or --record).
+	rec := opts.Recorder
+	startRecording := func(path string) error {
+		w, h, err := appSize()
+		if err != nil {
+			w, h = 80, 24
+		}
+		mu.Lock()
+		title := sessionName
+		mu.Unlock()
+		r, err := newRecorder(path, w, h, title)
+		if err != nil {
+			return err
+		}
+		outMu.Lock()
+		rec = r
+		outMu.Unlock()
+		return nil
+	}
+	stopRecording := func() (string, error) {
+		outMu.Lock()
+		r := rec
+		rec = nil
+		outMu.Unlock()
+		if r == nil {
+			return "", nil
+		}
+		return r.path, r.Close()
+	}
+	defer stopRecording()
+
+	// barText returns what draws the status bar, if it's shown. Call it
+	// with outMu held, between pred.undraw and pred.draw.
+	barText := func() string {
+		w, h, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			return ""
+		}
+		bar.recording = rec != nil
+		return bar.draw(w, h, keys)
+	}
+	showBar = func() {
+		outMu.Lock()
+		defer outMu.Unlock()
+		if bar.on && !paused {
+			os.Stdout.WriteString(pred.undraw() + barText() + pred.draw())
+		}
+	}
+	// setRegion confines the session's scrolling to the rows above the
+	// bar, or lets it have the whole screen.
+	setRegion := func() string {
+		_, h, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			return ""
+		}
+		return bar.region(h)
+	}
+	if bar.on {
+		os.Stdout.WriteString(setRegion() + barText())
+	}
+	defer func() {
+		outMu.Lock()
+		if bar.on {
+			os.Stdout.WriteString(freeRegion)
+		}
+		outMu.Unlock()
+	}()
+
+	// Copy mode and the chooser have the whole screen to themselves.
+	setPaused := func(p bool) {
+		outMu.Lock()
+		paused = p
+		pred.reset()
+		if bar.on {
+			if p {
+				os.Stdout.WriteString(freeRegion)
+			} else {
+				os.Stdout.WriteString(setRegion() + barText())
+			}
+		}
+		outMu.Unlock()
+	}
+
+	// statusLine draws a transient message on the bottom row without
+	// disturbing the remote app's cursor, in the status bar if it's shown.
+	statusLine = func(text string) {
+		_, h, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			return
+		}
+		outMu.Lock()
+		defer outMu.Unlock()
+		if bar.on {
+			bar.note, bar.noteAt = text, time.Now()
+			time.AfterFunc(noteTTL, showBar)
+		}
+		if paused {
+			return
+		}
+		if bar.on {
+			os.Stdout.WriteString(pred.undraw() + barText() + pred.draw())
+			return
+		}
+		fmt.Fprintf(os.Stdout, "%s\0337\033[%d;1H\033[2K\033[7m %s \033[0m\0338%s", pred.undraw(), h, text, pred.draw())
 	}
-	sendResize()
 
 	// SIGWINCH
 	sigch := make(chan os.Signal, 1)
@@ -62,69 +327,621 @@ func RunAttach(api *APIClient, sessionName string) AttachResult {
 	defer signal.Stop(sigch)
 
 	done := make(chan AttachResult, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	// exitStatus is set before Exited is sent on done.
+	var exitStatus *int
+	// finish reports how the attach ended. The first result wins; later
+	// ones are dropped rather than left blocking. A session we deleted
+	// ends with us detaching, not with its exit.
+	finish := func(result AttachResult) {
+		mu.Lock()
+		if deleting && result == Exited {
+			result = Detached
+		}
+		mu.Unlock()
+		select {
+		case done <- result:
+		default:
+		}
+	}
+
+	// reconnect redials with backoff until it succeeds, the server rejects
+	// the session outright, or we run out of attempts. It reports exited
+	// if the session turns out to have gone, setting exitStatus.
+	reconnect := func() (ok, exited bool) {
+		delay := reconnectMinDelay
+		for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+			statusLine(fmt.Sprintf("reconnecting… (attempt %d/%d)", attempt, reconnectAttempts))
+			log.Info("reconnecting", "attempt", attempt, "delay", delay)
+			select {
+			case <-stop:
+				return false, false
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, reconnectMaxDelay)
+
+			mu.Lock()
+			name := sessionName
+			mu.Unlock()
+
+			// If the server is up but the session is gone, the process
+			// exited rather than the connection dropping. Servers that
+			// don't say so on the connection may still know how.
+			if sessions, err := api.ListSessions(ctx); err == nil && !hasSession(sessions, name) {
+				log.Info("session gone while reconnecting", "session", name)
+				if all, err := api.ListAllSessions(ctx); err == nil {
+					for _, s := range all {
+						if s.Name == name {
+							exitStatus = s.ExitStatus
+						}
+					}
+				}
+				return false, true
+			}
+			c, resp, err := dial(name)
+			if err != nil {
+				log.Warn("reconnect failed", "session", name, "attempt", attempt, "err", err)
+				opts.Errors.add(fmt.Errorf("reconnect to %s: %w", name, err))
+				// 4xx means the session is gone or we're not allowed in;
+				// retrying won't help.
+				if resp != nil && resp.StatusCode >= 400 && resp.StatusCode < 500 {
+					return false, false
+				}
+				continue
+			}
+			watch(c)
+			log.Info("reconnected", "session", name, "attempt", attempt)
+			mu.Lock()
+			conn = c
+			mu.Unlock()
+			outMu.Lock()
+			if !paused {
+				os.Stdout.WriteString("\033[2J\033[H" + barText())
+			}
+			pred.reset()
+			outMu.Unlock()
+			sendResize()
+			return true, false
+		}
+		log.Warn("gave up reconnecting", "attempts", reconnectAttempts)
+		return false, false
+	}
+
+	// switchTo moves the attachment to another session: dial it, then swap
+	// the connection so the reader picks it up once the old one closes.
+	switchTo := func(name string) bool {
+		mu.Lock()
+		reconnecting := conn == nil
+		mu.Unlock()
+		if reconnecting {
+			return false
+		}
+		c, _, err := dial(name)
+		if err != nil {
+			log.Warn("switch failed", "session", name, "err", err)
+			statusLine("cannot attach to " + name)
+			return false
+		}
+		log.Info("switched session", "session", name)
+		watch(c)
+		mu.Lock()
+		old := conn
+		conn, sessionName = c, name
+		mu.Unlock()
+		old.Close()
+		sb.Reset()
+		outMu.Lock()
+		bar.name, bar.viewers = name, 0
+		if !paused {
+			os.Stdout.WriteString("\033[2J\033[H" + barText())
+		}
+		pred.reset()
+		outMu.Unlock()
+		retitle(name)
+		sendResize()
+		return true
+	}
+
+	// cycle switches to the next (dir 1) or previous (dir -1) running
+	// session in the server's list.
+	cycle := func(dir int) {
+		sessions, err := api.ListSessions(ctx)
+		if err != nil {
+			statusLine("cannot list sessions")
+			return
+		}
+		mu.Lock()
+		cur := sessionName
+		mu.Unlock()
+		next := adjacentSession(sessions, cur, dir)
+		if next == "" || next == cur {
+			statusLine("no other sessions")
+			return
+		}
+		switchTo(next)
+	}
+
+	// create starts a session like the current one, running the same
+	// command in the same directory, and switches to it.
+	create := func() {
+		mu.Lock()
+		cur := sessionName
+		mu.Unlock()
+		newOpts := CreateOptions{Command: "claude"}
+		if sessions, err := api.ListSessions(ctx); err == nil {
+			for _, s := range sessions {
+				if s.Name == cur {
+					newOpts.Command, newOpts.Cwd, newOpts.Host, newOpts.Project = s.Command, s.Cwd, s.Host, s.Project
+				}
+			}
+		}
+		statusLine("creating session…")
+		s, err := api.CreateSession(ctx, newOpts)
+		if err != nil {
+			statusLine("cannot create session: " + err.Error())
+			return
+		}
+		switchTo(s.Name)
+	}
 
 	// WS -> stdout
 	go func() {
+		var osc52 osc52Filter
 		for {
-			_, msg, err := conn.ReadMessage()
-			if err != nil {
-				done <- Disconnected
+			mu.Lock()
+			c := conn
+			mu.Unlock()
+			var readErr error
+			for {
+				msg, err := c.Read()
+				if err != nil {
+					readErr = err
+					break
+				}
+				msg, copied := osc52.Filter(msg)
+				for _, text := range copied {
+					switch opts.Clipboard {
+					case clipboardOff:
+					case clipboardSystem:
+						go copyToClipboard(text)
+					default:
+						outMu.Lock()
+						writeOSC52(text)
+						outMu.Unlock()
+					}
+				}
+				sb.Write(msg)
+				outMu.Lock()
+				if rec != nil {
+					rec.Output(msg)
+				}
+				modes.Write(msg)
+				if !paused {
+					os.Stdout.WriteString(pred.undraw())
+					os.Stdout.Write(msg)
+					// The output may have cleared or scrolled the bar away.
+					os.Stdout.WriteString(barText())
+				}
+				expired := pred.output(msg)
+				if !paused {
+					os.Stdout.WriteString(pred.draw())
+				}
+				outMu.Unlock()
+				if expired {
+					repaint()
+				}
+				// Acknowledging output only once it's written keeps the
+				// server from sending faster than the terminal draws.
+				mu.Lock()
+				if conn == c {
+					c.Ack()
+				}
+				mu.Unlock()
+			}
+
+			mu.Lock()
+			if conn != c {
+				// Switched sessions; carry on with the new connection.
+				mu.Unlock()
+				continue
+			}
+			c.Close()
+			conn = nil
+			name := sessionName
+			mu.Unlock()
+			if status, ok := c.Exited(); ok {
+				log.Info("session exited", "session", name, "exit", exitText(status))
+				exitStatus = status
+				finish(Exited)
+				return
+			}
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			log.Warn("connection lost", "session", name, "err", readErr)
+			opts.Errors.add(fmt.Errorf("attach %s: connection lost: %w", name, readErr))
+			switch ok, exited := reconnect(); {
+			case exited:
+				finish(Exited)
+				return
+			case !ok:
+				finish(Disconnected)
+				return
+			}
+		}
+	}()
+
+	// Presence: announce other viewers coming and going, on servers that
+	// push viewer counts. Our own arrival, alone, isn't news. Renames of
+	// the session are followed here too, so reconnects find it.
+	go func() {
+		events, err := api.Events(ctx)
+		if err != nil {
+			return
+		}
+		shown := map[string]int{}
+		setViewers := func(n int) {
+			outMu.Lock()
+			bar.viewers = n
+			outMu.Unlock()
+			showBar()
+		}
+		if sessions, err := api.ListSessions(ctx); err == nil {
+			mu.Lock()
+			cur := sessionName
+			mu.Unlock()
+			for _, s := range sessions {
+				if s.Name != cur {
+					continue
+				}
+				setViewers(s.Viewers)
+				if s.Viewers > 1 {
+					statusLine(viewersLabel(s.Viewers))
+					shown[cur] = s.Viewers
+				}
+			}
+		}
+		for ev := range events {
+			mu.Lock()
+			cur := sessionName
+			renamed := ev.Type == "renamed" && ev.From == cur
+			if renamed {
+				sessionName = ev.Name
+			}
+			mu.Unlock()
+			if renamed {
+				outMu.Lock()
+				bar.name = ev.Name
+				outMu.Unlock()
+				showBar()
+				retitle(ev.Name)
+				continue
+			}
+			if ev.Type != "viewers" || ev.Name != cur || ev.Viewers == 0 {
+				continue
+			}
+			setViewers(ev.Viewers)
+			if ev.Viewers == shown[cur] {
+				continue
+			}
+			if shown[cur] > 0 || ev.Viewers > 1 {
+				statusLine(viewersLabel(ev.Viewers))
+			}
+			shown[cur] = ev.Viewers
+		}
+	}()
+
+	// Pings carry their send time so the pong tells us the round trip.
+	go func() {
+		t := time.NewTicker(pingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+			mu.Lock()
+			if conn != nil {
+				conn.Ping([]byte(strconv.FormatInt(time.Now().UnixNano(), 10)))
+			}
+			mu.Unlock()
+		}
+	}()
+
+	// SIGTSTP suspends attach as it would any job, handing the shell back the
+	// terminal as it was before; output waits on outMu until SIGCONT.
+	tstp := make(chan os.Signal, 1)
+	cont := make(chan os.Signal, 1)
+	signal.Notify(tstp, syscall.SIGTSTP)
+	signal.Notify(cont, syscall.SIGCONT)
+	defer signal.Stop(tstp)
+	defer signal.Stop(cont)
+	go func() {
+		suspended := false
+		for {
+			select {
+			case <-stop:
 				return
+			case <-tstp:
+				if suspended {
+					break
+				}
+				outMu.Lock()
+				os.Stdout.WriteString(pred.undraw() + modes.off())
+				if bar.on {
+					os.Stdout.WriteString(freeRegion)
+				}
+				term.Restore(fd, oldState)
+				suspended = true
+				// The Go runtime keeps its SIGTSTP handler even once
+				// we stop asking for the signal, so stop with SIGSTOP.
+				syscall.Kill(0, syscall.SIGSTOP)
+			case <-cont:
+				// Whoever stopped us, the shell has had the terminal since.
+				term.MakeRaw(fd)
+				if suspended {
+					os.Stdout.WriteString(modes.restore() + "\033[2J\033[H")
+					if bar.on {
+						os.Stdout.WriteString(setRegion() + barText())
+					}
+					pred.reset()
+					suspended = false
+					outMu.Unlock()
+				}
+				sendResize()
+				repaint()
 			}
-			os.Stdout.Write(msg)
 		}
 	}()
 
 	// SIGWINCH -> resize
 	go func() {
 		for range sigch {
+			if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+				outMu.Lock()
+				if rec != nil {
+					rec.Resize(w, bar.rows(h))
+				}
+				if bar.on && !paused {
+					os.Stdout.WriteString(pred.undraw() + bar.region(h) + barText() + pred.draw())
+				}
+				outMu.Unlock()
+			}
 			sendResize()
 		}
 	}()
 
-	// stdin -> WS with Ctrl-A interception
+	// send sends keystrokes, drawing any predicted echo first.
+	send := func(data []byte) {
+		outMu.Lock()
+		pred.typed(data)
+		if !paused {
+			os.Stdout.WriteString(pred.draw())
+		}
+		outMu.Unlock()
+		wsSend(data)
+	}
+
+	// stdin -> WS with prefix-key interception. A character split across
+	// reads is held back until it's whole: a text frame must be valid
+	// UTF-8, and the server may drop the connection otherwise.
 	go func() {
 		controlMode := false
+		var cm *copyMode
+		var ch *chooser
+		helpShown := false
+		confirmKill := false // asked whether to delete the session
+		var runes runeJoiner
+		var paste *pasteBuffer // the bracketed paste coming in, nil if none
 		buf := make([]byte, 4096)
 		for {
 			n, err := os.Stdin.Read(buf)
 			if err != nil {
-				done <- AttachError
+				finish(AttachError)
 				return
 			}
 
-			data := buf[:n]
+			data := runes.Next(buf[:n])
 			i := 0
 			for i < len(data) {
+				if cm != nil {
+					if cm.handle(data[i:]) {
+						cm = nil
+						os.Stdout.WriteString("\033[0m\033[2J\033[H\033[?25h")
+						setPaused(false)
+						redraw()
+					}
+					break
+				}
+				if ch != nil {
+					pick, done := ch.handle(data[i:])
+					if done {
+						current := ch.current
+						ch = nil
+						os.Stdout.WriteString("\033[0m\033[2J\033[H\033[?25h")
+						setPaused(false)
+						if pick == "" || pick == current || !switchTo(pick) {
+							redraw()
+						}
+					}
+					break
+				}
+				if helpShown {
+					// Any key closes it, and goes no further.
+					helpShown = false
+					os.Stdout.WriteString("\033[0m\033[2J\033[H\033[?25h")
+					setPaused(false)
+					repaint()
+					break
+				}
+				if confirmKill {
+					confirmKill = false
+					if data[i] != 'y' && data[i] != 'Y' {
+						statusLine("kept")
+						break
+					}
+					mu.Lock()
+					name := sessionName
+					deleting = true
+					mu.Unlock()
+					if err := api.DeleteSession(ctx, name); err != nil {
+						mu.Lock()
+						deleting = false
+						mu.Unlock()
+						statusLine("cannot delete " + name + ": " + err.Error())
+						break
+					}
+					finish(Detached)
+					return
+				}
+				if paste != nil {
+					n, done := paste.add(data[i:])
+					i += n
+					switch {
+					case done:
+						sendChunked(paste.buf, wsSend)
+						paste = nil
+					case len(paste.buf) >= pasteMax:
+						sendChunked(paste.flush(), wsSend)
+					}
+					continue
+				}
 				if controlMode {
 					controlMode = false
 					switch data[i] {
-					case 'd': // detach
-						done <- Detached
+					case keys.Detach:
+						finish(Detached)
 						return
-					case 0x01: // Ctrl-A again -> send literal
-						if err := wsSend([]byte{0x01}); err != nil {
-							done <- Disconnected
-							return
+					case '[': // copy mode
+						w, h, err := term.GetSize(int(os.Stdout.Fd()))
+						if err != nil {
+							break
+						}
+						setPaused(true)
+						cm = newCopyMode(sb.Lines(), w, h)
+						cm.render()
+					case 's': // session chooser
+						w, h, err := term.GetSize(int(os.Stdout.Fd()))
+						if err != nil {
+							break
+						}
+						sessions, err := api.ListSessions(ctx)
+						if err != nil {
+							statusLine("cannot list sessions")
+							break
+						}
+						mu.Lock()
+						cur := sessionName
+						mu.Unlock()
+						setPaused(true)
+						ch = newChooser(sessions, cur, w, h)
+						ch.render()
+					case 'R': // toggle recording
+						outMu.Lock()
+						recording := rec != nil
+						outMu.Unlock()
+						if recording {
+							path, err := stopRecording()
+							if err != nil {
+								statusLine("recording failed: " + err.Error())
+							} else {
+								statusLine("saved " + path)
+							}
+							break
+						}
+						mu.Lock()
+						path := defaultCastPath(sessionName)
+						mu.Unlock()
+						if err := startRecording(path); err != nil {
+							statusLine("cannot record: " + err.Error())
+						} else {
+							statusLine("recording to " + path + " (" + keys.PrefixName() + " R to stop)")
+						}
+					case '?': // help
+						w, h, err := term.GetSize(int(os.Stdout.Fd()))
+						if err != nil {
+							break
 						}
+						setPaused(true)
+						helpShown = true
+						os.Stdout.WriteString(attachHelpView(keys, w, h))
+					case 't': // show / hide the status bar
+						_, h, err := term.GetSize(int(os.Stdout.Fd()))
+						if err != nil {
+							break
+						}
+						outMu.Lock()
+						bar.on = !bar.on
+						os.Stdout.WriteString(pred.undraw() + bar.region(h))
+						if bar.on {
+							os.Stdout.WriteString(barText())
+						} else {
+							os.Stdout.WriteString(bar.clear(h))
+						}
+						os.Stdout.WriteString(pred.draw())
+						outMu.Unlock()
+						sendResize()
+					case 'r': // ask the server to redraw the screen
+						repaint()
+					case 'l': // clear the screen here, leaving the session be
+						outMu.Lock()
+						if !paused {
+							os.Stdout.WriteString("\033[0m\033[2J\033[3J\033[H")
+						}
+						pred.reset()
+						outMu.Unlock()
+					case 0x1a: // ctrl-z
+						select {
+						case tstp <- syscall.SIGTSTP:
+						default:
+						}
+					case 'c': // new session
+						create()
+					case 'k': // delete this session, once confirmed
+						mu.Lock()
+						name := sessionName
+						mu.Unlock()
+						confirmKill = true
+						statusLine("delete " + name + " and its output? (y/n)")
+					case 'n':
+						cycle(1)
+					case 'p':
+						cycle(-1)
+					case keys.Prefix: // prefix again -> send literal
+						send([]byte{keys.Prefix})
+					default:
+						name := "that key"
+						if b := data[i]; b < 0x7f && b != 0x1b {
+							name = rawKeyName(b)
+						}
+						statusLine("no binding for " + name + " (" + keys.PrefixName() + " ? for help)")
 					}
-					// unknown key: ignore
-					i++
+					// Skip the whole key, even one we don't know.
+					_, size := utf8.DecodeRune(data[i:])
+					i += size
 				} else {
-					// Scan forward to next Ctrl-A or end
+					// Scan forward to next prefix key, paste or end
 					j := i
-					for j < len(data) && data[j] != 0x01 {
+					for j < len(data) && data[j] != keys.Prefix && !bytes.HasPrefix(data[j:], pasteStart) {
 						j++
 					}
 					if j > i {
-						if err := wsSend(data[i:j]); err != nil {
-							done <- Disconnected
-							return
-						}
+						send(data[i:j])
 					}
-					if j < len(data) && data[j] == 0x01 {
+					switch {
+					case j == len(data):
+					case data[j] == keys.Prefix:
 						controlMode = true
 						j++
+					default:
+						paste = &pasteBuffer{}
+						outMu.Lock()
+						pred.interrupt()
+						outMu.Unlock()
 					}
 					i = j
 				}
@@ -132,5 +949,47 @@ func RunAttach(api *APIClient, sessionName string) AttachResult {
 		}
 	}()
 
-	return <-done
+	result := <-done
+	if result == Detached {
+		log.Info("detached")
+	}
+	return result, exitStatus
+}
+
+// exitText describes how a session ended, e.g. "session ended (exit 0)".
+func exitText(status *int) string {
+	if status == nil {
+		return "session ended"
+	}
+	return fmt.Sprintf("session ended (exit %d)", *status)
+}
+
+// adjacentSession returns the session dir places from name, wrapping around.
+// If name isn't in the list it starts from whichever end dir points away
+// from.
+func adjacentSession(sessions []Session, name string, dir int) string {
+	if len(sessions) == 0 {
+		return ""
+	}
+	i := -1
+	for j, s := range sessions {
+		if s.Name == name {
+			i = j
+			break
+		}
+	}
+	if i < 0 && dir < 0 {
+		i = 0
+	}
+	n := len(sessions)
+	return sessions[((i+dir)%n+n)%n].Name
+}
+
+func hasSession(sessions []Session, name string) bool {
+	for _, s := range sessions {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The attach protocol. Clients that offer attachProtocol as a WebSocket
+// subprotocol get binary frames, each a one-byte type and its payload.
+// Anyone else gets the original protocol: terminal bytes in text frames,
+// and resizes as {"resize":[cols,rows]}, which input that happens to be
+// that JSON is mistaken for.
+//
+// Clients offer legacyProtocol first. A server that doesn't know either
+// and picks the first one offered, as the Node server does, then speaks
+// the protocol it is named for; this server picks attachProtocol.
+const (
+	attachProtocol = "claude-host.attach.v2"
+	legacyProtocol = "claude-host.attach.v1"
+)
+
+type frameType byte
+
+const (
+	frameData    frameType = iota // terminal bytes, in either direction
+	frameResize                   // client: cols and rows as big-endian uint16s
+	framePing                     // client: payload is echoed back in a framePong
+	framePong                     // server
+	frameControl                  // JSON, a controlMessage
+	frameAck                      // client: total frameData bytes written out, as a big-endian uint64
+)
+
+// Flow control: the server sends a client at most ackWindow bytes of output
+// beyond what it has acknowledged, so a slow terminal holds the output back
+// on the server rather than in buffers along the way. Clients acknowledge
+// once ackEvery bytes have built up. A client that falls further behind
+// than the server will queue for it has the backlog dropped and the screen
+// repainted once it catches up.
+const (
+	ackWindow = 256 << 10
+	ackEvery  = ackWindow / 4
+)
+
+// controlMessage is the payload of a frameControl. Clients ignore types
+// and fields they don't know.
+type controlMessage struct {
+	Type   string `json:"type"`
+	Status *int   `json:"status,omitempty"` // with controlExited, if known
+}
+
+// Control message types.
+const (
+	// The server has started dropping output the client is too far
+	// behind to take.
+	controlCatchingUp = "catching_up"
+	// The server has repainted the screen in place of what it dropped.
+	controlCaughtUp = "caught_up"
+	// Client: redraw the whole screen.
+	controlRepaint = "repaint"
+	// The session's process has exited, and the connection is closing.
+	controlExited = "exited"
+)
+
+func encodeFrame(t frameType, payload []byte) []byte {
+	return append([]byte{byte(t)}, payload...)
+}
+
+func decodeFrame(msg []byte) (frameType, []byte, error) {
+	if len(msg) == 0 {
+		return 0, nil, errors.New("empty frame")
+	}
+	return frameType(msg[0]), msg[1:], nil
+}
+
+func resizePayload(cols, rows int) []byte {
+	return binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, uint16(cols)), uint16(rows))
+}
+
+func parseResize(p []byte) (cols, rows int, ok bool) {
+	if len(p) != 4 {
+		return 0, 0, false
+	}
+	return int(binary.BigEndian.Uint16(p)), int(binary.BigEndian.Uint16(p[2:])), true
+}
+
+func parseAck(p []byte) (n uint64, ok bool) {
+	if len(p) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(p), true
+}
+
+// attachConn is the client end of an attach connection, in whichever
+// protocol the server chose. Like the WebSocket underneath, it allows one
+// writer at a time, with Ping safe alongside it only on the legacy
+// protocol.
+type attachConn struct {
+	*websocket.Conn
+	binary    bool // speaking attachProtocol
+	onPong    func(payload []byte)
+	onControl func(controlMessage)
+	// Output bytes read, and acknowledged. Only Read and Ack touch them.
+	read, acked uint64
+	exited      *controlMessage // the server's controlExited, once it's said
+}
+
+// dialAttach connects to a session's terminal, at cols x rows if they're
+// known.
+func dialAttach(ctx context.Context, api *APIClient, name string, compress bool, cols, rows int) (*attachConn, *http.Response, error) {
+	url := api.WebSocketURL(name)
+	if cols > 0 && rows > 0 {
+		url = fmt.Sprintf("%s?cols=%d&rows=%d", url, cols, rows)
+	}
+	d := api.Dialer(name, compress)
+	d.Subprotocols = []string{legacyProtocol, attachProtocol}
+	c, resp, err := d.DialContext(ctx, url, api.WebSocketHeader(name))
+	if err != nil {
+		return nil, resp, err
+	}
+	// Only output is worth compressing; input is a few bytes at a time.
+	c.EnableWriteCompression(false)
+	return &attachConn{Conn: c, binary: c.Subprotocol() == attachProtocol}, resp, nil
+}
+
+// Send sends keystrokes.
+func (c *attachConn) Send(p []byte) error {
+	if c.binary {
+		return c.WriteMessage(websocket.BinaryMessage, encodeFrame(frameData, p))
+	}
+	return c.WriteMessage(websocket.TextMessage, p)
+}
+
+// Resize tells the server the terminal's size.
+func (c *attachConn) Resize(cols, rows int) error {
+	if c.binary {
+		return c.WriteMessage(websocket.BinaryMessage, encodeFrame(frameResize, resizePayload(cols, rows)))
+	}
+	msg, _ := json.Marshal(map[string][]int{"resize": {cols, rows}})
+	return c.WriteMessage(websocket.TextMessage, msg)
+}
+
+// Ping asks the server to echo payload, which arrives at the OnPong
+// function.
+func (c *attachConn) Ping(payload []byte) error {
+	if c.binary {
+		return c.WriteMessage(websocket.BinaryMessage, encodeFrame(framePing, payload))
+	}
+	return c.WriteControl(websocket.PingMessage, payload, time.Now().Add(pongWait))
+}
+
+// Repaint asks the server to redraw the whole screen, reporting false if
+// it can't be asked.
+func (c *attachConn) Repaint() bool {
+	if !c.binary {
+		return false
+	}
+	data, _ := json.Marshal(controlMessage{Type: controlRepaint})
+	return c.WriteMessage(websocket.BinaryMessage, encodeFrame(frameControl, data)) == nil
+}
+
+// OnPong sets the function told of each answered Ping. It is called from
+// Read.
+func (c *attachConn) OnPong(f func(payload []byte)) {
+	c.onPong = f
+	c.SetPongHandler(func(data string) error {
+		f([]byte(data))
+		return nil
+	})
+}
+
+// OnControl sets the function told of control messages. It is called from
+// Read.
+func (c *attachConn) OnControl(f func(controlMessage)) {
+	c.onControl = f
+}
+
+// Ack acknowledges the output Read has returned so far, once there's
+// enough of it to be worth a frame. Call it once the output is written out.
+func (c *attachConn) Ack() error {
+	if !c.binary || c.read-c.acked < ackEvery {
+		return nil
+	}
+	c.acked = c.read
+	return c.WriteMessage(websocket.BinaryMessage, encodeFrame(frameAck, binary.BigEndian.AppendUint64(nil, c.acked)))
+}
+
+// Exited reports whether the server said the session's process exited
+// before the connection closed, and its exit status if known. Call it from
+// the goroutine that calls Read.
+func (c *attachConn) Exited() (status *int, ok bool) {
+	if c.exited == nil {
+		return nil, false
+	}
+	return c.exited.Status, true
+}
+
+// Read returns the next piece of terminal output.
+func (c *attachConn) Read() ([]byte, error) {
+	for {
+		_, msg, err := c.ReadMessage()
+		if err != nil || !c.binary {
+			return msg, err
+		}
+		t, payload, err := decodeFrame(msg)
+		if err != nil {
+			return nil, err
+		}
+		switch t {
+		case frameData:
+			c.read += uint64(len(payload))
+			return payload, nil
+		case framePong:
+			if c.onPong != nil {
+				c.onPong(payload)
+			}
+		case frameControl:
+			var msg controlMessage
+			if json.Unmarshal(payload, &msg) != nil {
+				break
+			}
+			if msg.Type == controlExited {
+				c.exited = &msg
+			}
+			if c.onControl != nil {
+				c.onControl(msg)
+			}
+		}
+		// Other frames are for newer clients.
+	}
+}
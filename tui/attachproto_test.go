@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     frameType
+		payload []byte
+	}{
+		{"data", frameData, []byte("hello\r\n")},
+		{"empty data", frameData, nil},
+		{"resize", frameResize, resizePayload(200, 50)},
+		{"ping", framePing, []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"pong", framePong, []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"control", frameControl, []byte(`{"type":"exited","status":1}`)},
+		{"ack", frameAck, binary.BigEndian.AppendUint64(nil, 1<<40)},
+		{"unknown type", frameType(200), []byte("for a newer client")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ, payload, err := decodeFrame(encodeFrame(tt.typ, tt.payload))
+			if err != nil {
+				t.Fatalf("decodeFrame: %v", err)
+			}
+			if typ != tt.typ || !bytes.Equal(payload, tt.payload) {
+				t.Errorf("got %d %q, want %d %q", typ, payload, tt.typ, tt.payload)
+			}
+		})
+	}
+}
+
+func TestDecodeFrameEmpty(t *testing.T) {
+	if _, _, err := decodeFrame(nil); err == nil {
+		t.Error("decodeFrame(nil) succeeded")
+	}
+}
+
+func TestParseResize(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    []byte
+		cols, rows int
+		ok         bool
+	}{
+		{"round trip", resizePayload(200, 50), 200, 50, true},
+		{"largest", resizePayload(65535, 65535), 65535, 65535, true},
+		{"empty", nil, 0, 0, false},
+		{"truncated", resizePayload(200, 50)[:3], 0, 0, false},
+		{"oversized", append(resizePayload(200, 50), 0), 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, rows, ok := parseResize(tt.payload)
+			if cols != tt.cols || rows != tt.rows || ok != tt.ok {
+				t.Errorf("parseResize(%v) = %d, %d, %v; want %d, %d, %v", tt.payload, cols, rows, ok, tt.cols, tt.rows, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseAck(t *testing.T) {
+	ack := binary.BigEndian.AppendUint64(nil, ackWindow+1)
+	tests := []struct {
+		name    string
+		payload []byte
+		n       uint64
+		ok      bool
+	}{
+		{"round trip", ack, ackWindow + 1, true},
+		{"zero", make([]byte, 8), 0, true},
+		{"empty", nil, 0, false},
+		{"truncated", ack[:7], 0, false},
+		{"oversized", append(ack, 0), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ok := parseAck(tt.payload)
+			if n != tt.n || ok != tt.ok {
+				t.Errorf("parseAck(%v) = %d, %v; want %d, %v", tt.payload, n, ok, tt.n, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// AuditEvent is one entry in the embedded server's audit trail of what was
+// done to sessions.
+type AuditEvent struct {
+	ID      uint64 `json:"id"`
+	Time    string `json:"time"` // RFC 3339
+	Session string `json:"session"`
+	Owner   string `json:"owner,omitempty"`
+	Action  string `json:"action"`           // created, attached, detached, renamed, killed, restarted, archived, unarchived, deleted, summarized, uploaded or downloaded
+	User    string `json:"user,omitempty"`   // who did it
+	Remote  string `json:"remote,omitempty"` // the address they did it from
+	Detail  string `json:"detail,omitempty"` // e.g. the command, for created
+}
+
+// String describes the event without its time or session, e.g.
+// "attached by alice from 10.0.0.2".
+func (ev AuditEvent) String() string {
+	parts := []string{ev.Action}
+	if ev.Detail != "" {
+		parts = append(parts, ev.Detail)
+	}
+	if ev.User != "" {
+		parts = append(parts, "by "+ev.User)
+	}
+	if ev.Remote != "" {
+		parts = append(parts, "from "+ev.Remote)
+	}
+	return strings.Join(parts, " ")
+}
+
+// AuditLog returns recent events, oldest first: the named session's, or
+// with name "" those of every session the user can see. With after set it
+// returns the events since that one instead. The Node server keeps no
+// audit trail, and answers 404.
+func (a *APIClient) AuditLog(ctx context.Context, name string, after uint64, limit int) ([]AuditEvent, error) {
+	path := "/api/events"
+	if name != "" {
+		a, name = a.route(name)
+		path = "/api/sessions/" + url.PathEscape(name) + "/events"
+	}
+	q := url.Values{}
+	if after > 0 {
+		q.Set("after", strconv.FormatUint(after, 10))
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var events []AuditEvent
+	if err := decodeResponse(resp, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// eventsPollInterval is how often `events -f` asks for new events.
+const eventsPollInterval = time.Second
+
+// detailEvents is how many of a session's events the detail view shows.
+const detailEvents = 5
+
+type auditMsg struct {
+	name   string
+	events []AuditEvent
+}
+
+// fetchAudit gets the session's recent events for the detail view. Servers
+// without an audit trail leave the view without them.
+func (m DashboardModel) fetchAudit(name string) tea.Cmd {
+	ctx, api := m.ctx, m.api
+	return func() tea.Msg {
+		events, err := api.AuditLog(ctx, name, 0, detailEvents)
+		if err != nil {
+			return nil
+		}
+		return auditMsg{name: name, events: events}
+	}
+}
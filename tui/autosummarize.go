@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auto-summarize keeps descriptions fresh in the background: when enough of
+// a session's screen has changed since its last summary, it is summarized
+// again. Only one summary runs at a time, and a session is summarized at
+// most once per interval.
+
+const (
+	defaultAutoInterval = 5 * time.Minute
+	// autoChange is the share of a screen's lines that must be new since
+	// the last summary for it to count as a significant change.
+	autoChange = 0.4
+)
+
+type autoSummarizer struct {
+	api      *APIClient
+	notes    *notifications // where failures go
+	req      SummarizeRequest
+	interval time.Duration
+
+	mu       sync.Mutex
+	on       bool
+	sessions map[string]*summarized
+	cancel   context.CancelFunc // stops the summary in flight, nil if none
+}
+
+// summarized is what a session's screen looked like at its last summary.
+type summarized struct {
+	lines map[uint64]bool // hashes of its non-blank lines
+	at    time.Time
+}
+
+func newAutoSummarizer(api *APIClient, cfg SummarizeConfig, notes *notifications) *autoSummarizer {
+	a := &autoSummarizer{
+		api:      api,
+		notes:    notes,
+		req:      cfg.request(),
+		interval: defaultAutoInterval,
+		on:       cfg.Auto,
+		sessions: map[string]*summarized{},
+	}
+	if cfg.AutoMinutes != nil {
+		a.interval = time.Duration(*cfg.AutoMinutes) * time.Minute
+	}
+	return a
+}
+
+// Enabled reports whether auto-summarize is on.
+func (a *autoSummarizer) Enabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.on
+}
+
+// Toggle turns auto-summarize on or off, cancelling any summary in flight,
+// and reports whether it is now on.
+func (a *autoSummarizer) Toggle() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.on = !a.on
+	if a.cancel != nil {
+		a.cancel()
+		a.cancel = nil
+	}
+	return a.on
+}
+
+// observe is given every running session's screen each time the watcher
+// polls, and starts summarizing the first one that is due.
+func (a *autoSummarizer) observe(ctx context.Context, screens screensMsg) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for name := range a.sessions {
+		if _, ok := screens[name]; !ok {
+			delete(a.sessions, name)
+		}
+	}
+	if !a.on || a.cancel != nil {
+		return
+	}
+	now := time.Now()
+	for name, text := range screens {
+		lines := lineHashes(text)
+		last := a.sessions[name]
+		if last == nil {
+			// Start from what the session looks like now; its
+			// description is presumably current enough.
+			a.sessions[name] = &summarized{lines: lines, at: now}
+			continue
+		}
+		if now.Sub(last.at) < a.interval || changed(last.lines, lines) < autoChange {
+			continue
+		}
+		a.sessions[name] = &summarized{lines: lines, at: now}
+		ctx, cancel := context.WithCancel(ctx)
+		a.cancel = cancel
+		go func() {
+			defer cancel()
+			if _, err := a.api.Summarize(ctx, name, a.req); err != nil && ctx.Err() == nil {
+				a.notes.add(noteSummarize, name, "auto-summary of %s failed: %v", name, err)
+			}
+			a.mu.Lock()
+			if ctx.Err() == nil {
+				a.cancel = nil
+			}
+			a.mu.Unlock()
+		}()
+		return
+	}
+}
+
+func lineHashes(text string) map[uint64]bool {
+	lines := map[uint64]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write([]byte(line))
+		lines[h.Sum64()] = true
+	}
+	return lines
+}
+
+// changed is the share of now's lines that weren't in before.
+func changed(before, now map[uint64]bool) float64 {
+	if len(now) == 0 {
+		return 0
+	}
+	n := 0
+	for h := range now {
+		if !before[h] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(now))
+}
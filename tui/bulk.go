@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const bulkConcurrency = 8
+
+type bulkItem struct {
+	name string
+	err  error
+}
+
+// bulkDoneMsg reports the outcome of a bulk operation along with a fresh
+// session list.
+type bulkDoneMsg struct {
+	op       string
+	items    []bulkItem
+	sessions []Session
+	listErr  error
+}
+
+// runBulk applies fn to every name concurrently (at most bulkConcurrency at
+// a time) and then refreshes the list.
+func runBulk(ctx context.Context, op string, names []string, fn func(ctx context.Context, name string) error, list func(context.Context) ([]Session, error)) tea.Cmd {
+	return func() tea.Msg {
+		items := make([]bulkItem, len(names))
+		sem := make(chan struct{}, bulkConcurrency)
+		var wg sync.WaitGroup
+		for i, name := range names {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				items[i] = bulkItem{name: name, err: fn(ctx, name)}
+			}()
+		}
+		wg.Wait()
+		sessions, err := list(ctx)
+		return bulkDoneMsg{op: op, items: items, sessions: sessions, listErr: err}
+	}
+}
+
+// applyTagEdit adds tags, or removes those written with a leading "-".
+func applyTagEdit(tags []string, edit []string) []string {
+	out := slices.Clone(tags)
+	for _, t := range edit {
+		if rm, ok := strings.CutPrefix(t, "-"); ok {
+			out = slices.DeleteFunc(out, func(x string) bool { return x == rm })
+		} else if t != "" && !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (r bulkDoneMsg) View() string {
+	var ok, failed int
+	for _, it := range r.items {
+		if it.err == nil {
+			ok++
+		} else {
+			failed++
+		}
+	}
+	var s strings.Builder
+	summary := fmt.Sprintf("%s: %d ok", r.op, ok)
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d failed", failed)
+	}
+	s.WriteString("  " + dimStyle.Render(summary) + "\n")
+	for _, it := range r.items {
+		if it.err != nil {
+			s.WriteString("    " + errSty.Render(fmt.Sprintf("✗ %s: %v", it.name, it.err)) + "\n")
+		} else {
+			s.WriteString("    " + dimStyle.Render("✓ "+it.name) + "\n")
+		}
+	}
+	return s.String()
+}
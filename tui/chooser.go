@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// chooser is the ctrl-a s session picker. Like copy mode it draws straight
+// to the raw terminal while live output is paused.
+type chooser struct {
+	sessions []Session
+	current  string // session we're attached to
+	cursor   int
+	top      int
+	width    int
+	height   int
+}
+
+func newChooser(sessions []Session, current string, width, height int) *chooser {
+	c := &chooser{sessions: sessions, current: current, width: width, height: height}
+	for i, s := range sessions {
+		if s.Name == current {
+			c.cursor = i
+		}
+	}
+	c.move(0)
+	return c
+}
+
+// viewHeight leaves room for the title and the status line.
+func (c *chooser) viewHeight() int {
+	return max(1, c.height-3)
+}
+
+// handle processes raw stdin bytes. It reports done once the user picks a
+// session or backs out, in which case pick is "".
+func (c *chooser) handle(data []byte) (pick string, done bool) {
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		if b == 0x1b {
+			if i+2 < len(data) && data[i+1] == '[' {
+				switch data[i+2] {
+				case 'A':
+					c.move(-1)
+				case 'B':
+					c.move(1)
+				}
+				i += 2
+				continue
+			}
+			return "", true
+		}
+		switch {
+		case b == 'q' || b == 0x03:
+			return "", true
+		case b == 'k':
+			c.move(-1)
+		case b == 'j':
+			c.move(1)
+		case b == 'g':
+			c.move(-len(c.sessions))
+		case b == 'G':
+			c.move(len(c.sessions))
+		case b == '\r' || b == '\n':
+			if len(c.sessions) == 0 {
+				return "", true
+			}
+			return c.sessions[c.cursor].Name, true
+		case b >= '0' && b <= '9':
+			// Digits pick directly, like tmux's choose-tree.
+			if n := int(b - '0'); n < len(c.sessions) {
+				return c.sessions[n].Name, true
+			}
+		}
+	}
+	c.render()
+	return "", false
+}
+
+func (c *chooser) move(delta int) {
+	c.cursor = max(0, min(len(c.sessions)-1, c.cursor+delta))
+	page := c.viewHeight()
+	if c.cursor < c.top {
+		c.top = c.cursor
+	} else if c.cursor >= c.top+page {
+		c.top = c.cursor - page + 1
+	}
+}
+
+func (c *chooser) render() {
+	var b strings.Builder
+	b.WriteString("\033[?25l\033[H\033[2J")
+	b.WriteString("\033[1m choose session\033[0m\r\n\r\n")
+	if len(c.sessions) == 0 {
+		b.WriteString(" no running sessions\r\n")
+	}
+	end := min(len(c.sessions), c.top+c.viewHeight())
+	for i := c.top; i < end; i++ {
+		s := c.sessions[i]
+		key := " "
+		if i < 10 {
+			key = fmt.Sprint(i)
+		}
+		mark := " "
+		if s.Name == c.current {
+			mark = "*"
+		}
+		line := fmt.Sprintf(" %s %s %s %s %s", key, mark, pad(s.Name, 22), pad(s.Command, 10), timeAgo(s.CreatedAt))
+		if s.Description != "" {
+			line += "  " + s.Description
+		}
+		line = truncate(line, c.width)
+		if i == c.cursor {
+			b.WriteString("\033[7m" + pad(line, c.width) + "\033[0m")
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\r\n")
+	}
+	status := " SESSIONS  j/k move  enter switch  0-9 pick  q cancel "
+	fmt.Fprintf(&b, "\033[%d;1H\033[7m%s\033[0m", c.height, truncate(status, c.width))
+	os.Stdout.WriteString(b.String())
+}
@@ -0,0 +1,1228 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// cli holds state shared by all subcommands, populated before any of them
+// run.
+type cli struct {
+	baseURL  string
+	allHosts bool
+	host     string    // active [hosts] profile, allHosts, or "" when the URL came from elsewhere
+	tls      TLSConfig // from flags, overriding the config file
+	ssh      string    // --ssh destination, overriding the host profile's
+	proxy    string    // --proxy, overriding the config file
+	sets     []string  // --set key=value, overriding the file and environment
+	verbose  bool      // --verbose, logging to logPath
+	from     string    // where baseURL came from, for config show
+	tunnels  map[string]*sshTunnel
+	cfg      Config
+	keys     KeyMap
+	approve  *approver
+	columns  []string
+	api      *APIClient
+	log      *slog.Logger // the debug log, discardLog without --verbose
+	errlog   *errorLog
+}
+
+func newRootCmd() *cobra.Command {
+	c := &cli{log: discardLog}
+
+	root := &cobra.Command{
+		Use:   "claude-host [url]",
+		Short: "Terminal client for claude-host",
+		Long: "Terminal client for claude-host. With no subcommand, opens the interactive dashboard.\n\n" +
+			"The server URL defaults to $CLAUDE_HOST or http://localhost:3000.",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.setup()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Bare `claude-host <url>` is kept for backwards compatibility.
+			if len(args) == 1 {
+				c.baseURL, c.host = args[0], ""
+				api, err := c.client("", c.baseURL)
+				if err != nil {
+					return err
+				}
+				c.api = api
+			}
+			useTheme(c.cfg.Theme)
+			opts := c.tuiOptions()
+			if c.cfg.Dashboard.AttachCommand != "" {
+				opts.external = c.openAttachCommand
+			}
+			return runTUI(cmd.Context(), c.api, opts)
+		},
+	}
+	root.PersistentFlags().StringVar(&c.baseURL, "url", "", "server URL, http(s):// or unix:///path/to.sock (default $CLAUDE_HOST or http://localhost:3000)")
+	root.PersistentFlags().StringVarP(&c.host, "host", "H", "", "server profile from [hosts] in the config file")
+	root.PersistentFlags().BoolVarP(&c.allHosts, "all-hosts", "A", false, "aggregate every [hosts] profile into one session list")
+	root.MarkFlagsMutuallyExclusive("url", "host", "all-hosts")
+	root.PersistentFlags().StringVar(&c.ssh, "ssh", "", "reach the server through an SSH tunnel to [user@]host[:port]")
+	root.PersistentFlags().StringVar(&c.proxy, "proxy", "", `proxy URL, or "direct" to ignore $HTTPS_PROXY (default from the environment)`)
+	root.PersistentFlags().StringVar(&c.tls.CA, "ca", "", "PEM bundle of extra CAs to trust for https servers")
+	root.PersistentFlags().StringVar(&c.tls.Cert, "cert", "", "client certificate for mutual TLS")
+	root.PersistentFlags().StringVar(&c.tls.Key, "key", "", "private key for --cert")
+	root.PersistentFlags().BoolVar(&c.tls.InsecureSkipVerify, "insecure-skip-verify", false, "don't verify the server's certificate")
+	root.PersistentFlags().StringArrayVar(&c.sets, "set", nil, "override a config setting, e.g. --set dashboard.poll_seconds=10 (repeatable)")
+	root.PersistentFlags().BoolVar(&c.verbose, "verbose", false, "log requests, connections and errors to "+logPath())
+
+	root.AddCommand(
+		c.lsCmd(),
+		c.snapshotCmd(),
+		c.logsCmd(),
+		c.eventsCmd(),
+		c.exportCmd(),
+		c.attachCmd(),
+		c.shareCmd(),
+		c.newCmd(),
+		c.upCmd(),
+		c.rmCmd(),
+		c.killCmd(),
+		c.archiveCmd(),
+		c.restartCmd(),
+		c.execCmd(),
+		c.cpCmd(),
+		c.tmuxCmd(),
+		c.waitCmd(),
+		c.playCmd(),
+		c.serveCmd(),
+		c.webhookCmd(),
+		c.doctorCmd(),
+		c.configCmd(),
+	)
+	return root
+}
+
+func (c *cli) setup() error {
+	c.errlog = newErrorLog(c.log, "")
+	if c.verbose {
+		log, err := openLog(logPath())
+		if err != nil {
+			return fmt.Errorf("--verbose: %w", err)
+		}
+		c.log, c.errlog = log, newErrorLog(log, logPath())
+	}
+	if err := c.loadConfig(); err != nil {
+		return err
+	}
+	if c.host == allHosts {
+		_, err := c.connect(allHosts)
+		return err
+	}
+	var err error
+	c.api, err = c.client(c.host, c.baseURL)
+	return err
+}
+
+// loadConfig reads the config and resolves which server to use, without
+// connecting to it.
+func (c *cli) loadConfig() error {
+	cfg, err := LoadConfig(c.sets)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	// The server comes from --url, --host, $CLAUDE_HOST, default_host,
+	// then localhost, in that order.
+	switch {
+	case c.baseURL != "":
+		c.from = "--url"
+	case c.allHosts:
+		c.host, c.from = allHosts, "--all-hosts"
+	case c.host != "":
+		h, ok := cfg.Hosts[c.host]
+		if !ok {
+			return fmt.Errorf("no host %q in %s", c.host, configPath())
+		}
+		c.baseURL, c.from = h.URL, "--host "+c.host
+	case os.Getenv("CLAUDE_HOST") != "":
+		c.baseURL, c.from = os.Getenv("CLAUDE_HOST"), "$CLAUDE_HOST"
+	case cfg.DefaultHost != "":
+		c.host = cfg.DefaultHost
+		c.baseURL, c.from = cfg.Hosts[c.host].URL, "default_host"
+	default:
+		c.baseURL, c.from = "http://localhost:3000", "default"
+	}
+	keys, err := NewKeyMap(cfg.Keys)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	approve, err := newApprover(cfg.Approve)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	columns, err := parseColumns(cfg.Dashboard.Columns)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	c.cfg = cfg
+	c.keys = keys
+	c.approve = approve
+	c.columns = columns
+	return nil
+}
+
+// client builds the client for a server with a host profile's token, TLS
+// and SSH settings ("" for none).
+func (c *cli) client(host, url string) (*APIClient, error) {
+	conn := ConnOptions{Retry: newRetryPolicy(c.cfg.Retry), Log: c.log}
+	var err error
+	if conn.TLS, err = loadTLS(c.tlsFor(host)); err != nil {
+		return nil, err
+	}
+	if conn.Proxy, err = parseProxy(c.proxyFor(host)); err != nil {
+		return nil, err
+	}
+	dest := c.ssh
+	if dest == "" {
+		dest = c.cfg.Hosts[host].SSH
+	}
+	if dest != "" {
+		if conn.SSH, err = c.tunnel(dest); err != nil {
+			return nil, err
+		}
+	}
+	return NewAPIClient(url, c.tokenFor(host), conn), nil
+}
+
+// tunnel returns the SSH tunnel to dest, connecting on first use. Tunnels
+// stay open for the life of the process, so switching back to a host is
+// instant.
+func (c *cli) tunnel(dest string) (*sshTunnel, error) {
+	if t := c.tunnels[dest]; t != nil {
+		return t, nil
+	}
+	t, err := dialSSH(dest)
+	if err != nil {
+		return nil, err
+	}
+	if c.tunnels == nil {
+		c.tunnels = map[string]*sshTunnel{}
+	}
+	c.tunnels[dest] = t
+	return t, nil
+}
+
+// tlsFor resolves TLS settings: a host profile's [hosts.<name>.tls] replaces
+// [tls], and flags override either field by field.
+func (c *cli) tlsFor(host string) TLSConfig {
+	cfg := c.cfg.TLS
+	if h := c.cfg.Hosts[host]; h.TLS != nil {
+		cfg = *h.TLS
+	}
+	if c.tls.CA != "" {
+		cfg.CA = c.tls.CA
+	}
+	if c.tls.Cert != "" || c.tls.Key != "" {
+		cfg.Cert, cfg.Key = c.tls.Cert, c.tls.Key
+	}
+	cfg.InsecureSkipVerify = cfg.InsecureSkipVerify || c.tls.InsecureSkipVerify
+	return cfg
+}
+
+// proxyFor resolves the proxy setting: --proxy, the host profile's, then the
+// top-level one.
+func (c *cli) proxyFor(host string) string {
+	if c.proxy != "" {
+		return c.proxy
+	}
+	if p := c.cfg.Hosts[host].Proxy; p != "" {
+		return p
+	}
+	return c.cfg.Proxy
+}
+
+// tokenFor resolves the bearer token: $CLAUDE_HOST_TOKEN wins over the host
+// profile's token, which wins over [auth].
+func (c *cli) tokenFor(host string) string {
+	if v := os.Getenv("CLAUDE_HOST_TOKEN"); v != "" {
+		return v
+	}
+	if t := c.cfg.Hosts[host].Token; t != "" {
+		return t
+	}
+	return c.cfg.Auth.Token
+}
+
+// connect switches to the named host profile, or to all of them, returning
+// its client.
+func (c *cli) connect(host string) (*APIClient, error) {
+	if host == allHosts {
+		if len(c.cfg.Hosts) == 0 {
+			return nil, fmt.Errorf("no [hosts] profiles in %s", configPath())
+		}
+		peers := map[string]*APIClient{}
+		for name, h := range c.cfg.Hosts {
+			p, err := c.client(name, h.URL)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			peers[name] = p
+		}
+		c.host, c.baseURL = allHosts, ""
+		c.api = NewMultiClient(peers)
+		return c.api, nil
+	}
+	h, ok := c.cfg.Hosts[host]
+	if !ok {
+		return nil, fmt.Errorf("no host %q in %s", host, configPath())
+	}
+	api, err := c.client(host, h.URL)
+	if err != nil {
+		return nil, err
+	}
+	c.host, c.baseURL, c.api = host, h.URL, api
+	return api, nil
+}
+
+// tuiOptions configures the dashboard.
+func (c *cli) tuiOptions() tuiOptions {
+	return tuiOptions{
+		keys:      c.keys,
+		approve:   c.approve,
+		attach:    c.attachOptions(),
+		notify:    c.cfg.Notify,
+		hooks:     c.cfg.Hooks,
+		columns:   c.columns,
+		host:      c.host,
+		hosts:     c.cfg.Hosts,
+		templates: c.cfg.Templates,
+		summarize: c.cfg.Summarize,
+		editor:    c.cfg.Editor,
+		github:    c.cfg.GitHub,
+		poll:      c.cfg.Dashboard.pollInterval(),
+		connect:   c.connect,
+		errlog:    c.errlog,
+	}
+}
+
+func (c *cli) attachOptions() AttachOptions {
+	opts := AttachOptions{Clipboard: c.cfg.Attach.Clipboard, Compress: true, Predict: c.cfg.Attach.Predict, StatusBar: c.cfg.Attach.StatusBar, Errors: c.errlog}
+	if c.cfg.Attach.Compression != nil {
+		opts.Compress = *c.cfg.Attach.Compression
+	}
+	return opts
+}
+
+func (c *cli) lsCmd() *cobra.Command {
+	var asJSON, allUsers, archived bool
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List running sessions",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.api.SetAllUsers(allUsers)
+			list := c.api.ListSessions
+			if archived {
+				c.api.SetShowArchived(true)
+				list = c.api.ListAllSessions
+			}
+			sessions, err := list(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if archived {
+				sessions = slices.DeleteFunc(sessions, func(s Session) bool { return !s.Archived })
+			}
+			if asJSON {
+				if sessions == nil {
+					sessions = []Session{}
+				}
+				return printJSON(sessions)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			if allUsers {
+				fmt.Fprintln(w, "NAME\tOWNER\tCOMMAND\tCREATED\tDESCRIPTION")
+			} else {
+				fmt.Fprintln(w, "NAME\tCOMMAND\tCREATED\tDESCRIPTION")
+			}
+			for _, s := range sessions {
+				if allUsers {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Owner, s.Command, timeAgo(s.CreatedAt), s.Description)
+				} else {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Command, timeAgo(s.CreatedAt), s.Description)
+				}
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print sessions as JSON")
+	cmd.Flags().BoolVar(&allUsers, "all-users", false, "list every user's sessions (admins only)")
+	cmd.Flags().BoolVar(&archived, "archived", false, "list archived sessions instead")
+	return cmd
+}
+
+func (c *cli) snapshotCmd() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "snapshot <name>",
+		Short: "Print the current screen contents of a session",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			text, err := c.api.GetSnapshot(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				return printJSON(map[string]string{"name": args[0], "text": text})
+			}
+			fmt.Print(text)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the snapshot as JSON")
+	return cmd
+}
+
+func (c *cli) logsCmd() *cobra.Command {
+	var lines int
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "logs <name>",
+		Short: "Print a session's output, including scrollback",
+		Long: "Print a session's output, including scrollback. With -f, stream its live output\n" +
+			"to stdout until the session exits, without touching the terminal or reading stdin.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if follow {
+				return followSession(cmd.Context(), c.api, args[0], os.Stdout, c.attachOptions().Compress)
+			}
+			text, err := c.api.GetScrollback(cmd.Context(), args[0], lines)
+			if err != nil {
+				return err
+			}
+			fmt.Print(text)
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&lines, "lines", "n", 0, "lines of scrollback to fetch (default: server's, 2000)")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "stream live output until the session exits")
+	return cmd
+}
+
+func (c *cli) eventsCmd() *cobra.Command {
+	var limit int
+	var follow, asJSON bool
+	cmd := &cobra.Command{
+		Use:   "events [name]",
+		Short: "Print who created, attached to, deleted or summarized sessions, and from where",
+		Long: "Print the server's audit trail of what was done to sessions: every session the user can\n" +
+			"see, or one session's. With -f, keep printing events as they happen.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) > 0 {
+				name = args[0]
+			}
+			show := func(events []AuditEvent) error {
+				for _, ev := range events {
+					if asJSON {
+						if err := json.NewEncoder(os.Stdout).Encode(ev); err != nil {
+							return err
+						}
+						continue
+					}
+					when := ev.Time
+					if t := parseTime(ev.Time); !t.IsZero() {
+						when = t.Local().Format("2006-01-02 15:04:05")
+					}
+					fmt.Printf("%s  %-22s %s\n", when, ev.Session, ev)
+				}
+				return nil
+			}
+			events, err := c.api.AuditLog(cmd.Context(), name, 0, limit)
+			if e := asAPIError(err); e != nil && e.NotFound() && name == "" {
+				return errors.New("the server keeps no event log")
+			}
+			if err != nil {
+				return err
+			}
+			if err := show(events); err != nil || !follow {
+				return err
+			}
+			var last uint64
+			if len(events) > 0 {
+				last = events[len(events)-1].ID
+			}
+			t := time.NewTicker(eventsPollInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-cmd.Context().Done():
+					return nil
+				case <-t.C:
+				}
+				events, err := c.api.AuditLog(cmd.Context(), name, last, 0)
+				if err != nil {
+					if cmd.Context().Err() != nil {
+						return nil
+					}
+					return err
+				}
+				if err := show(events); err != nil {
+					return err
+				}
+				if len(events) > 0 {
+					last = events[len(events)-1].ID
+				}
+			}
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "lines", "n", 20, "how many past events to print")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing new events")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print events as JSON, one per line")
+	return cmd
+}
+
+func (c *cli) exportCmd() *cobra.Command {
+	var format, output string
+	cmd := &cobra.Command{
+		Use:   "export <name>",
+		Short: "Write a session's full output to a transcript file",
+		Long: "Write a session's full scrollback, with terminal escapes stripped, to a Markdown,\n" +
+			"text or HTML file. The file defaults to <name>-<timestamp>.<format>; -o - prints it.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := exportSession(cmd.Context(), c.api, args[0], format, output)
+			if err != nil {
+				return err
+			}
+			if path != "-" {
+				fmt.Fprintln(os.Stderr, "wrote", path)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "md", "transcript format: md, txt or html")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write, - for stdout")
+	return cmd
+}
+
+func (c *cli) attachCmd() *cobra.Command {
+	var record, link string
+	var tmuxWindow bool
+	cmd := &cobra.Command{
+		Use:   "attach <name> | --link <url>",
+		Short: "Attach this terminal to a session",
+		Long: "Attach this terminal to a session, by name or with a share link from `claude-host share`.\n" +
+			"A link carries its own server and token; --ssh, --proxy and the TLS flags still apply.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if link != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if link != "" {
+				base, name, token, err := parseLink(link)
+				if err != nil {
+					return err
+				}
+				api, err := c.client("", base)
+				if err != nil {
+					return err
+				}
+				c.api = api.WithToken(token)
+				args = []string{name}
+			}
+			useTheme(c.cfg.Theme)
+			opts := c.attachOptions()
+			if record != "" {
+				w, h, err := term.GetSize(int(os.Stdout.Fd()))
+				if err != nil {
+					w, h = 80, 24
+				}
+				rec, err := newRecorder(record, w, h, args[0])
+				if err != nil {
+					return fmt.Errorf("record: %w", err)
+				}
+				opts.Recorder = rec
+			}
+			opts.Tmux = tmuxWindow
+			return c.attach(cmd.Context(), args[0], opts)
+		},
+	}
+	cmd.Flags().StringVar(&record, "record", "", "record the session to an asciicast v2 file")
+	cmd.Flags().StringVar(&link, "link", "", "attach with a share link instead of a name")
+	cmd.Flags().BoolVar(&tmuxWindow, "tmux-window", false, "keep the tmux window named after the session")
+	cmd.Flags().MarkHidden("tmux-window")
+	return cmd
+}
+
+func (c *cli) tmuxCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tmux [name]",
+		Short: "Run the dashboard in tmux, attaching in tmux windows",
+		Long: "Run the dashboard inside your tmux, opening each attach in a new window instead of\n" +
+			"taking over the terminal, named after its session. Attaching to a session that already\n" +
+			"has a window selects it. With a name, just open that session's window.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !inTmux() {
+				return errors.New("not inside tmux: start tmux, then run this from it")
+			}
+			if len(args) == 1 {
+				return c.openTmux(cmd.Context(), c.host, args[0])
+			}
+			useTheme(c.cfg.Theme)
+			opts := c.tuiOptions()
+			opts.external = c.openTmux
+			return runTUI(cmd.Context(), c.api, opts)
+		},
+	}
+}
+
+func (c *cli) shareCmd() *cobra.Command {
+	var ttl time.Duration
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "share <name>",
+		Short: "Print a time-limited link others can attach to a session with",
+		Long: "Print a signed link to a session. Anyone holding it can attach with\n" +
+			"`claude-host attach --link <url>` until it expires, without an account of their own,\n" +
+			"but can't reach any other session or change this one's record.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			link, err := c.api.Share(cmd.Context(), args[0], ttl)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(link)
+			}
+			fmt.Println(link.URL)
+			if t, err := time.Parse(time.RFC3339, link.ExpiresAt); err == nil {
+				fmt.Fprintf(os.Stderr, "expires %s\n", t.Local().Format("2006-01-02 15:04"))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&ttl, "ttl", time.Hour, "how long the link works, at most 168h")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the link, token and expiry as JSON")
+	return cmd
+}
+
+func (c *cli) attach(ctx context.Context, name string, opts AttachOptions) error {
+	fmt.Print("\033[2J\033[H")
+	result, status := attachWithTitle(ctx, c.api, name, c.keys, opts)
+	fmt.Print("\033[2J\033[H")
+	switch result {
+	case Exited:
+		fmt.Fprintln(os.Stderr, exitText(status))
+	case Disconnected:
+		return errors.New("connection lost")
+	case AttachError:
+		return fmt.Errorf("cannot attach to %s", name)
+	}
+	return nil
+}
+
+func (c *cli) newCmd() *cobra.Command {
+	var command, description, cwd, template, project, issue string
+	var env []string
+	var attach, asJSON bool
+	cmd := &cobra.Command{
+		Use:   "new",
+		Short: "Create a session and print its name",
+		Long: "Create a session and print its name. With --template it starts from a [templates.<name>]\n" +
+			"preset: flags replace its command and directory, add to its env, and -d follows its\n" +
+			"description prefix.\n\n" +
+			"With --from-issue owner/repo#123 it starts Claude on a GitHub issue instead: in the\n" +
+			"repository's directory from [github] repos, with the issue as its first prompt, named\n" +
+			"after the issue. Private repositories need [github] token or $GITHUB_TOKEN.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			envMap, err := parseEnv(env)
+			if err != nil {
+				return err
+			}
+			opts := CreateOptions{Command: command}
+			if template != "" {
+				t, ok := c.cfg.Templates[template]
+				if !ok {
+					return fmt.Errorf("no template %q in %s", template, configPath())
+				}
+				opts = t.options()
+				if cmd.Flags().Changed("cmd") {
+					opts.Command = command
+				}
+			}
+			opts.Description += description
+			if cwd != "" {
+				opts.Cwd = cwd
+			}
+			if project != "" {
+				opts.Project = project
+			}
+			if len(envMap) > 0 {
+				opts.Env = maps.Clone(opts.Env)
+				if opts.Env == nil {
+					opts.Env = map[string]string{}
+				}
+				maps.Copy(opts.Env, envMap)
+			}
+			var s *Session
+			if issue != "" {
+				s, err = createFromIssue(cmd.Context(), c.api, c.cfg.GitHub, issue, opts)
+				if s != nil && err != nil {
+					fmt.Fprintln(os.Stderr, "warning:", err)
+					err = nil
+				}
+			} else {
+				s, err = c.api.CreateSession(cmd.Context(), opts)
+			}
+			if err != nil {
+				return err
+			}
+			if attach {
+				return c.attach(cmd.Context(), s.Name, c.attachOptions())
+			}
+			if asJSON {
+				return printJSON(s)
+			}
+			fmt.Println(s.Name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&command, "cmd", "claude", "command to run in the session")
+	cmd.Flags().StringVarP(&description, "description", "d", "", "session description")
+	cmd.Flags().StringVarP(&cwd, "cwd", "C", "", "working directory for the command")
+	cmd.Flags().StringVarP(&project, "project", "p", "", "project to group the session under")
+	cmd.Flags().StringArrayVarP(&env, "env", "e", nil, "environment override as KEY=value (repeatable)")
+	cmd.Flags().StringVarP(&template, "template", "t", "", "start from a [templates] preset in the config file")
+	cmd.Flags().StringVar(&issue, "from-issue", "", "start Claude on a GitHub issue, owner/repo#123 or its URL")
+	cmd.Flags().BoolVarP(&attach, "attach", "a", false, "attach to the session after creating it")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the created session as JSON")
+	cmd.MarkFlagsMutuallyExclusive("from-issue", "template")
+	cmd.MarkFlagsMutuallyExclusive("from-issue", "cmd")
+	return cmd
+}
+
+func (c *cli) upCmd() *cobra.Command {
+	var dryRun, recreate, prune bool
+	cmd := &cobra.Command{
+		Use:   "up <manifest.yaml>",
+		Short: "Create or reconcile the sessions a YAML manifest declares",
+		Long: "Bring the sessions a manifest declares into being: create those that don't exist, restart\n" +
+			"those that have exited and bring running ones' descriptions and projects in line. Running\n" +
+			"it again changes only what differs, so it can be rerun as the manifest changes.\n\n" +
+			"A running session whose command, prompt or env no longer match is left alone unless\n" +
+			"--recreate replaces it. --prune deletes sessions in the manifest's projects it no longer\n" +
+			"declares. Each session takes name, and optionally command (default claude), prompt, cwd,\n" +
+			"env, description, project and host; a top-level project applies to them all:\n\n" +
+			"  project: sprint-12\n" +
+			"  sessions:\n" +
+			"    - name: auth\n" +
+			"      cwd: ~/src/api\n" +
+			"      prompt: Move the login handler onto the new session store.\n" +
+			"      env:\n" +
+			"        CLAUDE_MODEL: opus",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := loadManifest(args[0])
+			if err != nil {
+				return err
+			}
+			c.api.SetShowArchived(true)
+			sessions, err := c.api.ListAllSessions(cmd.Context())
+			if err != nil {
+				return err
+			}
+			var failed bool
+			for _, st := range planUp(m, sessions, c.api, recreate, prune) {
+				if !dryRun {
+					if err := st.apply(cmd.Context(), c.api); err != nil {
+						fmt.Fprintf(os.Stderr, "%s: %v\n", st.Name, err)
+						failed = true
+						continue
+					}
+				}
+				line := fmt.Sprintf("%-10s %s", st.Action, st.Name)
+				if st.Detail != "" {
+					line += "  (" + st.Detail + ")"
+				}
+				fmt.Println(line)
+			}
+			if failed {
+				return errors.New("some sessions could not be brought up")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "print what would change without changing it")
+	cmd.Flags().BoolVar(&recreate, "recreate", false, "replace running sessions whose command or env changed")
+	cmd.Flags().BoolVar(&prune, "prune", false, "delete sessions in the manifest's projects that it doesn't declare")
+	return cmd
+}
+
+func (c *cli) rmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm <name>...",
+		Aliases: []string{"delete"},
+		Short:   "Delete one or more sessions entirely, output and all (see kill)",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var failed bool
+			for _, name := range args {
+				if err := c.api.DeleteSession(cmd.Context(), name); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+					failed = true
+				}
+			}
+			if failed {
+				return errors.New("some sessions could not be deleted")
+			}
+			return nil
+		},
+	}
+}
+
+func (c *cli) killCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill <name>...",
+		Short: "Stop sessions' processes, keeping the sessions and their output",
+		Long:  "Stop sessions' processes. Unlike rm, the sessions stay, exited, so their output can still\nbe read with logs or export and the command run again with restart.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var failed bool
+			for _, name := range args {
+				if err := c.api.KillSession(cmd.Context(), name); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+					failed = true
+				}
+			}
+			if failed {
+				return errors.New("some sessions could not be killed")
+			}
+			return nil
+		},
+	}
+}
+
+func (c *cli) archiveCmd() *cobra.Command {
+	var undo bool
+	cmd := &cobra.Command{
+		Use:   "archive <name>...",
+		Short: "Hide sessions from the list, stopping any that are running",
+		Long: "Hide sessions from the list, stopping any that are running. Their output can still be read\n" +
+			"with logs or export; ls --archived lists them and --undo brings them back.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			op, verb := c.api.ArchiveSession, "archived"
+			if undo {
+				op, verb = c.api.UnarchiveSession, "unarchived"
+			}
+			var failed bool
+			for _, name := range args {
+				if err := op(cmd.Context(), name); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+					failed = true
+				}
+			}
+			if failed {
+				return fmt.Errorf("some sessions could not be %s", verb)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&undo, "undo", false, "unarchive the sessions instead")
+	return cmd
+}
+
+func (c *cli) restartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restart <name>...",
+		Short: "Kill and relaunch sessions' commands, keeping their names and scrollback",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var failed bool
+			for _, name := range args {
+				if err := c.api.RestartSession(cmd.Context(), name); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+					failed = true
+				}
+			}
+			if failed {
+				return errors.New("some sessions could not be restarted")
+			}
+			return nil
+		},
+	}
+}
+
+func (c *cli) execCmd() *cobra.Command {
+	var description string
+	var rm bool
+	cmd := &cobra.Command{
+		Use:   "exec [flags] -- <command>...",
+		Short: "Run a command in a new session and wait for it",
+		Long: "Run a command in a new session, stream its output to stdout and exit with its exit status.\n" +
+			"As with ssh, the arguments are joined with spaces and run by the session's shell.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, code, err := execSession(cmd.Context(), c.api, CreateOptions{
+				Description: description,
+				Command:     strings.Join(args, " "),
+			}, os.Stdout, c.attachOptions().Compress)
+			if rm && name != "" {
+				// Clean up even if we were interrupted.
+				ctx := context.WithoutCancel(cmd.Context())
+				if derr := c.api.DeleteSession(ctx, name); derr != nil && err == nil {
+					err = derr
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				return &exitError{code}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&description, "description", "d", "", "session description")
+	cmd.Flags().BoolVar(&rm, "rm", false, "delete the session when the command finishes")
+	return cmd
+}
+
+func (c *cli) cpCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cp <file> <name>:<path> | <name>:<path> <file>",
+		Short: "Copy a file into or out of a session's working directory",
+		Long: "Copy a local file into a session's working directory, at a path relative to it, or a\n" +
+			"file out of it. A path that is empty or ends in / is a directory, and the file keeps\n" +
+			"its name there: `claude-host cp spec.md swift-otter:`, `claude-host cp data.csv\n" +
+			"swift-otter:samples/`, `claude-host cp swift-otter:report.md .`. A local - is stdout.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return copyFile(cmd.Context(), c.api, args[0], args[1])
+		},
+	}
+}
+
+func (c *cli) waitCmd() *cobra.Command {
+	var idle, timeout time.Duration
+	var exit bool
+	cmd := &cobra.Command{
+		Use:   "wait <name>",
+		Short: "Wait for a session to exit or go idle",
+		Long: "Block until a session's process exits (--exit, the default) or its output has been\n" +
+			"idle for a duration (--idle 30s). Exits 0 when the condition is met and 124 on --timeout.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if idle < 0 {
+				return errors.New("--idle must be positive")
+			}
+			ctx := cmd.Context()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			err := waitSession(ctx, c.api, args[0], idle)
+			if errors.Is(err, context.DeadlineExceeded) && cmd.Context().Err() == nil {
+				fmt.Fprintf(os.Stderr, "timed out waiting for %s\n", args[0])
+				return &exitError{waitTimeoutCode}
+			}
+			return err
+		},
+	}
+	cmd.Flags().DurationVar(&idle, "idle", 0, "wait until output has been idle this long")
+	cmd.Flags().BoolVar(&exit, "exit", false, "wait until the process exits (default)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "give up after this long")
+	cmd.MarkFlagsMutuallyExclusive("idle", "exit")
+	return cmd
+}
+
+func (c *cli) playCmd() *cobra.Command {
+	var speed float64
+	cmd := &cobra.Command{
+		Use:   "play <file.cast>",
+		Short: "Replay a recorded session",
+		Long:  "Replay an asciicast v2 recording. space pauses, + and - change speed, q quits.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if speed <= 0 {
+				return errors.New("--speed must be positive")
+			}
+			return playCast(cmd.Context(), args[0], speed)
+		},
+	}
+	cmd.Flags().Float64VarP(&speed, "speed", "s", 1, "playback speed multiplier")
+	return cmd
+}
+
+func (c *cli) serveCmd() *cobra.Command {
+	var opts serveOptions
+	var pol ServeConfig
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a claude-host server from this binary",
+		Long: "Serve the claude-host API and session WebSockets, keeping each session in tmux.\n" +
+			"Clients must present $CLAUDE_HOST_TOKEN or the [auth] token when one is set, or with\n" +
+			"[serve.users] configured, their own token; each user then sees only their own sessions.\n\n" +
+			"Policies default to [serve] in the config file; the flags override it.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := c.cfg.Serve
+			if cmd.Flags().Changed("idle-hours") {
+				cfg.IdleHours = pol.IdleHours
+			}
+			if cmd.Flags().Changed("idle-action") {
+				cfg.IdleAction = pol.IdleAction
+			}
+			if cmd.Flags().Changed("max-sessions") {
+				cfg.MaxSessions = pol.MaxSessions
+			}
+			switch cfg.IdleAction {
+			case "", idleKill, idleArchive:
+			default:
+				return fmt.Errorf("--idle-action must be %q or %q", idleKill, idleArchive)
+			}
+			opts.token, opts.users, opts.policy = c.tokenFor(""), cfg.Users, newPolicy(cfg)
+			return serve(cmd.Context(), opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.addr, "listen", "l", "localhost:3000", "host:port or unix:///path/to.sock to listen on")
+	cmd.Flags().StringVar(&opts.dir, "data", dataDir(), "directory for the session database")
+	cmd.Flags().Float64Var(&pol.IdleHours, "idle-hours", 0, "reap sessions with no output for this many hours (0 never)")
+	cmd.Flags().StringVar(&pol.IdleAction, "idle-action", idleKill, `what reaping does: "kill" stops the process, "archive" also hides the session`)
+	cmd.Flags().IntVar(&pol.MaxSessions, "max-sessions", 0, "most sessions allowed to run at once (0 no cap)")
+	return cmd
+}
+
+func (c *cli) webhookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "webhook",
+		Aliases: []string{"webhooks"},
+		Short:   "Manage webhooks the server calls on session events",
+		Long: "Webhooks POST JSON to a URL when your sessions are created, exit, crash or go idle.\n" +
+			"The body includes a \"text\" field, so a Slack incoming-webhook URL works as it is.",
+	}
+
+	var asJSON bool
+	ls := &cobra.Command{
+		Use:   "ls",
+		Short: "List webhooks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hooks, err := c.api.ListWebhooks(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				return printJSON(hooks)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tURL\tEVENTS\tSIGNED")
+			for _, h := range hooks {
+				events := strings.Join(h.Events, ",")
+				if events == "" {
+					events = "all"
+				}
+				if h.IdleMinutes > 0 && h.wants(hookIdle) {
+					events += fmt.Sprintf(" (idle %dm)", h.IdleMinutes)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%v\n", h.ID, h.URL, events, h.Signed)
+			}
+			return w.Flush()
+		},
+	}
+	ls.Flags().BoolVar(&asJSON, "json", false, "print webhooks as JSON")
+
+	var h Webhook
+	add := &cobra.Command{
+		Use:   "add <url>",
+		Short: "Register a webhook and print its ID",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h.URL = args[0]
+			out, err := c.api.CreateWebhook(cmd.Context(), h)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out.ID)
+			return nil
+		},
+	}
+	add.Flags().StringSliceVar(&h.Events, "events", nil, "events to send: created, exited, crashed, idle (default all)")
+	add.Flags().IntVar(&h.IdleMinutes, "idle-minutes", 0, "minutes without output before idle fires (default 10)")
+	add.Flags().StringVar(&h.Secret, "secret", "", "sign bodies with HMAC-SHA256 in X-Claude-Host-Signature")
+
+	rm := &cobra.Command{
+		Use:   "rm <id>...",
+		Short: "Remove webhooks",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var failed bool
+			for _, id := range args {
+				if err := c.api.DeleteWebhook(cmd.Context(), id); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+					failed = true
+				}
+			}
+			if failed {
+				return errors.New("some webhooks could not be removed")
+			}
+			return nil
+		},
+	}
+
+	test := &cobra.Command{
+		Use:   "test <id>",
+		Short: "Send a webhook a test event",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.api.TestWebhook(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.AddCommand(ls, add, rm, test)
+	return cmd
+}
+
+func (c *cli) doctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the connection to the server step by step",
+		Long: "Check each step of reaching the server — DNS, the connection, TLS, the health endpoint,\n" +
+			"the token and WebSocket upgrades — and how far apart the two clocks are,\n" +
+			"printing what passed and what didn't.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if c.host == allHosts {
+				return errors.New("doctor checks one server; pick it with --host or --url")
+			}
+			if n := runDoctor(cmd.Context(), c.api, os.Stdout); n > 0 {
+				return fmt.Errorf("checks failed: %d", n)
+			}
+			return nil
+		},
+	}
+}
+
+func (c *cli) configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show or edit the effective settings",
+		Long: "Settings come from " + configPath() + ", then $CLAUDE_HOST_<KEY> environment\n" +
+			"variables, then --set key=value flags, each overriding the last. KEY is the setting's\n" +
+			"dotted name in capitals with dots as underscores, e.g. dashboard.poll_seconds is\n" +
+			"$CLAUDE_HOST_DASHBOARD_POLL_SECONDS. Lists are comma-separated.",
+		// Don't connect, and let edit fix a config that doesn't load.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+
+	var all, secrets bool
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "List settings with their values and where they came from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.loadConfig(); err != nil {
+				return err
+			}
+			fmt.Printf("config: %s\n", configPath())
+			if c.host == allHosts {
+				fmt.Printf("server: every [hosts] profile (%s)\n\n", c.from)
+			} else {
+				fmt.Printf("server: %s (%s)\n\n", c.baseURL, c.from)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+			for _, s := range c.cfg.settings(all) {
+				value, source := formatValue(s.value, false), s.source
+				if source == "" {
+					value, source = "-", "default"
+				} else if isSecret(s.key) && value != "" && !secrets {
+					value = "(hidden)"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", s.key, value, source)
+			}
+			return w.Flush()
+		},
+	}
+	show.Flags().BoolVarP(&all, "all", "a", false, "include settings left at their defaults")
+	show.Flags().BoolVar(&secrets, "show-secrets", false, "print tokens instead of hiding them")
+	cmd.RunE = show.RunE
+	cmd.Flags().AddFlagSet(show.Flags())
+
+	get := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print one setting's value, empty if unset",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.loadConfig(); err != nil {
+				return err
+			}
+			s, err := c.cfg.get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(formatValue(s.value, true))
+			return nil
+		},
+	}
+
+	path := &cobra.Command{
+		Use:   "path",
+		Short: "Print the config file's path",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(configPath())
+		},
+	}
+
+	edit := &cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in $VISUAL or $EDITOR, then check it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			editor := cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+			if err := os.MkdirAll(configDir(), 0o700); err != nil {
+				return err
+			}
+			argv := append(strings.Fields(editor), configPath())
+			e := exec.CommandContext(cmd.Context(), argv[0], argv[1:]...)
+			e.Stdin, e.Stdout, e.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := e.Run(); err != nil {
+				return fmt.Errorf("%s: %w", editor, err)
+			}
+			if _, err := LoadConfig(c.sets); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(show, get, path, edit)
+	return cmd
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
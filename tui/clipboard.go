@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// copyToClipboard puts text on the local clipboard using the platform's
+// clipboard tool, falling back to an OSC 52 escape sequence (which also works
+// over SSH in most modern terminals).
+func copyToClipboard(text string) error {
+	for _, argv := range clipboardCommands() {
+		if _, err := exec.LookPath(argv[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	return writeOSC52(text)
+}
+
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+	var cmds [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		cmds = append(cmds, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		cmds = append(cmds, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	return cmds
+}
+
+func writeOSC52(text string) error {
+	_, err := fmt.Fprintf(os.Stdout, "\033]52;c;%s\007", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+// Clipboard modes for OSC 52 writes from the remote session ([attach]
+// clipboard in the config).
+const (
+	clipboardTerminal = "terminal" // forward to the local terminal (default)
+	clipboardSystem   = "system"   // decode into the local clipboard tool
+	clipboardOff      = "off"      // drop
+)
+
+const (
+	osc52Prefix = "\033]52;"
+	osc52Max    = 1 << 20 // give up on sequences longer than this
+)
+
+// osc52Filter pulls OSC 52 clipboard sequences out of the output stream so
+// they can be handled according to the clipboard mode. Sequences may be
+// split across WebSocket messages, so partial ones are held back until the
+// next write.
+type osc52Filter struct {
+	held []byte
+}
+
+// Filter returns p with any complete OSC 52 sequences removed, along with
+// the text of clipboard writes among them. Clipboard queries ("?") are
+// dropped: answering them would let the remote read the local clipboard.
+func (f *osc52Filter) Filter(p []byte) (out []byte, copied []string) {
+	data := p
+	if len(f.held) > 0 {
+		data = append(f.held, p...)
+		f.held = nil
+	}
+	for len(data) > 0 {
+		i := bytes.Index(data, []byte(osc52Prefix))
+		if i < 0 {
+			// Hold back a trailing partial prefix.
+			keep := 0
+			for n := min(len(osc52Prefix)-1, len(data)); n > 0; n-- {
+				if bytes.HasSuffix(data, []byte(osc52Prefix[:n])) {
+					keep = n
+					break
+				}
+			}
+			out = append(out, data[:len(data)-keep]...)
+			f.held = append(f.held, data[len(data)-keep:]...)
+			break
+		}
+		out = append(out, data[:i]...)
+		body := data[i+len(osc52Prefix):]
+		end, termLen := bytes.IndexByte(body, '\a'), 1
+		if st := bytes.Index(body, []byte("\033\\")); st >= 0 && (end < 0 || st < end) {
+			end, termLen = st, 2
+		}
+		if end < 0 {
+			if len(data)-i > osc52Max {
+				// Not a real sequence, or an absurd one; let it through.
+				out = append(out, data[i:]...)
+			} else {
+				f.held = append(f.held, data[i:]...)
+			}
+			break
+		}
+		// body is "Pc;Pd": selection targets, then base64 data or "?".
+		if _, pd, ok := bytes.Cut(body[:end], []byte(";")); ok && string(pd) != "?" {
+			if text, err := base64.StdEncoding.DecodeString(string(pd)); err == nil {
+				copied = append(copied, string(text))
+			}
+		}
+		data = body[end+termLen:]
+	}
+	return out, copied
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Dashboard columns, chosen and ordered with [dashboard] columns. The
+// description goes on its own line under the row wherever it is listed.
+const (
+	colName        = "name"
+	colHost        = "host"
+	colOwner       = "owner"
+	colCommand     = "command"
+	colAge         = "age"
+	colCwd         = "cwd"
+	colGit         = "git"
+	colActivity    = "activity"
+	colStatus      = "status"
+	colUsage       = "usage"
+	colCost        = "cost"
+	colTags        = "tags"
+	colProject     = "project"
+	colDescription = "description"
+)
+
+var defaultColumns = []string{colName, colGit, colCommand, colAge, colStatus, colActivity, colUsage, colCost, colTags, colDescription}
+
+var knownColumns = []string{colName, colHost, colOwner, colGit, colCommand, colAge, colCwd, colStatus, colActivity, colUsage, colCost, colTags, colProject, colDescription}
+
+// withHostColumn adds the host column after the name, for an aggregated
+// dashboard whose columns weren't configured.
+func withHostColumn(cols []string) []string {
+	return withColumn(cols, colHost)
+}
+
+// withColumn adds col after the name unless it is already shown.
+func withColumn(cols []string, col string) []string {
+	if slices.Contains(cols, col) {
+		return cols
+	}
+	i := slices.Index(cols, colName) + 1
+	return slices.Insert(slices.Clone(cols), i, col)
+}
+
+// shownColumns are the configured columns, plus the owner while every
+// user's sessions are listed, less git when no session is in a repository,
+// status when no session has one, usage when the server doesn't report it
+// and cost when no session has any.
+func (m DashboardModel) shownColumns() []string {
+	cols := m.columns
+	if m.allUsers {
+		cols = withColumn(cols, colOwner)
+	}
+	if !slices.ContainsFunc(m.sessions, func(s Session) bool { return s.Git != nil }) {
+		cols = slices.DeleteFunc(slices.Clone(cols), func(c string) bool { return c == colGit })
+	}
+	if !slices.ContainsFunc(m.sessions, func(s Session) bool { return s.Status != "" }) {
+		cols = slices.DeleteFunc(slices.Clone(cols), func(c string) bool { return c == colStatus })
+	}
+	if len(m.stats.stats) == 0 {
+		cols = slices.DeleteFunc(slices.Clone(cols), func(c string) bool { return c == colUsage })
+	}
+	if _, ok := totalCost(m.sessions); !ok {
+		cols = slices.DeleteFunc(slices.Clone(cols), func(c string) bool { return c == colCost })
+	}
+	return cols
+}
+
+// parseColumns validates a configured column list, falling back to the
+// defaults when it is empty.
+func parseColumns(cols []string) ([]string, error) {
+	if len(cols) == 0 {
+		return defaultColumns, nil
+	}
+	seen := map[string]bool{}
+	for _, c := range cols {
+		if !slices.Contains(knownColumns, c) {
+			return nil, fmt.Errorf("dashboard.columns: unknown column %q (want one of %s)", c, strings.Join(knownColumns, ", "))
+		}
+		if seen[c] {
+			return nil, fmt.Errorf("dashboard.columns: %q listed twice", c)
+		}
+		seen[c] = true
+	}
+	if !seen[colName] {
+		return nil, fmt.Errorf("dashboard.columns: must include %q", colName)
+	}
+	return cols, nil
+}
+
+// renderRow renders a session's main line from the configured columns.
+func (m DashboardModel) renderRow(i int, sess Session) string {
+	prefix := "  "
+	nameS := normStyle
+	if i == m.cursor && !m.onHeader {
+		prefix = "▸ "
+		nameS = selStyle
+	}
+	if !sess.Alive {
+		nameS = deadStyle
+	}
+	if len(m.marked) > 0 {
+		if m.marked[sess.Name] {
+			prefix += markStyle.Render("● ")
+		} else {
+			prefix += "  "
+		}
+	}
+
+	var cells []string
+	for _, col := range m.shownColumns() {
+		switch col {
+		case colName:
+			name := sess.Name
+			if slices.Contains(m.columns, colHost) {
+				name = sess.BareName()
+			}
+			cells = append(cells, nameS.Render(pad(name, 22)))
+		case colHost:
+			cells = append(cells, promptSty.Render(pad(sess.Host, 10)))
+		case colOwner:
+			cells = append(cells, promptSty.Render(pad(sess.Owner, 10)))
+		case colGit:
+			git := ""
+			if sess.Git != nil {
+				git = sess.Git.String()
+			}
+			cells = append(cells, promptSty.Render(pad(truncate(git, 24), 24)))
+		case colCommand:
+			cells = append(cells, cmdStyle.Render(pad(sess.Command, 10)))
+		case colAge:
+			if sess.Archived {
+				cells = append(cells, deadStyle.Render(fmt.Sprintf("%-8s", "archived")))
+			} else if !sess.Alive {
+				cells = append(cells, deadStyle.Render(fmt.Sprintf("%-8s", sess.exitLabel())))
+			} else {
+				cells = append(cells, tStyle.Render(fmt.Sprintf("%-8s", timeAgo(sess.CreatedAt))))
+			}
+		case colCwd:
+			cwd := sess.Cwd
+			if cwd == "" {
+				cwd = "-"
+			}
+			cells = append(cells, cmdStyle.Render(pad(truncate(cwd, 20), 20)))
+		case colStatus:
+			cells = append(cells, statusStyle(sess.Status).Render(fmt.Sprintf("%-12s", statusLabels[sess.Status])))
+		case colActivity:
+			if !sess.Alive {
+				cells = append(cells, fmt.Sprintf("%-10s", ""))
+				break
+			}
+			activity := activityLabel(m.watch.LastChange(sess.Name))
+			if strings.HasPrefix(activity, "●") {
+				cells = append(cells, activeStyle.Render(fmt.Sprintf("%-10s", activity)))
+			} else {
+				cells = append(cells, tStyle.Render(fmt.Sprintf("%-10s", activity)))
+			}
+		case colUsage:
+			st := m.stats.stats[sess.Name]
+			switch {
+			case st == nil || !sess.Alive:
+				cells = append(cells, fmt.Sprintf("%-14s", ""))
+			case st.runaway():
+				cells = append(cells, warnSty.Render(fmt.Sprintf("%-14s", st)))
+			default:
+				cells = append(cells, tStyle.Render(fmt.Sprintf("%-14s", st)))
+			}
+		case colCost:
+			cost := ""
+			if sess.Tokens != nil {
+				cost = sess.Tokens.String()
+			}
+			cells = append(cells, tStyle.Render(fmt.Sprintf("%-16s", cost)))
+		case colTags:
+			if len(sess.Tags) > 0 {
+				cells = append(cells, renderChips(sess.Tags))
+			}
+		case colProject:
+			cells = append(cells, promptSty.Render(pad(truncate(sess.Project, 14), 14)))
+		}
+	}
+	if m.waiting[sess.Name] {
+		cells = append(cells, waitStyle.Render(" waiting for approval "))
+	}
+	if sess.Viewers > 0 {
+		cells = append(cells, promptSty.Render(viewersLabel(sess.Viewers)))
+	}
+	return "  " + prefix + strings.Join(cells, " ")
+}
+
+// viewersLabel is the presence note for a session with n clients attached.
+func viewersLabel(n int) string {
+	if n == 1 {
+		return "1 viewer"
+	}
+	return fmt.Sprintf("%d viewers", n)
+}
+
+// detailLine is the line under a session's row: its description, or a
+// progress note while it is being summarized. "" means no second line.
+func (m DashboardModel) detailLine(sess Session) string {
+	if !slices.Contains(m.columns, colDescription) {
+		return ""
+	}
+	if sess.Description != "" {
+		desc, width := sess.Description, m.width
+		if m.sideBySide() {
+			width = m.listWidth()
+		}
+		if width > 10 {
+			desc = truncateTail(desc, width-10)
+		}
+		return "    " + dimStyle.Render(desc)
+	}
+	if m.summarizing == sess.Name {
+		return "    " + dimStyle.Render("summarizing...")
+	}
+	return ""
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config mirrors ~/.config/claude-host/config.toml. Every field is optional;
+// anything left unset falls back to the built-in defaults. Settings can also
+// come from $CLAUDE_HOST_<KEY> environment variables and --set flags, which
+// win over the file in that order; see settings.go.
+type Config struct {
+	// DefaultHost is the profile used when neither --host, --url nor
+	// $CLAUDE_HOST picks a server.
+	DefaultHost string                `toml:"default_host"`
+	Hosts       map[string]HostConfig `toml:"hosts"`
+	Auth        AuthConfig            `toml:"auth"`
+	TLS         TLSConfig             `toml:"tls"`
+	Retry       RetryConfig           `toml:"retry"`
+	Attach      AttachConfig          `toml:"attach"`
+	Approve     ApproveConfig         `toml:"approve"`
+	Notify      NotifyConfig          `toml:"notify"`
+	Dashboard   DashboardConfig       `toml:"dashboard"`
+	Serve       ServeConfig           `toml:"serve"`
+	Summarize   SummarizeConfig       `toml:"summarize"`
+	Editor      EditorConfig          `toml:"editor"`
+	GitHub      GitHubConfig          `toml:"github"`
+	Theme       ThemeConfig           `toml:"theme"`
+	Keys        KeyConfig             `toml:"keys"`
+	Templates   map[string]Template   `toml:"templates"`
+	Hooks       map[string]HookConfig `toml:"hooks"` // by session name pattern, e.g. [hooks."api-*"]
+
+	// Proxy overrides $HTTPS_PROXY/$HTTP_PROXY for REST calls and
+	// WebSockets alike: an http://, https:// or socks5:// URL, or "direct"
+	// to ignore the environment.
+	Proxy string `toml:"proxy"`
+
+	sources map[string]string // where each setting came from, by key
+}
+
+// HostConfig is a named server profile, e.g. [hosts.work].
+type HostConfig struct {
+	URL   string `toml:"url"`
+	Token string `toml:"token"` // default [auth] token
+	// SSH tunnels to the server through [user@]host[:port]; URL is then
+	// resolved on that machine.
+	SSH   string `toml:"ssh"`
+	Proxy string `toml:"proxy"` // replaces the top-level proxy
+	// EditorHost is how the [editor] command reaches the server's machine
+	// over SSH, when that isn't the host in its URL.
+	EditorHost string `toml:"editor_host"`
+	// TLS replaces [tls] for this host, e.g. [hosts.work.tls].
+	TLS *TLSConfig `toml:"tls"`
+}
+
+type AuthConfig struct {
+	Token string `toml:"token"` // overridden by $CLAUDE_HOST_TOKEN
+}
+
+// TLSConfig customises certificate checks for https:// servers.
+type TLSConfig struct {
+	CA                 string `toml:"ca"`   // PEM bundle trusted on top of the system roots
+	Cert               string `toml:"cert"` // client certificate for mutual TLS
+	Key                string `toml:"key"`  // and its private key
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
+}
+
+// RetryConfig controls retries of read-only requests that fail because the
+// server couldn't be reached.
+type RetryConfig struct {
+	Attempts  *int `toml:"attempts"`   // total tries, default 3
+	BackoffMS *int `toml:"backoff_ms"` // before the first retry, doubling after; default 250
+}
+
+type AttachConfig struct {
+	// What to do when the remote session sets the clipboard via OSC 52:
+	// "terminal" (default) forwards it to the local terminal, "system"
+	// copies it with the local clipboard tool, "off" drops it.
+	Clipboard string `toml:"clipboard"`
+	// Negotiate permessage-deflate on the attach WebSocket (default true).
+	// Turning it off can shave latency on a fast local connection.
+	Compression *bool `toml:"compression"`
+	// Echo typed characters locally before the session does, underlined
+	// until it confirms them: "off" (default), "adaptive" (only while the
+	// round trip is noticeably slow) or "always".
+	Predict string `toml:"predict"`
+	// Show the status bar from the start of each attach (ctrl-a t toggles
+	// it).
+	StatusBar bool `toml:"status_bar"`
+}
+
+// ApproveConfig controls quick-approve of permission prompts from the
+// dashboard.
+type ApproveConfig struct {
+	Patterns []string `toml:"patterns"` // regexps matched against the bottom of the screen
+	Yes      string   `toml:"yes"`      // sent by the approve key, default "1"
+	No       string   `toml:"no"`       // sent by the deny key, default esc
+}
+
+type DashboardConfig struct {
+	// Columns to show, in order: name, host, owner, git, command, age, cwd,
+	// status, activity, usage, cost, tags, project, description. host only
+	// applies to an aggregated dashboard, owner to a multi-user server, git
+	// to sessions in a repository, status to Claude sessions.
+	Columns []string `toml:"columns"`
+	// PollSeconds is how often sessions and screens are fetched when the
+	// server doesn't push changes; default 3.
+	PollSeconds *int `toml:"poll_seconds"`
+	// AttachCommand, if set, attaches in a new pane or tab instead of
+	// taking over the dashboard's terminal. {attach} is the attach command
+	// and {name} the session, e.g. "wezterm cli spawn -- {attach}",
+	// "zellij action new-pane --name {name} -- {attach}" or
+	// "kitty @ launch --type=tab --tab-title {name} {attach}".
+	AttachCommand string `toml:"attach_command"`
+}
+
+// pollInterval is the dashboard and watcher's polling period.
+func (c DashboardConfig) pollInterval() time.Duration {
+	if c.PollSeconds == nil {
+		return defaultPollInterval
+	}
+	return time.Duration(*c.PollSeconds) * time.Second
+}
+
+// NotifyConfig controls notifications when a session goes quiet.
+type NotifyConfig struct {
+	IdleSeconds *int  `toml:"idle_seconds"` // default 30; 0 disables
+	Desktop     *bool `toml:"desktop"`      // notify-send / osascript as well as the terminal, default true
+}
+
+// ServeConfig sets the policies `claude-host serve` enforces.
+type ServeConfig struct {
+	IdleHours   float64 `toml:"idle_hours"`   // reap sessions with no output for this long; 0 disables
+	IdleAction  string  `toml:"idle_action"`  // "kill" (default) stops the process; "archive" also hides the session
+	MaxSessions int     `toml:"max_sessions"` // cap on running sessions; 0 for none
+	// Users gives each person their own token and sessions, e.g.
+	// [serve.users.alice]. Without any, the server has a single user.
+	Users map[string]ServeUser `toml:"users"`
+}
+
+type ServeUser struct {
+	Token string `toml:"token"`
+	Admin bool   `toml:"admin"` // can see and act on everyone's sessions
+}
+
+type KeyConfig struct {
+	// Attach control layer
+	Prefix string `toml:"prefix"` // e.g. "ctrl-b"
+	Detach string `toml:"detach"` // pressed after prefix
+
+	// Dashboard
+	Up            keyList `toml:"up"`
+	Down          keyList `toml:"down"`
+	Attach        keyList `toml:"attach"`
+	Create        keyList `toml:"create"`
+	Delete        keyList `toml:"delete"`
+	Rename        keyList `toml:"rename"`
+	Edit          keyList `toml:"edit"`
+	Restart       keyList `toml:"restart"`
+	Share         keyList `toml:"share"`
+	ShowAll       keyList `toml:"show_all"`
+	Archive       keyList `toml:"archive"`
+	ShowArchived  keyList `toml:"show_archived"`
+	AllUsers      keyList `toml:"all_users"`
+	Mark          keyList `toml:"mark"`
+	MarkAll       keyList `toml:"mark_all"`
+	Tag           keyList `toml:"tag"`
+	Send          keyList `toml:"send"`
+	Sort          keyList `toml:"sort"`
+	Detail        keyList `toml:"detail"`
+	Wall          keyList `toml:"wall"`
+	Export        keyList `toml:"export"`
+	Editor        keyList `toml:"editor"`
+	Group         keyList `toml:"group"`
+	Collapse      keyList `toml:"collapse"`
+	Expand        keyList `toml:"expand"`
+	Project       keyList `toml:"project"`
+	Issue         keyList `toml:"issue"`
+	Download      keyList `toml:"download"`
+	Focus         keyList `toml:"focus"`
+	Conversation  keyList `toml:"conversation"`
+	Search        keyList `toml:"search"`
+	Approve       keyList `toml:"approve"`
+	Deny          keyList `toml:"deny"`
+	Notifications keyList `toml:"notifications"`
+	Errors        keyList `toml:"errors"`
+	TagFilter     keyList `toml:"tag_filter"`
+	Hosts         keyList `toml:"hosts"`
+	Help          keyList `toml:"help"`
+	Summarize     keyList `toml:"summarize"`
+	SummarizeAll  keyList `toml:"summarize_all"`
+	AutoSummarize keyList `toml:"auto_summarize"`
+	Find          keyList `toml:"find"`
+	Quit          keyList `toml:"quit"`
+}
+
+// keyList accepts either a single key ("x") or an array (["x", "y"]).
+type keyList []string
+
+func (k *keyList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*k = keyList{v}
+	case []any:
+		out := make(keyList, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("key binding must be a string, got %T", item)
+			}
+			out = append(out, s)
+		}
+		*k = out
+	default:
+		return fmt.Errorf("key binding must be a string or array of strings, got %T", v)
+	}
+	return nil
+}
+
+func configDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "claude-host")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "claude-host")
+}
+
+func configPath() string {
+	return filepath.Join(configDir(), "config.toml")
+}
+
+// LoadConfig reads the config file, then applies $CLAUDE_HOST_* variables
+// and sets, each "key=value". A missing file is not an error.
+func LoadConfig(sets []string) (Config, error) {
+	cfg := Config{sources: map[string]string{}}
+	path := configPath()
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, k := range md.Keys() {
+		cfg.sources[k.String()] = "file"
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+	for _, s := range sets {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("--set %s: want key=value", s)
+		}
+		if err := cfg.set(key, value); err != nil {
+			return Config{}, fmt.Errorf("--set: %w", err)
+		}
+		cfg.sources[key] = "--set"
+	}
+	if err := cfg.validate(path); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// validate checks settings the types alone don't. Errors name where the
+// setting came from, path for the config file.
+func (cfg Config) validate(path string) error {
+	for name, h := range cfg.Hosts {
+		if h.URL == "" {
+			return fmt.Errorf("%s: hosts.%s: url is required", path, name)
+		}
+	}
+	if _, ok := cfg.Hosts[cfg.DefaultHost]; cfg.DefaultHost != "" && !ok {
+		return fmt.Errorf("%s: default_host %q is not in [hosts]", cfg.origin("default_host", path), cfg.DefaultHost)
+	}
+	switch cfg.Attach.Clipboard {
+	case "", clipboardTerminal, clipboardSystem, clipboardOff:
+	default:
+		return fmt.Errorf("%s: attach.clipboard must be %q, %q or %q", cfg.origin("attach.clipboard", path), clipboardTerminal, clipboardSystem, clipboardOff)
+	}
+	switch cfg.Attach.Predict {
+	case "", predictOff, predictAdaptive, predictAlways:
+	default:
+		return fmt.Errorf("%s: attach.predict must be %q, %q or %q", cfg.origin("attach.predict", path), predictOff, predictAdaptive, predictAlways)
+	}
+	tokens := map[string]string{}
+	for name, u := range cfg.Serve.Users {
+		if u.Token == "" {
+			return fmt.Errorf("%s: serve.users.%s: token is required", path, name)
+		}
+		if other, ok := tokens[u.Token]; ok {
+			return fmt.Errorf("%s: serve.users.%s and serve.users.%s have the same token", path, other, name)
+		}
+		tokens[u.Token] = name
+	}
+	for name, t := range cfg.Templates {
+		for k := range t.Env {
+			if k == "" || strings.Contains(k, "=") {
+				return fmt.Errorf("%s: templates.%s: invalid env name %q", path, name, k)
+			}
+		}
+	}
+	for pattern := range cfg.Hooks {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: hooks.%s: bad pattern", path, pattern)
+		}
+	}
+	req := cfg.Summarize.request()
+	if err := req.validate(); err != nil {
+		return fmt.Errorf("%s: summarize: %w", path, err)
+	}
+	if n := cfg.Summarize.AutoMinutes; n != nil && *n < 1 {
+		return fmt.Errorf("%s: summarize.auto_minutes must be at least 1", cfg.origin("summarize.auto_minutes", path))
+	}
+	if err := cfg.Theme.validate(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if n := cfg.Dashboard.PollSeconds; n != nil && *n < 1 {
+		return fmt.Errorf("%s: dashboard.poll_seconds must be at least 1", cfg.origin("dashboard.poll_seconds", path))
+	}
+	switch cfg.Serve.IdleAction {
+	case "", idleKill, idleArchive:
+	default:
+		return fmt.Errorf("%s: serve.idle_action must be %q or %q", cfg.origin("serve.idle_action", path), idleKill, idleArchive)
+	}
+	return nil
+}
+
+// origin is where key was set, for errors: an environment variable, --set,
+// or path.
+func (cfg Config) origin(key, path string) string {
+	if s := cfg.sources[key]; s != "" && s != "file" {
+		return s
+	}
+	return path
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Turn is one turn of a Claude session's conversation: a prompt, or
+// everything the assistant said and did in reply to one.
+type Turn struct {
+	Role  string    `json:"role"` // "user" or "assistant"
+	Text  string    `json:"text"`
+	Tools []string  `json:"tools,omitempty"` // calls made, e.g. "Bash(go test ./...)"
+	Time  time.Time `json:"time"`
+}
+
+// Conversation is GET /api/sessions/{name}/conversation.
+type Conversation struct {
+	Turns []Turn `json:"turns"`
+}
+
+// How many turns the preview asks for, and how many once it has focus.
+const (
+	previewTurns = 10
+	focusTurns   = 50
+)
+
+// Conversation returns the last turns of a Claude session's conversation,
+// read from Claude Code's log. It is a 404 for other commands, before the
+// first prompt, and from the Node server.
+func (a *APIClient) Conversation(ctx context.Context, name string, turns int) ([]Turn, error) {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	path := "/api/sessions/" + url.PathEscape(name) + "/conversation"
+	if turns > 0 {
+		path += "?turns=" + strconv.Itoa(turns)
+	}
+	resp, err := a.do(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var c Conversation
+	if err := decodeResponse(resp, &c); err != nil {
+		return nil, err
+	}
+	return c.Turns, nil
+}
+
+// previewLine is a line of the preview and how to draw it.
+type previewLine struct {
+	text  string
+	style lipgloss.Style
+}
+
+// renderTurns lays a conversation out for the preview, wrapped to width:
+// prompts marked ❯, replies ⏺ with the tools they called.
+func renderTurns(turns []Turn, width int) []previewLine {
+	var lines []previewLine
+	add := func(mark, text string, style lipgloss.Style) {
+		for i, l := range wrapText(text, max(10, width-2)) {
+			if i == 0 {
+				l = mark + " " + l
+			} else {
+				l = "  " + l
+			}
+			lines = append(lines, previewLine{l, style})
+		}
+	}
+	for i, t := range turns {
+		if i > 0 {
+			lines = append(lines, previewLine{"", previewStyle})
+		}
+		if t.Role == "user" {
+			add("❯", t.Text, userTurnStyle)
+			continue
+		}
+		for _, tool := range t.Tools {
+			add("⎿", tool, toolTurnStyle)
+		}
+		if t.Text != "" {
+			add("⏺", t.Text, normStyle)
+		}
+	}
+	return lines
+}
+
+// turnsText is a conversation as plain text, for views that just want the
+// words.
+func turnsText(turns []Turn) string {
+	var s strings.Builder
+	for _, l := range renderTurns(turns, 100) {
+		s.WriteString(l.text + "\n")
+	}
+	return s.String()
+}
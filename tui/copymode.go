@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const scrollbackLines = 5000
+
+// scrollback keeps a plain-text copy of recent attach output so it can be
+// browsed in copy mode. Escape sequences are stripped; the parser state is
+// kept across writes since sequences can straddle WebSocket frames.
+type scrollback struct {
+	mu    sync.Mutex
+	lines []string
+	cur   []byte
+	max   int
+	state escState
+}
+
+type escState int
+
+const (
+	escNone escState = iota
+	escStart
+	escCSI
+	escOSC
+	escOSCEnd // saw ESC inside OSC, expecting '\'
+)
+
+func newScrollback(max int) *scrollback {
+	return &scrollback{max: max}
+}
+
+func (s *scrollback) Write(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range p {
+		switch s.state {
+		case escStart:
+			switch b {
+			case '[':
+				s.state = escCSI
+			case ']':
+				s.state = escOSC
+			default:
+				s.state = escNone
+			}
+			continue
+		case escCSI:
+			if b >= 0x40 && b <= 0x7e {
+				s.state = escNone
+				// Absolute cursor positioning usually means a full-screen
+				// app is drawing a new row.
+				if (b == 'H' || b == 'f') && len(s.cur) > 0 {
+					s.newline()
+				}
+			}
+			continue
+		case escOSC:
+			if b == 0x07 {
+				s.state = escNone
+			} else if b == 0x1b {
+				s.state = escOSCEnd
+			}
+			continue
+		case escOSCEnd:
+			s.state = escNone
+			continue
+		}
+
+		switch {
+		case b == 0x1b:
+			s.state = escStart
+		case b == '\n':
+			s.newline()
+		case b == '\t':
+			s.cur = append(s.cur, ' ', ' ', ' ', ' ')
+		case b == '\b':
+			if len(s.cur) > 0 {
+				s.cur = s.cur[:len(s.cur)-1]
+			}
+		case b < 0x20 || b == 0x7f:
+			// other control characters (including \r) carry no text
+		default:
+			s.cur = append(s.cur, b)
+			if len(s.cur) >= 4096 {
+				s.newline()
+			}
+		}
+	}
+}
+
+func (s *scrollback) newline() {
+	s.lines = append(s.lines, strings.TrimRight(string(s.cur), " "))
+	s.cur = s.cur[:0]
+	if len(s.lines) > s.max {
+		s.lines = append(s.lines[:0], s.lines[len(s.lines)-s.max:]...)
+	}
+}
+
+// Reset discards everything buffered, e.g. when switching sessions.
+func (s *scrollback) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = nil
+	s.cur = s.cur[:0]
+	s.state = escNone
+}
+
+// Lines returns a copy of the buffered lines, including the partial last line.
+func (s *scrollback) Lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, len(s.lines), len(s.lines)+1)
+	copy(out, s.lines)
+	if len(s.cur) > 0 {
+		out = append(out, string(s.cur))
+	}
+	return out
+}
+
+// copyMode is the ctrl-a [ scrollback browser. It draws directly to the raw
+// terminal while live output is paused, and exits on q/esc or after copying.
+type copyMode struct {
+	lines  []string
+	width  int
+	height int
+	top    int // first visible line
+	cursor int // absolute line index
+	anchor int // selection start, -1 when not selecting
+	status string
+}
+
+func newCopyMode(lines []string, width, height int) *copyMode {
+	c := &copyMode{lines: lines, width: width, height: height, anchor: -1}
+	if len(lines) == 0 {
+		c.lines = []string{""}
+	}
+	c.cursor = len(c.lines) - 1
+	c.top = max(0, len(c.lines)-c.viewHeight())
+	return c
+}
+
+func (c *copyMode) viewHeight() int {
+	return max(1, c.height-1)
+}
+
+// handle processes raw stdin bytes and reports whether copy mode is done.
+func (c *copyMode) handle(data []byte) (exit bool) {
+	page := c.viewHeight()
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		if b == 0x1b {
+			// CSI key sequences arrive in a single read; a bare ESC exits.
+			if i+2 < len(data) && data[i+1] == '[' {
+				j := i + 2
+				for j < len(data) && (data[j] < 0x40 || data[j] > 0x7e) {
+					j++
+				}
+				if j >= len(data) {
+					return false
+				}
+				switch string(data[i+2 : j+1]) {
+				case "A":
+					c.move(-1)
+				case "B":
+					c.move(1)
+				case "5~":
+					c.move(-page)
+				case "6~":
+					c.move(page)
+				case "H":
+					c.move(-len(c.lines))
+				case "F":
+					c.move(len(c.lines))
+				}
+				i = j
+				continue
+			}
+			return true
+		}
+		switch b {
+		case 'q', 0x03:
+			return true
+		case 'k':
+			c.move(-1)
+		case 'j':
+			c.move(1)
+		case 0x15: // ctrl-u
+			c.move(-page / 2)
+		case 0x04: // ctrl-d
+			c.move(page / 2)
+		case 0x02: // ctrl-b
+			c.move(-page)
+		case 0x06: // ctrl-f
+			c.move(page)
+		case 'g':
+			c.move(-len(c.lines))
+		case 'G':
+			c.move(len(c.lines))
+		case 'v', ' ':
+			if c.anchor >= 0 {
+				c.anchor = -1
+			} else {
+				c.anchor = c.cursor
+			}
+		case 'y', '\r':
+			text := c.selection()
+			if err := copyToClipboard(text); err != nil {
+				c.status = "copy failed: " + err.Error()
+				continue
+			}
+			return true
+		}
+	}
+	c.render()
+	return false
+}
+
+func (c *copyMode) move(delta int) {
+	c.cursor = max(0, min(len(c.lines)-1, c.cursor+delta))
+	page := c.viewHeight()
+	if c.cursor < c.top {
+		c.top = c.cursor
+	} else if c.cursor >= c.top+page {
+		c.top = c.cursor - page + 1
+	}
+	c.status = ""
+}
+
+func (c *copyMode) selectedRange() (int, int) {
+	if c.anchor < 0 {
+		return c.cursor, c.cursor
+	}
+	return min(c.anchor, c.cursor), max(c.anchor, c.cursor)
+}
+
+// selection returns the selected lines, or just the cursor line.
+func (c *copyMode) selection() string {
+	from, to := c.selectedRange()
+	return strings.Join(c.lines[from:to+1], "\n")
+}
+
+func (c *copyMode) render() {
+	var b strings.Builder
+	b.WriteString("\033[?25l\033[H\033[2J")
+	from, to := c.selectedRange()
+	end := min(len(c.lines), c.top+c.viewHeight())
+	for i := c.top; i < end; i++ {
+		line := truncate(c.lines[i], c.width)
+		switch {
+		case i == c.cursor:
+			b.WriteString("\033[7m" + pad(line, c.width) + "\033[0m")
+		case c.anchor >= 0 && i >= from && i <= to:
+			b.WriteString(rangeStyle.Render(pad(line, c.width)))
+		default:
+			b.WriteString(line)
+		}
+		b.WriteString("\r\n")
+	}
+
+	status := fmt.Sprintf(" COPY  %d/%d  j/k scroll  v select  y copy  q exit ", c.cursor+1, len(c.lines))
+	if c.anchor >= 0 {
+		status = fmt.Sprintf(" COPY  %d lines selected  y copy  v cancel  q exit ", to-from+1)
+	}
+	if c.status != "" {
+		status = " " + c.status + " "
+	}
+	fmt.Fprintf(&b, "\033[%d;1H\033[7m%s\033[0m", c.height, truncate(status, c.width))
+	os.Stdout.WriteString(b.String())
+}
@@ -0,0 +1,66 @@
+package main
+
+import "fmt"
+
+// TokenUsage is what a Claude session has used, tallied by the server from
+// Claude Code's conversation logs.
+type TokenUsage struct {
+	Input      int64   `json:"input_tokens"`
+	Output     int64   `json:"output_tokens"`
+	CacheWrite int64   `json:"cache_write_tokens"`
+	CacheRead  int64   `json:"cache_read_tokens"`
+	CostUSD    float64 `json:"cost_usd"` // estimated
+}
+
+func (t *TokenUsage) add(u TokenUsage) {
+	t.Input += u.Input
+	t.Output += u.Output
+	t.CacheWrite += u.CacheWrite
+	t.CacheRead += u.CacheRead
+	t.CostUSD += u.CostUSD
+}
+
+// Tokens counts every token, cached or not.
+func (t TokenUsage) Tokens() int64 {
+	return t.Input + t.Output + t.CacheWrite + t.CacheRead
+}
+
+// String is e.g. "$1.24 3.1M tok".
+func (t TokenUsage) String() string {
+	return formatCost(t.CostUSD) + " " + formatCount(t.Tokens()) + " tok"
+}
+
+func formatCost(usd float64) string {
+	if usd >= 100 {
+		return fmt.Sprintf("$%.0f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// formatCount is a short count like 950, 12k or 3.1M.
+func formatCount(n int64) string {
+	switch {
+	case n >= 10_000_000:
+		return fmt.Sprintf("%dM", n/1_000_000)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1000:
+		return fmt.Sprintf("%dk", n/1000)
+	default:
+		return fmt.Sprint(n)
+	}
+}
+
+// totalCost sums the estimated cost of the sessions that report one, and
+// says whether any did.
+func totalCost(sessions []Session) (float64, bool) {
+	var total float64
+	found := false
+	for _, s := range sessions {
+		if s.Tokens != nil {
+			total += s.Tokens.CostUSD
+			found = true
+		}
+	}
+	return total, found
+}
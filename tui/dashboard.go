@@ -1,10 +1,18 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -15,19 +23,26 @@ const (
 	ActionNone DashboardAction = iota
 	ActionAttach
 	ActionQuit
+	ActionSwitchHost
 )
 
 type DashboardResult struct {
 	Action      DashboardAction
 	SessionName string
+	Host        string // for ActionSwitchHost
 }
 
 // Messages
 type sessionsMsg []Session
-type snapshotMsg string
+type snapshotMsg struct {
+	name  string
+	text  string
+	turns []Turn // the conversation, when the preview shows one
+}
 type tickMsg time.Time
 type errMsg struct{ err error }
 type attachMsg string // session name to auto-attach
+type noticeMsg string // one-line report for the footer
 type summarizeMsg struct {
 	name string
 	desc string
@@ -39,55 +54,223 @@ type inputMode int
 const (
 	modeNormal inputMode = iota
 	modeDelete
+	modeRestart
+	modeArchive
+	modeRename
+	modeTag
+	modeSend
+	modeEdit
+	modeSearch
+	modeDownload
+	modeProject
+	modeIssue
 )
 
+// inflight holds cancel funcs for requests that a newer one supersedes. It is
+// shared by pointer so copies of the model cancel the same requests.
+type inflight struct {
+	snapshot  context.CancelFunc
+	summarize context.CancelFunc
+}
+
 type DashboardModel struct {
-	api      *APIClient
-	sessions []Session
-	cursor   int
-	snapshot string
-	width    int
-	height   int
-	result      DashboardResult
-	mode        inputMode
-	creating    bool
-	summarizing string // name of session being summarized, "" if idle
-	err         error
+	ctx          context.Context // cancelled when the dashboard exits
+	api          *APIClient
+	inflight     *inflight
+	sessions     []Session // visible sessions (after tag filter)
+	all          []Session // everything from the last fetch
+	cursor       int
+	snapshot     string
+	width        int
+	height       int
+	result       DashboardResult
+	mode         inputMode
+	creating     bool
+	summarizing  string           // name of session being summarized, "" if idle
+	progress     *summaryProgress // bulk summarize run, nil when none is running
+	finder       *FinderModel     // fuzzy finder overlay, nil when closed
+	form         *CreateForm      // new-session form overlay, nil when closed
+	prompt       textinput.Model
+	target       string // session the active prompt applies to
+	showAll      bool   // include sessions whose process has exited
+	showArchived bool   // include archived sessions, and so exited ones
+	marked       map[string]bool
+	bulk         *bulkDoneMsg // last bulk operation report, until the next key
+	notice       string       // last one-line report, until the next key
+	tagFilter    string       // only show sessions with this tag, "" for all
+	sortBy       sortKey
+	grouped      bool            // sessions listed under their projects
+	collapsed    map[string]bool // projects whose sessions are hidden
+	onHeader     bool            // the cursor is on its session's project header
+	columns      []string
+	host         string // active [hosts] profile, "" if none
+	hosts        map[string]HostConfig
+	tagMenu      *TagMenu           // tag filter menu overlay, nil when closed
+	hostMenu     *HostMenu          // host switcher overlay, nil when closed
+	notes        *NotificationPanel // notification list overlay, nil when closed
+	errPanel     *ErrorPanel        // error log overlay, nil when closed
+	templates    map[string]Template
+	summarize    SummarizeRequest // options for every summarize request
+	editor       EditorConfig     // how the editor key opens a session's directory
+	github       GitHubConfig     // for sessions from issues
+	poll         time.Duration    // between list refreshes
+	templateMenu *TemplateMenu    // template picker ahead of the create form, nil when closed
+	detail       *DetailView      // session detail overlay, nil when closed
+	wall         *WallView        // grid of live previews, nil when closed
+	screens      screensMsg       // latest screens from the watcher
+	showHelp     bool
+	preview      viewport.Model
+	previewName  string            // session whose snapshot the preview holds
+	previewFocus bool              // keys scroll and search the preview
+	seen         map[string]string // each session's screen when the preview last showed it
+	fresh        map[int]bool      // lines of freshName's screen that are new, see flashFresh
+	freshName    string
+	flashUntil   time.Time
+	conversation bool   // preview Claude sessions' conversations, not their screens
+	turns        []Turn // the conversation the preview holds, nil for a screen
+	search       string // preview search term
+	matches      []int  // preview lines matching search
+	match        int    // current index into matches
+	lastClick    time.Time
+	lastRow      int
+	approve      *approver
+	watch        *watcher
+	waiting      map[string]bool // sessions sitting at a permission prompt
+	keys         KeyMap
+	err          error
+	errlog       *errorLog           // every error, where err has only the last
+	offline      *outage             // set while the server can't be reached
+	offlineTick  bool                // the outage countdown is running
+	events       <-chan SessionEvent // live list updates, nil while polling
+	eventsRetry  time.Time           // when to try subscribing again, zero for never
+	listedAt     time.Time           // last successful list
+	policies     *Policies           // the server's session policies, nil if it has none
+	account      *Account            // who the server says we are, nil if it has no accounts
+	allUsers     bool                // list every user's sessions, for admins
+	stats        statsMsg            // resource use of running sessions
+	noStats      bool                // the server can't report resource use
+	health       *Health             // the server's version and latency, nil until known
+	noHealth     bool                // the server has no health endpoint
 }
 
-func NewDashboard(api *APIClient) DashboardModel {
-	return DashboardModel{api: api}
+func NewDashboard(ctx context.Context, api *APIClient, opts tuiOptions, watch *watcher) DashboardModel {
+	if opts.host == allHosts {
+		opts.columns = withHostColumn(opts.columns)
+	}
+	st := LoadState()
+	collapsed := map[string]bool{}
+	for _, p := range st.Collapsed {
+		collapsed[p] = true
+	}
+	return DashboardModel{
+		ctx:          ctx,
+		api:          api,
+		inflight:     &inflight{},
+		approve:      opts.approve,
+		watch:        watch,
+		sortBy:       st.Sort,
+		grouped:      st.Grouped,
+		collapsed:    collapsed,
+		seen:         map[string]string{},
+		conversation: st.Conversation,
+		columns:      opts.columns,
+		host:         opts.host,
+		hosts:        opts.hosts,
+		keys:         opts.keys,
+		templates:    opts.templates,
+		summarize:    opts.summarize.request(),
+		editor:       opts.editor,
+		github:       opts.github,
+		poll:         opts.poll,
+		errlog:       opts.errlog,
+	}
 }
 
 func (m DashboardModel) Init() tea.Cmd {
-	return tea.Batch(m.fetchSessions(), m.tick())
+	return tea.Batch(m.fetchSessions(), m.tick(), m.subscribe(), m.fetchPolicies(), m.fetchAccount(), m.fetchHealth(), m.watch.Updates(m.ctx))
+}
+
+// lister returns the list call matching the show-all toggle.
+func (m DashboardModel) lister() func(context.Context) ([]Session, error) {
+	if m.showAll || m.showArchived {
+		return m.api.ListAllSessions
+	}
+	return m.api.ListSessions
 }
 
 func (m DashboardModel) fetchSessions() tea.Cmd {
-	api := m.api
+	ctx, list := m.ctx, m.lister()
 	return func() tea.Msg {
-		sessions, err := api.ListSessions()
+		sessions, err := list(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if e := asAPIError(err); e != nil && e.Unreachable() {
+				return offlineMsg{err}
+			}
 			return errMsg{err}
 		}
 		return sessionsMsg(sessions)
 	}
 }
 
+// fetchSnapshot loads the preview for the cursor session, cancelling any
+// fetch still running for a session the cursor has since left. While the
+// preview has focus it loads the scrollback instead of just the screen. A
+// Claude session's conversation is loaded instead when that's toggled on
+// and the server has one.
 func (m DashboardModel) fetchSnapshot() tea.Cmd {
+	if m.inflight.snapshot != nil {
+		m.inflight.snapshot()
+		m.inflight.snapshot = nil
+	}
 	if m.cursor >= len(m.sessions) {
 		return nil
 	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.inflight.snapshot = cancel
 	api := m.api
-	name := m.sessions[m.cursor].Name
+	sess := m.sessions[m.cursor]
+	name := sess.Name
+	get := api.GetSnapshot
+	turns := 0
+	if m.conversation && isClaude(sess.Command) {
+		turns = previewTurns
+	}
+	if m.previewFocus {
+		get = func(ctx context.Context, name string) (string, error) {
+			return api.GetScrollback(ctx, name, previewScrollback)
+		}
+		if turns > 0 {
+			turns = focusTurns
+		}
+	}
 	return func() tea.Msg {
-		s, _ := api.GetSnapshot(name)
-		return snapshotMsg(s)
+		defer cancel()
+		if turns > 0 {
+			if t, err := api.Conversation(ctx, name, turns); err == nil {
+				return snapshotMsg{name: name, text: turnsText(t), turns: t}
+			}
+		}
+		text, err := get(ctx, name)
+		if err != nil {
+			return nil
+		}
+		return snapshotMsg{name: name, text: text}
 	}
 }
 
+// summarizeContext returns a context for a summarize run that esc can
+// cancel.
+func (m DashboardModel) summarizeContext() context.Context {
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.inflight.summarize = cancel
+	return ctx
+}
+
 func (m DashboardModel) tick() tea.Cmd {
-	return tea.Tick(3*time.Second, func(t time.Time) tea.Msg {
+	return tea.Tick(m.poll, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
@@ -95,130 +278,1066 @@ func (m DashboardModel) tick() tea.Cmd {
 func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.finder != nil {
+			f, cmd := m.finder.Update(msg)
+			m.finder = &f
+			return m, cmd
+		}
+		if m.form != nil {
+			f, cmd := m.form.Update(msg)
+			m.form = &f
+			return m, cmd
+		}
+		if m.tagMenu != nil {
+			t, cmd := m.tagMenu.Update(msg)
+			m.tagMenu = &t
+			return m, cmd
+		}
+		if m.hostMenu != nil {
+			h, cmd := m.hostMenu.Update(msg)
+			m.hostMenu = &h
+			return m, cmd
+		}
+		if m.templateMenu != nil {
+			t, cmd := m.templateMenu.Update(msg)
+			m.templateMenu = &t
+			return m, cmd
+		}
+		if m.notes != nil {
+			n, cmd := m.notes.Update(msg, m.keys, m.watch.notes)
+			m.notes = &n
+			return m, cmd
+		}
+		if m.errPanel != nil {
+			e, cmd := m.errPanel.Update(msg, m.keys, m.errlog)
+			m.errPanel = &e
+			return m, cmd
+		}
+		if m.wall != nil {
+			w, cmd := m.wall.Update(msg, m.keys, m.running(), m.width, m.height)
+			m.wall = &w
+			return m, cmd
+		}
+		if m.detail != nil && m.mode == modeNormal {
+			d, cmd := m.detail.Update(msg, m.keys)
+			m.detail = &d
+			return m, cmd
+		}
+		if m.showHelp {
+			switch {
+			case msg.String() == "esc", msg.String() == "q", m.keys.Matches(msg, m.keys.Help):
+				m.showHelp = false
+			}
+			return m, nil
+		}
 		switch m.mode {
 		case modeDelete:
 			return m.updateDelete(msg)
+		case modeRestart:
+			return m.updateRestart(msg)
+		case modeArchive:
+			return m.updateArchive(msg)
+		case modeRename:
+			return m.updateRename(msg)
+		case modeTag:
+			return m.updateTag(msg)
+		case modeSend:
+			return m.updateSend(msg)
+		case modeEdit:
+			return m.updateEdit(msg)
+		case modeSearch:
+			return m.updateSearch(msg)
+		case modeDownload:
+			return m.updateDownload(msg)
+		case modeProject:
+			return m.updateProject(msg)
+		case modeIssue:
+			return m.updateIssue(msg)
+		case modeNormal:
+			if m.previewFocus {
+				return m.updatePreview(msg)
+			}
+			return m.updateNormal(msg)
 		default:
 			return m.updateNormal(msg)
 		}
 
+	case tea.MouseMsg:
+		if m.finder != nil || m.form != nil || m.tagMenu != nil || m.hostMenu != nil || m.templateMenu != nil || m.notes != nil || m.errPanel != nil || m.detail != nil || m.wall != nil || m.showHelp || m.mode != modeNormal {
+			return m, nil
+		}
+		return m.updateMouse(msg)
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		if m.finder != nil {
+			m.finder.SetSize(m.width, m.height)
+		}
+		m.syncPreview()
 		return m, nil
 
 	case sessionsMsg:
-		m.sessions = []Session(msg)
-		m.err = nil
-		if m.cursor >= len(m.sessions) {
-			m.cursor = max(0, len(m.sessions)-1)
+		m.setSessions(msg)
+		if m.offline != nil {
+			m.api.log().Info("server reachable again", "server", m.api.String(), "after", time.Since(m.offline.since).Round(time.Second))
+			m.watch.notes.add(noteServer, "", "reconnected to %s after %s", m.api.String(), shortDuration(time.Since(m.offline.since)))
+		}
+		m.offline = nil
+		m.listedAt = time.Now()
+		// A fresh list resolves connection errors, but a not-found error
+		// explains why a session vanished, so it stays until the next key.
+		if e := asAPIError(m.err); e == nil || !e.NotFound() {
+			m.err = nil
 		}
 		return m, m.fetchSnapshot()
 
+	case screensMsg:
+		m.screens = msg
+		m.waiting = map[string]bool{}
+		for name, screen := range msg {
+			if m.approve.waiting(screen) {
+				m.waiting[name] = true
+			}
+		}
+		// The server may see a prompt that scrolled past the screen here.
+		for _, s := range m.sessions {
+			if s.Status == statusPermission {
+				m.waiting[s.Name] = true
+			}
+		}
+		if m.sortBy == sortActivity {
+			m.setSessions(m.all)
+		}
+		// Live updates don't say when output changes, so the watcher's
+		// screens keep the preview current.
+		var flash tea.Cmd
+		if m.cursor < len(m.sessions) && !m.previewFocus && m.turns == nil {
+			name := m.sessions[m.cursor].Name
+			if screen, ok := msg[name]; ok && m.previewName == name && screen != m.snapshot {
+				flash = m.flashFresh(name, screen)
+				m.snapshot = screen
+				m.syncPreview()
+			}
+		}
+		return m, tea.Batch(m.watch.Updates(m.ctx), flash)
+
+	case bulkDoneMsg:
+		m.bulk = &msg
+		if errors.Is(msg.listErr, context.Canceled) {
+			return m, m.fetchSessions()
+		}
+		if msg.listErr != nil {
+			m.setErr(msg.listErr)
+			return m, nil
+		}
+		m.setSessions(msg.sessions)
+		return m, m.fetchSnapshot()
+
 	case snapshotMsg:
-		m.snapshot = string(msg)
+		var flash tea.Cmd
+		if m.cursor < len(m.sessions) && m.sessions[m.cursor].Name == msg.name {
+			// The focused preview holds scrollback, not the screen.
+			if msg.turns == nil && !m.previewFocus {
+				flash = m.flashFresh(msg.name, msg.text)
+			}
+			m.snapshot = msg.text
+			m.turns = msg.turns
+			m.syncPreview()
+			if m.waiting != nil && msg.turns == nil {
+				m.waiting[msg.name] = m.approve.waiting(msg.text)
+			}
+		}
+		return m, flash
+
+	case flashDoneMsg:
+		if !time.Time(msg).Before(m.flashUntil) {
+			m.fresh = nil
+			m.syncPreview()
+		}
+		return m, nil
+
+	case healthMsg:
+		m.health = msg.health
+		m.noHealth = msg.unsupported
+		return m, nil
+
+	case statsMsg:
+		m.stats = msg
+		m.noStats = msg.unsupported
 		return m, nil
 
 	case tickMsg:
-		return m, tea.Batch(m.fetchSessions(), m.tick())
+		if m.offline != nil {
+			// Retries run on the outage's own backoff.
+			return m, m.tick()
+		}
+		cmds := []tea.Cmd{m.tick(), m.fetchStats(), m.fetchHealth()}
+		if m.policies != nil {
+			// Idle deadlines move with output, which no event reports.
+			cmds = append(cmds, m.fetchPolicies())
+		}
+		if m.events != nil {
+			// The server pushes changes; no need to poll.
+			return m, tea.Batch(cmds...)
+		}
+		cmds = append(cmds, m.fetchSessions())
+		if !m.eventsRetry.IsZero() && time.Time(msg).After(m.eventsRetry) {
+			m.eventsRetry = time.Time{}
+			cmds = append(cmds, m.subscribe())
+		}
+		return m, tea.Batch(cmds...)
+
+	case eventsUpMsg:
+		m.api.log().Debug("live updates on")
+		m.events = msg.ch
+		// Catch up on anything that changed before the subscription began.
+		return m, tea.Batch(m.fetchSessions(), nextEvent(m.events))
+
+	case eventsDownMsg:
+		m.api.log().Info("live updates off; polling", "err", msg.err)
+		if e := asAPIError(msg.err); e == nil || !e.NotFound() {
+			m.eventsRetry = time.Now().Add(eventsRetryInterval)
+			// A server without events isn't an error, but losing them is.
+			if msg.err != nil {
+				m.errlog.add(fmt.Errorf("live updates: %w", msg.err))
+			} else if m.events != nil {
+				m.errlog.add(errors.New("live updates: connection lost"))
+			}
+		}
+		m.events = nil
+		return m, m.fetchSessions()
+
+	case sessionEventMsg:
+		if m.events == nil {
+			return m, nil
+		}
+		return m, tea.Batch(m.fetchSessions(), nextEvent(m.events))
+
+	case policiesMsg:
+		m.policies = msg.p
+		return m, nil
+
+	case accountMsg:
+		m.account = msg.acct
+		return m, nil
+
+	case offlineMsg:
+		if m.offline == nil {
+			m.api.log().Warn("server unreachable", "server", m.api.String())
+			m.offline = &outage{since: time.Now()}
+		}
+		m.offline.next(msg.err, time.Now())
+		m.api.log().Debug("retry scheduled", "in", m.offline.delay)
+		m.errlog.add(msg.err)
+		m.creating = false
+		if !m.offlineTick {
+			m.offlineTick = true
+			return m, offlineTick()
+		}
+		return m, nil
+
+	case offlineTickMsg:
+		if m.offline == nil {
+			m.offlineTick = false
+			return m, nil
+		}
+		if !m.offline.trying && !time.Time(msg).Before(m.offline.retryAt) {
+			m.offline.trying = true
+			return m, tea.Batch(m.fetchSessions(), offlineTick())
+		}
+		return m, offlineTick()
+
+	case noticeMsg:
+		m.notice = string(msg)
+		return m, nil
 
 	case attachMsg:
+		if m.wall != nil {
+			m.wall = nil
+			m.watch.SetFast(false)
+		}
+		m.result = DashboardResult{Action: ActionAttach, SessionName: string(msg)}
+		return m, tea.Quit
+
+	case finderPickMsg:
+		m.finder = nil
 		m.result = DashboardResult{Action: ActionAttach, SessionName: string(msg)}
 		return m, tea.Quit
 
+	case finderCloseMsg:
+		m.finder = nil
+		return m, nil
+
+	case formSubmitMsg:
+		m.form = nil
+		if m.cursor < len(m.sessions) {
+			// An aggregated dashboard creates on the cursor session's host.
+			msg.Host = m.sessions[m.cursor].Host
+		}
+		m.creating = true
+		m.err = nil
+		return m, m.createAndAttach(CreateOptions(msg))
+
+	case formCancelMsg:
+		m.form = nil
+		return m, nil
+
+	case templatePickMsg:
+		m.templateMenu = nil
+		f := NewCreateForm(CreateOptions(msg))
+		m.form = &f
+		return m, nil
+
+	case templateMenuCloseMsg:
+		m.templateMenu = nil
+		return m, nil
+
+	case tagFilterMsg:
+		m.tagMenu = nil
+		m.tagFilter = string(msg)
+		m.cursor = 0
+		m.snapshot = ""
+		m.setSessions(m.all)
+		return m, m.fetchSnapshot()
+
+	case tagMenuCloseMsg:
+		m.tagMenu = nil
+		return m, nil
+
+	case errorPanelCloseMsg:
+		m.errPanel = nil
+		return m, nil
+
+	case notificationsCloseMsg:
+		m.notes = nil
+		return m, nil
+
+	case notificationJumpMsg:
+		m.notes = nil
+		i := slices.IndexFunc(m.sessions, func(s Session) bool { return s.Name == string(msg) })
+		if i < 0 {
+			m.notice = string(msg) + " isn't listed"
+			if !m.showAll {
+				m.notice += fmt.Sprintf("; %s shows exited sessions", helpKey(m.keys.ShowAll))
+			}
+			return m, nil
+		}
+		m.cursor, m.onHeader = i, false
+		if m.grouped && m.collapsed[m.sessions[i].Project] {
+			m.onHeader = true
+		}
+		m.syncPreview()
+		return m, m.fetchSnapshot()
+
+	case hostPickMsg:
+		m.hostMenu = nil
+		m.result = DashboardResult{Action: ActionSwitchHost, Host: string(msg)}
+		return m, tea.Quit
+
+	case hostMenuCloseMsg:
+		m.hostMenu = nil
+		return m, nil
+
+	case wallCloseMsg:
+		m.wall = nil
+		m.watch.SetFast(false)
+		return m, nil
+
+	case detailCloseMsg:
+		m.detail = nil
+		return m, nil
+
+	case detailActionMsg:
+		m.detail = nil
+		return m.updateNormal(tea.KeyMsg(msg))
+
+	case auditMsg:
+		if m.detail != nil && m.detail.name == msg.name {
+			m.detail.events = msg.events
+		}
+		return m, nil
+
 	case summarizeMsg:
 		m.summarizing = ""
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
 		if msg.err == nil && msg.desc != "" {
-			for i, s := range m.sessions {
-				if s.Name == msg.name {
-					m.sessions[i].Description = msg.desc
-					break
-				}
-			}
+			m.setDescription(msg.name, msg.desc)
 		} else if msg.err != nil {
-			m.err = msg.err
+			m.setErr(msg.err)
+			m.watch.notes.add(noteSummarize, msg.name, "summary of %s failed: %v", msg.name, msg.err)
 		}
 		return m, nil
 
+	case summaryProgressMsg:
+		if m.progress == nil {
+			return m, nil
+		}
+		if errors.Is(msg.err, context.Canceled) {
+			return m, m.progress.next
+		}
+		if msg.err == nil {
+			m.setDescription(msg.name, msg.desc)
+		} else {
+			m.watch.notes.add(noteSummarize, msg.name, "summary of %s failed: %v", msg.name, msg.err)
+		}
+		m.progress.items = append(m.progress.items, bulkItem{name: msg.name, err: msg.err})
+		return m, m.progress.next
+
+	case summarizeDoneMsg:
+		m.summarizing = ""
+		if m.progress != nil {
+			m.bulk = &bulkDoneMsg{op: "summarize", items: m.progress.items}
+			m.progress = nil
+		}
+		if msg.listErr != nil {
+			m.setErr(msg.listErr)
+			return m, nil
+		}
+		m.setSessions(msg.sessions)
+		return m, m.fetchSnapshot()
+
 	case errMsg:
-		m.err = msg.err
+		m.setErr(msg.err)
 		m.creating = false
+		if e := asAPIError(msg.err); e != nil && e.NotFound() {
+			// Our list is stale; refresh now rather than on the next tick.
+			return m, m.fetchSessions()
+		}
 		return m, m.tick()
 	}
 
 	return m, nil
 }
 
+func (m *DashboardModel) setSessions(sessions []Session) {
+	var selected, project string
+	if m.cursor < len(m.sessions) {
+		selected, project = m.sessions[m.cursor].Name, m.sessions[m.cursor].Project
+	}
+	m.all = sessions
+	m.sessions = sortSessions(filterByTag(sessions, m.tagFilter), m.sortBy, m.watch)
+	if m.grouped {
+		m.sessions = groupByProject(m.sessions)
+	}
+	// Keep the cursor on the same session, or project header, when the
+	// order changes.
+	if i := m.projectStart(project); m.onHeader && m.grouped && i >= 0 {
+		m.cursor = i
+	} else {
+		m.onHeader = false
+		for i, s := range m.sessions {
+			if s.Name == selected {
+				m.cursor = i
+			}
+		}
+	}
+	if m.cursor >= len(m.sessions) {
+		m.cursor = max(0, len(m.sessions)-1)
+	}
+	// A session in a collapsed project can't be selected; its header can.
+	if m.grouped && !m.onHeader && m.cursor < len(m.sessions) && m.collapsed[m.sessions[m.cursor].Project] {
+		m.cursor, m.onHeader = m.projectStart(m.sessions[m.cursor].Project), true
+	}
+	if m.finder != nil {
+		m.finder.SetItems(m.sessions)
+	}
+	if m.detail != nil && !hasSession(m.sessions, m.detail.name) {
+		m.detail = nil
+	}
+	// Drop marks for sessions that have gone away
+	for name := range m.marked {
+		if !hasSession(m.sessions, name) {
+			delete(m.marked, name)
+		}
+	}
+}
+
+// setDescription shows a new description before the next list has it.
+func (m *DashboardModel) setDescription(name, desc string) {
+	for i, s := range m.sessions {
+		if s.Name == name {
+			m.sessions[i].Description = desc
+			break
+		}
+	}
+}
+
+// running is the visible sessions whose process is still alive.
+func (m DashboardModel) running() []Session {
+	var out []Session
+	for _, s := range m.sessions {
+		if s.Alive {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (m DashboardModel) cursorWaiting() bool {
+	return m.cursor < len(m.sessions) && !m.onHeader && m.waiting[m.sessions[m.cursor].Name]
+}
+
+// targets are the marked sessions, or the one under the cursor if none are
+// marked.
+func (m DashboardModel) targets() []string {
+	if len(m.marked) > 0 {
+		var names []string
+		for _, s := range m.sessions {
+			if m.marked[s.Name] {
+				names = append(names, s.Name)
+			}
+		}
+		return names
+	}
+	if m.cursor < len(m.sessions) {
+		return []string{m.sessions[m.cursor].Name}
+	}
+	return nil
+}
+
 func (m DashboardModel) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "q", "ctrl+c":
+	k := m.keys
+	m.bulk = nil
+	m.notice = ""
+	if e := asAPIError(m.err); e != nil && e.NotFound() {
+		m.err = nil
+	}
+	if m.onHeader && m.cursor < len(m.sessions) {
+		var cmd tea.Cmd
+		var done bool
+		if m, cmd, done = m.updateHeader(msg); done {
+			return m, cmd
+		}
+	}
+	switch {
+	case msg.String() == "esc" && m.summarizing != "":
+		if m.inflight.summarize != nil {
+			m.inflight.summarize()
+			m.inflight.summarize = nil
+		}
+	case msg.String() == "esc" && len(m.marked) > 0:
+		m.marked = nil
+	case k.Matches(msg, k.Mark):
+		if m.cursor < len(m.sessions) {
+			name := m.sessions[m.cursor].Name
+			if m.marked == nil {
+				m.marked = map[string]bool{}
+			}
+			if m.marked[name] {
+				delete(m.marked, name)
+			} else {
+				m.marked[name] = true
+			}
+			if m.move(1) {
+				m.snapshot = ""
+				return m, m.fetchSnapshot()
+			}
+		}
+	case k.Matches(msg, k.MarkAll):
+		if len(m.marked) == len(m.sessions) {
+			m.marked = nil
+		} else {
+			m.marked = map[string]bool{}
+			for _, s := range m.sessions {
+				m.marked[s.Name] = true
+			}
+		}
+	case k.Matches(msg, k.Help):
+		m.showHelp = true
+		return m, nil
+	case k.Matches(msg, k.Hosts):
+		h := NewHostMenu(m.hosts, m.host)
+		m.hostMenu = &h
+		return m, nil
+	case k.Matches(msg, k.TagFilter):
+		t := NewTagMenu(m.all, m.tagFilter)
+		m.tagMenu = &t
+		return m, nil
+	case msg.String() == "esc" && m.tagFilter != "":
+		m.tagFilter = ""
+		m.setSessions(m.all)
+		return m, m.fetchSnapshot()
+	case k.Matches(msg, k.Tag):
+		if len(m.sessions) > 0 {
+			m.prompt = newPrompt("")
+			m.prompt.Placeholder = "tag -removed-tag"
+			m.mode = modeTag
+		}
+	case k.Matches(msg, k.Send):
+		if len(m.sessions) > 0 {
+			m.prompt = newPrompt("")
+			m.prompt.Placeholder = "text to send, enter alone sends a newline"
+			m.mode = modeSend
+		}
+	case k.Matches(msg, k.Approve) && m.cursorWaiting():
+		return m.answer(true)
+	case k.Matches(msg, k.Deny) && m.cursorWaiting():
+		return m.answer(false)
+	case k.Matches(msg, k.Notifications):
+		n := NewNotificationPanel(m.watch.notes)
+		m.notes = &n
+		return m, nil
+	case k.Matches(msg, k.Errors):
+		e := NewErrorPanel(m.errlog)
+		m.errPanel = &e
+		return m, nil
+	case k.Matches(msg, k.Detail):
+		if m.cursor < len(m.sessions) {
+			name := m.sessions[m.cursor].Name
+			m.detail = &DetailView{name: name}
+			return m, m.fetchAudit(name)
+		}
+		return m, nil
+	case k.Matches(msg, k.Focus):
+		if m.snapshot != "" {
+			m.previewFocus = true
+			return m, m.fetchSnapshot()
+		}
+		return m, nil
+	case k.Matches(msg, k.Search):
+		if m.snapshot != "" {
+			m.previewFocus = true
+			m.prompt = newPrompt(m.search)
+			m.mode = modeSearch
+			return m, m.fetchSnapshot()
+		}
+		return m, nil
+	case k.Matches(msg, k.Export) && len(m.marked) > 0:
+		api := m.api
+		names := m.targets()
+		m.marked = nil
+		return m, runBulk(m.ctx, "export", names, func(ctx context.Context, name string) error {
+			_, err := exportSession(ctx, api, name, "md", "")
+			return err
+		}, m.lister())
+	case k.Matches(msg, k.Export):
+		if m.cursor < len(m.sessions) {
+			ctx, api, name := m.ctx, m.api, m.sessions[m.cursor].Name
+			return m, func() tea.Msg {
+				path, err := exportSession(ctx, api, name, "md", "")
+				if err != nil {
+					return errMsg{err}
+				}
+				return noticeMsg("exported " + name + " to " + path)
+			}
+		}
+	case k.Matches(msg, k.Download):
+		if m.cursor < len(m.sessions) {
+			m.target = m.sessions[m.cursor].Name
+			m.prompt = newPrompt("")
+			m.prompt.Placeholder = "path in the session's working directory"
+			m.mode = modeDownload
+		}
+	case k.Matches(msg, k.Editor):
+		if m.cursor < len(m.sessions) {
+			ctx, api, s := m.ctx, m.api, m.sessions[m.cursor]
+			cfg, hosts, profile := m.editor, m.hosts, cmp.Or(s.Host, m.host)
+			return m, func() tea.Msg {
+				if err := openEditor(ctx, api, cfg, hosts, profile, s); err != nil {
+					return errMsg{err}
+				}
+				return noticeMsg("opened " + s.Cwd + " in your editor")
+			}
+		}
+	case k.Matches(msg, k.Wall):
+		m.wall = &WallView{}
+		m.watch.SetFast(true)
+		return m, nil
+	case k.Matches(msg, k.Conversation):
+		m.conversation = !m.conversation
+		m.notice = "preview shows the screen"
+		if m.conversation {
+			m.notice = "preview shows Claude sessions' conversations"
+		}
+		st := LoadState()
+		st.Conversation = m.conversation
+		return m, tea.Batch(m.fetchSnapshot(), func() tea.Msg {
+			SaveState(st)
+			return nil
+		})
+	case k.Matches(msg, k.Sort):
+		m.sortBy = m.sortBy.next()
+		m.setSessions(m.all)
+		st := LoadState()
+		st.Sort = m.sortBy
+		return m, func() tea.Msg {
+			SaveState(st)
+			return nil
+		}
+	case k.Matches(msg, k.Quit):
 		m.result = DashboardResult{Action: ActionQuit}
 		return m, tea.Quit
-	case "j", "down":
-		if m.cursor < len(m.sessions)-1 {
-			m.cursor++
+	case k.Matches(msg, k.Down):
+		if m.move(1) {
 			m.snapshot = ""
 			return m, m.fetchSnapshot()
 		}
-	case "k", "up":
-		if m.cursor > 0 {
-			m.cursor--
+	case k.Matches(msg, k.Up):
+		if m.move(-1) {
 			m.snapshot = ""
 			return m, m.fetchSnapshot()
 		}
-	case "enter":
+	case k.Matches(msg, k.Group):
+		m.grouped = !m.grouped
+		m.setSessions(m.all)
+		st := LoadState()
+		st.Grouped = m.grouped
+		return m, func() tea.Msg {
+			SaveState(st)
+			return nil
+		}
+	case k.Matches(msg, k.Collapse):
+		return m.setCollapsed(true)
+	case k.Matches(msg, k.Expand):
+		return m.setCollapsed(false)
+	case k.Matches(msg, k.Project):
+		if len(m.sessions) > 0 {
+			value := ""
+			if names := m.targets(); len(names) == 1 {
+				value = m.sessions[m.cursor].Project
+			}
+			m.prompt = newPrompt(value)
+			m.prompt.Placeholder = "project, empty for none"
+			m.mode = modeProject
+		}
+	case k.Matches(msg, k.Attach):
 		if len(m.sessions) > 0 && m.cursor < len(m.sessions) {
+			if !m.sessions[m.cursor].Alive {
+				m.err = fmt.Errorf("%s is not running (%s to restart)", m.sessions[m.cursor].Name, helpKey(k.Restart))
+				return m, nil
+			}
 			m.result = DashboardResult{
 				Action:      ActionAttach,
 				SessionName: m.sessions[m.cursor].Name,
 			}
 			return m, tea.Quit
 		}
-	case "c":
+	case k.Matches(msg, k.Find):
+		f := NewFinder(m.sessions, m.width, m.height)
+		m.finder = &f
+		return m, nil
+	case k.Matches(msg, k.Create):
+		if !m.creating && len(m.templates) > 0 {
+			t := NewTemplateMenu(m.templates)
+			m.templateMenu = &t
+			return m, nil
+		}
 		if !m.creating {
-			m.creating = true
-			m.err = nil
-			return m, m.createAndAttach()
+			preset := CreateOptions{}
+			if m.grouped && m.cursor < len(m.sessions) {
+				preset.Project = m.sessions[m.cursor].Project
+			}
+			f := NewCreateForm(preset)
+			m.form = &f
+			return m, nil
+		}
+	case k.Matches(msg, k.Issue):
+		if !m.creating {
+			m.prompt = newPrompt("")
+			m.prompt.Placeholder = "owner/repo#123 or the issue's URL"
+			m.mode = modeIssue
+		}
+	case k.Matches(msg, k.Summarize) && len(m.marked) > 0:
+		if m.summarizing == "" {
+			m.summarizing = "bulk"
+			names := m.targets()
+			m.marked = nil
+			return m, m.summarizeMany(names)
 		}
-	case "s":
+	case k.Matches(msg, k.Summarize):
 		if len(m.sessions) > 0 && m.summarizing == "" {
 			name := m.sessions[m.cursor].Name
 			m.summarizing = name
-			api := m.api
+			api, req := m.api, m.summarize
+			ctx := m.summarizeContext()
 			return m, func() tea.Msg {
-				desc, err := api.Summarize(name)
+				desc, err := api.Summarize(ctx, name, req)
 				return summarizeMsg{name: name, desc: desc, err: err}
 			}
 		}
-	case "S":
+	case k.Matches(msg, k.SummarizeAll):
 		if len(m.sessions) > 0 && m.summarizing == "" {
 			m.summarizing = "all"
-			api := m.api
-			sessions := make([]Session, len(m.sessions))
-			copy(sessions, m.sessions)
-			return m, func() tea.Msg {
-				for _, sess := range sessions {
-					api.Summarize(sess.Name)
-				}
-				updated, err := api.ListSessions()
-				if err != nil {
-					return errMsg{err}
-				}
-				return sessionsMsg(updated)
+			names := make([]string, len(m.sessions))
+			for i, sess := range m.sessions {
+				names[i] = sess.Name
 			}
+			return m, m.summarizeMany(names)
 		}
-	case "d":
+	case k.Matches(msg, k.AutoSummarize):
+		m.notice = "auto-summarize off"
+		if m.watch.auto.Toggle() {
+			m.notice = "auto-summarize on: sessions whose screens change a lot are summarized again"
+		}
+		return m, nil
+	case k.Matches(msg, k.Delete):
 		if len(m.sessions) > 0 {
 			m.mode = modeDelete
+			// Refresh the screen the prompt quotes.
+			return m, m.fetchSnapshot()
+		}
+	case k.Matches(msg, k.Share):
+		if m.cursor < len(m.sessions) {
+			return m, m.share(m.sessions[m.cursor].Name)
+		}
+	case k.Matches(msg, k.ShowAll):
+		m.showAll = !m.showAll
+		return m, m.fetchSessions()
+	case k.Matches(msg, k.Archive) && len(m.marked) > 0:
+		m.mode = modeArchive
+		return m, nil
+	case k.Matches(msg, k.Archive):
+		if m.cursor < len(m.sessions) {
+			// Archiving a running session stops it, so ask first.
+			if m.sessions[m.cursor].Alive {
+				m.mode = modeArchive
+				return m, nil
+			}
+			m.err = nil
+			return m, m.archive(m.sessions[m.cursor])
+		}
+	case k.Matches(msg, k.ShowArchived):
+		m.showArchived = !m.showArchived
+		m.api.SetShowArchived(m.showArchived)
+		return m, m.fetchSessions()
+	case k.Matches(msg, k.AllUsers):
+		if m.account == nil || !m.account.Admin {
+			m.notice = "only admins can see other users' sessions"
+			return m, nil
+		}
+		m.allUsers = !m.allUsers
+		m.api.SetAllUsers(m.allUsers)
+		return m, m.fetchSessions()
+	case k.Matches(msg, k.Restart):
+		if m.cursor < len(m.sessions) {
+			// Restarting a running session kills its process, so ask first.
+			if m.sessions[m.cursor].Alive {
+				m.mode = modeRestart
+				return m, nil
+			}
+			m.err = nil
+			return m, m.restart(m.sessions[m.cursor].Name)
+		}
+	case k.Matches(msg, k.Edit):
+		if m.cursor < len(m.sessions) {
+			m.target = m.sessions[m.cursor].Name
+			m.prompt = newPrompt(m.sessions[m.cursor].Description)
+			m.prompt.CharLimit = 500
+			m.prompt.Width = max(20, m.width-len(m.target)-16)
+			m.mode = modeEdit
+		}
+	case k.Matches(msg, k.Rename):
+		if len(m.sessions) > 0 && m.cursor < len(m.sessions) {
+			m.target = m.sessions[m.cursor].Name
+			m.prompt = newPrompt(m.sessions[m.cursor].BareName())
+			m.mode = modeRename
 		}
 	}
 	return m, nil
 }
 
-func (m DashboardModel) createAndAttach() tea.Cmd {
-	api := m.api
+// newPrompt returns a focused single-line input for footer prompts.
+func newPrompt(value string) textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.CharLimit = 200
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	ti.SetValue(value)
+	ti.CursorEnd()
+	ti.Focus()
+	return ti
+}
+
+var validSessionName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func (m DashboardModel) updateTag(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeNormal
+		return m, nil
+	case "enter":
+		m.mode = modeNormal
+		edit := strings.Fields(m.prompt.Value())
+		if len(edit) == 0 {
+			return m, nil
+		}
+		current := make(map[string][]string, len(m.sessions))
+		for _, s := range m.sessions {
+			current[s.Name] = s.Tags
+		}
+		names := m.targets()
+		m.marked = nil
+		api := m.api
+		return m, runBulk(m.ctx, "tag", names, func(ctx context.Context, name string) error {
+			return api.UpdateMetadata(ctx, name, SessionMetadata{Tags: applyTagEdit(current[name], edit)})
+		}, m.lister())
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+func (m DashboardModel) updateSend(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeNormal
+		return m, nil
+	case "enter":
+		m.mode = modeNormal
+		text := m.prompt.Value() + "\r"
+		names := m.targets()
+		m.marked = nil
+		api := m.api
+		return m, runBulk(m.ctx, "send", names, func(ctx context.Context, name string) error {
+			return api.SendKeys(ctx, name, text)
+		}, m.lister())
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+func (m DashboardModel) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeNormal
+		return m, nil
+	case "enter":
+		name, desc := m.target, strings.TrimSpace(m.prompt.Value())
+		m.mode = modeNormal
+		// Show the new description straight away; the next refresh
+		// confirms it.
+		for i := range m.sessions {
+			if m.sessions[i].Name == name {
+				if m.sessions[i].Description == desc {
+					return m, nil
+				}
+				m.sessions[i].Description = desc
+			}
+		}
+		ctx, api, list := m.ctx, m.api, m.lister()
+		return m, func() tea.Msg {
+			if err := api.UpdateDescription(ctx, name, desc); err != nil {
+				return errMsg{err}
+			}
+			sessions, err := list(ctx)
+			if err != nil {
+				return errMsg{err}
+			}
+			return sessionsMsg(sessions)
+		}
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+func (m DashboardModel) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeNormal
+		return m, nil
+	case "enter":
+		oldName, newName := m.target, strings.TrimSpace(m.prompt.Value())
+		m.mode = modeNormal
+		if _, bare, ok := strings.Cut(oldName, "/"); ok && newName == bare {
+			return m, nil
+		}
+		if newName == "" || newName == oldName {
+			return m, nil
+		}
+		if !validSessionName.MatchString(newName) {
+			m.err = fmt.Errorf("invalid name %q: use letters, digits, - and _", newName)
+			return m, nil
+		}
+		api := m.api
+		list := m.lister()
+		ctx := m.ctx
+		return m, func() tea.Msg {
+			if err := api.RenameSession(ctx, oldName, newName); err != nil {
+				return errMsg{err}
+			}
+			sessions, err := list(ctx)
+			if err != nil {
+				return errMsg{err}
+			}
+			return sessionsMsg(sessions)
+		}
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+// updateIssue handles the issue prompt, starting Claude on the issue in a
+// new session and attaching to it.
+func (m DashboardModel) updateIssue(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeNormal
+		return m, nil
+	case "enter":
+		ref := strings.TrimSpace(m.prompt.Value())
+		m.mode = modeNormal
+		if ref == "" {
+			return m, nil
+		}
+		var opts CreateOptions
+		if m.cursor < len(m.sessions) {
+			// An aggregated dashboard creates on the cursor session's host.
+			opts.Host = m.sessions[m.cursor].Host
+		}
+		m.creating = true
+		m.err = nil
+		ctx, api, cfg := m.ctx, m.api, m.github
+		return m, func() tea.Msg {
+			// A name that's taken isn't worth stopping for.
+			s, err := createFromIssue(ctx, api, cfg, ref, opts)
+			if s == nil {
+				return errMsg{err}
+			}
+			return attachMsg(s.Name)
+		}
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+// updateDownload handles the download prompt, saving the file into the
+// directory the dashboard was started in.
+func (m DashboardModel) updateDownload(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeNormal
+		return m, nil
+	case "enter":
+		path := strings.TrimSpace(m.prompt.Value())
+		m.mode = modeNormal
+		if path == "" {
+			return m, nil
+		}
+		ctx, api, name := m.ctx, m.api, m.target
+		return m, func() tea.Msg {
+			local, n, err := downloadFile(ctx, api, name, path, ".")
+			if err != nil {
+				return errMsg{err}
+			}
+			return noticeMsg(fmt.Sprintf("saved %s:%s to %s (%s)", name, path, local, sizeLabel(n)))
+		}
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+func (m DashboardModel) createAndAttach(opts CreateOptions) tea.Cmd {
+	ctx, api := m.ctx, m.api
 	return func() tea.Msg {
-		session, err := api.CreateSession("", "claude")
+		session, err := api.CreateSession(ctx, opts)
 		if err != nil {
 			return errMsg{err}
 		}
@@ -226,16 +1345,94 @@ func (m DashboardModel) createAndAttach() tea.Cmd {
 	}
 }
 
+// deleteLines is how much of a session's screen the delete prompt quotes.
+const deleteLines = 5
+
+// deleteTargets spells out what the delete prompt is about to remove, so a
+// cursor one row off doesn't cost the wrong session: the marked sessions'
+// names and descriptions, or the cursor session's and the bottom of its
+// screen.
+func (m DashboardModel) deleteTargets() string {
+	width := max(20, m.width-6)
+	var s strings.Builder
+	line := func(style lipgloss.Style, text string) {
+		s.WriteString("  " + m.ruleStyle().Render("│") + " " + style.Render(truncate(text, width)) + "\n")
+	}
+	if len(m.marked) > 0 {
+		names := m.targets()
+		for i, name := range names {
+			if i == 8 {
+				line(dimStyle, fmt.Sprintf("and %d more", len(names)-i))
+				break
+			}
+			text := name
+			if i := slices.IndexFunc(m.all, func(s Session) bool { return s.Name == name }); i >= 0 && m.all[i].Description != "" {
+				text += "  " + m.all[i].Description
+			}
+			line(normStyle, text)
+		}
+		return s.String()
+	}
+	if m.cursor >= len(m.sessions) {
+		return ""
+	}
+	sess := m.sessions[m.cursor]
+	line(selStyle, fmt.Sprintf("%s  %s  started %s", sess.Name, sess.Command, timeAgo(sess.CreatedAt)))
+	if sess.Description != "" {
+		line(normStyle, sess.Description)
+	}
+	var screen []string
+	for _, l := range strings.Split(stripANSI(m.snapshot), "\n") {
+		if strings.TrimSpace(l) != "" {
+			screen = append(screen, strings.TrimRight(l, " "))
+		}
+	}
+	for _, l := range screen[max(0, len(screen)-deleteLines):] {
+		line(previewStyle, l)
+	}
+	return s.String()
+}
+
+// updateDelete answers the delete prompt: k stops the process but keeps the
+// session and its output, y removes it entirely.
 func (m DashboardModel) updateDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
+	case "k", "K":
+		m.mode = modeNormal
+		if len(m.marked) > 0 {
+			names := m.targets()
+			m.marked = nil
+			return m, runBulk(m.ctx, "kill", names, m.api.KillSession, m.lister())
+		}
+		if m.cursor < len(m.sessions) && m.sessions[m.cursor].Alive {
+			ctx, api, list := m.ctx, m.api, m.lister()
+			name := m.sessions[m.cursor].Name
+			return m, func() tea.Msg {
+				if err := api.KillSession(ctx, name); err != nil {
+					return errMsg{err}
+				}
+				sessions, err := list(ctx)
+				if err != nil {
+					return errMsg{err}
+				}
+				return sessionsMsg(sessions)
+			}
+		}
 	case "y", "Y":
+		if len(m.marked) > 0 {
+			names := m.targets()
+			m.marked = nil
+			m.mode = modeNormal
+			return m, runBulk(m.ctx, "delete", names, m.api.DeleteSession, m.lister())
+		}
 		if m.cursor < len(m.sessions) {
 			name := m.sessions[m.cursor].Name
 			m.mode = modeNormal
 			api := m.api
+			ctx, list := m.ctx, m.lister()
 			return m, func() tea.Msg {
-				_ = api.DeleteSession(name)
-				sessions, err := api.ListSessions()
+				_ = api.DeleteSession(ctx, name)
+				sessions, err := list(ctx)
 				if err != nil {
 					return errMsg{err}
 				}
@@ -249,83 +1446,191 @@ func (m DashboardModel) updateDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// Styles
-var (
-	titleStyle   = lipgloss.NewStyle().Bold(true)
-	dimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	selStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15"))
-	normStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("250"))
-	cmdStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-	tStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	errSty       = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
-	warnSty      = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
-	promptSty    = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
-	previewStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("248"))
-)
+func (m DashboardModel) updateArchive(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeNormal
+	if s := msg.String(); s != "y" && s != "Y" {
+		return m, nil
+	}
+	m.err = nil
+	if len(m.marked) > 0 {
+		names := m.targets()
+		m.marked = nil
+		return m, runBulk(m.ctx, "archive", names, m.api.ArchiveSession, m.lister())
+	}
+	if m.cursor < len(m.sessions) {
+		return m, m.archive(m.sessions[m.cursor])
+	}
+	return m, nil
+}
+
+func (m DashboardModel) updateRestart(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeNormal
+	if s := msg.String(); (s == "y" || s == "Y") && m.cursor < len(m.sessions) {
+		m.err = nil
+		return m, m.restart(m.sessions[m.cursor].Name)
+	}
+	return m, nil
+}
+
+// restart relaunches a session's command in place and refreshes the list.
+func (m DashboardModel) restart(name string) tea.Cmd {
+	ctx, api, list := m.ctx, m.api, m.lister()
+	return func() tea.Msg {
+		if err := api.RestartSession(ctx, name); err != nil {
+			return errMsg{err}
+		}
+		sessions, err := list(ctx)
+		if err != nil {
+			return errMsg{err}
+		}
+		return sessionsMsg(sessions)
+	}
+}
 
 func (m DashboardModel) View() string {
 	var s strings.Builder
 
 	s.WriteString("\n")
 	s.WriteString("  " + titleStyle.Render("claude-host"))
+	if m.host == allHosts {
+		s.WriteString(promptSty.Render(" @ all hosts"))
+	} else if m.host != "" {
+		s.WriteString(promptSty.Render(" @ " + m.host))
+	}
+	if m.account != nil && m.account.Name != localUser {
+		s.WriteString(dimStyle.Render(" as " + m.account.Name))
+	}
+	if m.allUsers {
+		s.WriteString(promptSty.Render("  all users"))
+	}
+	if m.showArchived {
+		s.WriteString(promptSty.Render("  archived shown"))
+	}
+	if m.watch.auto.Enabled() {
+		s.WriteString(promptSty.Render("  auto-summary"))
+	}
+	if down := m.api.UnreachableHosts(); len(down) > 0 {
+		s.WriteString(errSty.Render("  unreachable: " + strings.Join(down, ", ")))
+	}
 	if len(m.sessions) > 0 {
 		s.WriteString(dimStyle.Render(fmt.Sprintf("  %d sessions", len(m.sessions))))
+		if dead := countDead(m.sessions); dead > 0 {
+			s.WriteString(dimStyle.Render(fmt.Sprintf(" (%d exited)", dead)))
+		}
+		if cost, ok := totalCost(m.sessions); ok {
+			s.WriteString(dimStyle.Render("  ~" + formatCost(cost)))
+		}
+	}
+	if m.tagFilter != "" {
+		s.WriteString(dimStyle.Render("  tag ") + tagChip(m.tagFilter))
+	}
+	if m.sortBy != sortServer {
+		s.WriteString(dimStyle.Render("  by " + string(m.sortBy)))
 	}
+	if m.grouped {
+		s.WriteString(dimStyle.Render("  grouped by project"))
+	}
+	if n := m.watch.notes.Unread(); n > 0 {
+		s.WriteString(markStyle.Render(fmt.Sprintf("  %d new (%s)", n, helpKey(m.keys.Notifications))))
+	}
+	s.WriteString(m.policyView())
 	s.WriteString("\n\n")
 
-	if m.err != nil {
-		s.WriteString("  " + errSty.Render(fmt.Sprintf("! %v", m.err)) + "\n\n")
+	if m.showHelp {
+		s.WriteString(helpView(m.keys))
+		return s.String()
+	}
+	if m.finder != nil {
+		s.WriteString(m.finder.View())
+		return s.String()
+	}
+	if m.form != nil {
+		s.WriteString(m.form.View())
+		return s.String()
+	}
+	if m.tagMenu != nil {
+		s.WriteString(m.tagMenu.View())
+		return s.String()
+	}
+	if m.hostMenu != nil {
+		s.WriteString(m.hostMenu.View())
+		return s.String()
+	}
+	if m.templateMenu != nil {
+		s.WriteString(m.templateMenu.View())
+		return s.String()
+	}
+	if m.notes != nil {
+		s.WriteString(m.notes.View(m.width, m.height))
+		return s.String()
+	}
+	if m.errPanel != nil {
+		s.WriteString(m.errPanel.View(m.width, m.height))
+		return s.String()
+	}
+	if m.wall != nil {
+		s.WriteString(m.wall.View(m.running(), m.screens, m.waiting, m.watch, m.width, m.height))
+		return s.String()
+	}
+	if m.detail != nil && m.cursor < len(m.sessions) {
+		sess := m.sessions[m.cursor]
+		last := "no output seen yet"
+		if t := m.watch.LastChange(sess.Name); !t.IsZero() {
+			last = "last output " + shortDuration(time.Since(t)) + " ago"
+		}
+		s.WriteString(m.offlineView())
+		s.WriteString(m.detail.View(sess, m.snapshot, last, m.keys, m.width, m.height))
+		s.WriteString(m.statusBar())
+		return s.String()
 	}
 
-	if len(m.sessions) == 0 && m.err == nil {
-		s.WriteString(dimStyle.Render("  No sessions running. Press c to create one.") + "\n")
+	s.WriteString(m.offlineView())
+
+	quiet := m.err == nil && m.offline == nil
+	if len(m.sessions) == 0 && asBadResponse(m.err) != nil {
+		// Not that there are none: the server's answer couldn't be read.
+		s.WriteString(dimStyle.Render("  Couldn't read the session list from the server.") + "\n")
+	} else if len(m.sessions) == 0 && quiet && m.tagFilter != "" {
+		s.WriteString(dimStyle.Render("  No sessions with this tag. Press esc to clear the filter.") + "\n")
+	} else if len(m.sessions) == 0 && quiet {
+		s.WriteString(dimStyle.Render(fmt.Sprintf("  No sessions running. Press %s to create one.", helpKey(m.keys.Create))) + "\n")
 	}
 
-	for i, sess := range m.sessions {
-		prefix := "  "
-		nameS := normStyle
-		if i == m.cursor {
-			prefix = "▸ "
-			nameS = selStyle
+	var list strings.Builder
+	for _, r := range m.rows() {
+		if r.header {
+			list.WriteString(m.renderHeader(r.session) + "\n")
+			continue
 		}
-		name := nameS.Render(fmt.Sprintf("%-22s", sess.Name))
-		cmd := cmdStyle.Render(fmt.Sprintf("%-10s", sess.Command))
-		age := tStyle.Render(timeAgo(sess.CreatedAt))
-		s.WriteString(fmt.Sprintf("  %s%s %s %s\n", prefix, name, cmd, age))
-		if sess.Description != "" {
-			desc := sess.Description
-			if m.width > 10 && len(desc) > m.width-10 {
-				desc = desc[:m.width-10]
-			}
-			s.WriteString("    " + dimStyle.Render(desc) + "\n")
-		} else if m.summarizing == sess.Name {
-			s.WriteString("    " + dimStyle.Render("summarizing...") + "\n")
+		sess := m.sessions[r.session]
+		list.WriteString(m.renderRow(r.session, sess) + "\n")
+		if line := m.detailLine(sess); line != "" {
+			list.WriteString(line + "\n")
 		}
 	}
 
-	// Preview of selected session
-	if len(m.sessions) > 0 && m.snapshot != "" {
-		s.WriteString("\n")
-		w := 56
-		if m.width > 8 {
-			w = min(m.width-8, 72)
-		}
-		s.WriteString("  " + dimStyle.Render(strings.Repeat("─", w)) + "\n")
-
-		lines := strings.Split(strings.TrimRight(m.snapshot, "\n"), "\n")
-		maxLines := 10
-		if m.height > 0 {
-			avail := m.height - len(m.sessions) - 10
-			if avail > 3 {
-				maxLines = min(avail, 18)
-			}
+	// Preview of selected session: beside the list on wide terminals,
+	// below it otherwise.
+	hasPreview := len(m.sessions) > 0 && m.snapshot != "" && !m.onHeader
+	if m.sideBySide() {
+		left := lipgloss.NewStyle().MaxWidth(m.listWidth()).Render(strings.TrimSuffix(list.String(), "\n"))
+		if hasPreview {
+			height := max(lipgloss.Height(left), m.previewHeight())
+			left = lipgloss.NewStyle().Width(m.listWidth()).Height(height).Render(left)
+			rule := m.ruleStyle().Render(strings.TrimSuffix(strings.Repeat("│\n", height), "\n"))
+			left = lipgloss.JoinHorizontal(lipgloss.Top, left, " ", rule, strings.TrimSuffix(m.previewPane(), "\n"))
 		}
-		start := max(0, len(lines)-maxLines)
-		for _, line := range lines[start:] {
-			if m.width > 4 && len(line) > m.width-4 {
-				line = line[:m.width-4]
+		s.WriteString(left + "\n")
+	} else {
+		s.WriteString(list.String())
+		if hasPreview {
+			s.WriteString("\n")
+			w := 56
+			if m.width > 8 {
+				w = min(m.width-8, 72)
 			}
-			s.WriteString("  " + previewStyle.Render(line) + "\n")
+			s.WriteString("  " + m.ruleStyle().Render(strings.Repeat("─", w)) + "\n")
+			s.WriteString(m.previewPane())
 		}
 	}
 
@@ -333,19 +1638,157 @@ func (m DashboardModel) View() string {
 	s.WriteString("\n")
 	switch m.mode {
 	case modeDelete:
+		s.WriteString(m.deleteTargets())
+		const both = "k kill, keep output  y delete entirely  n cancel"
+		if len(m.marked) > 0 {
+			s.WriteString("  " + warnSty.Render(fmt.Sprintf("delete %d sessions? ", len(m.marked))))
+			s.WriteString(dimStyle.Render(both) + "\n")
+		} else if m.cursor < len(m.sessions) {
+			if m.sessions[m.cursor].Alive {
+				s.WriteString("  " + warnSty.Render(fmt.Sprintf("delete %s? ", m.sessions[m.cursor].Name)))
+				s.WriteString(dimStyle.Render(both) + "\n")
+			} else {
+				s.WriteString("  " + warnSty.Render(fmt.Sprintf("purge %s? ", m.sessions[m.cursor].Name)))
+				s.WriteString(dimStyle.Render("y/n") + "\n")
+			}
+		}
+	case modeArchive:
+		if len(m.marked) > 0 {
+			s.WriteString("  " + warnSty.Render(fmt.Sprintf("archive %d sessions? ", len(m.marked))))
+			s.WriteString(dimStyle.Render("running ones are stopped  y/n") + "\n")
+		} else if m.cursor < len(m.sessions) {
+			s.WriteString("  " + warnSty.Render(fmt.Sprintf("archive %s? ", m.sessions[m.cursor].Name)))
+			s.WriteString(dimStyle.Render("stops its process, keeping its output  y/n") + "\n")
+		}
+	case modeRestart:
 		if m.cursor < len(m.sessions) {
-			s.WriteString("  " + warnSty.Render(fmt.Sprintf("delete %s? ", m.sessions[m.cursor].Name)))
-			s.WriteString(dimStyle.Render("y/n") + "\n")
+			s.WriteString("  " + warnSty.Render(fmt.Sprintf("restart %s? ", m.sessions[m.cursor].Name)))
+			s.WriteString(dimStyle.Render("kills its process and runs "+m.sessions[m.cursor].Command+" again, keeping the scrollback  y/n") + "\n")
+		}
+	case modeTag:
+		label := "tag"
+		if names := m.targets(); len(names) > 1 {
+			label = fmt.Sprintf("tag %d sessions", len(names))
+		} else if len(names) == 1 {
+			label = "tag " + names[0]
 		}
+		s.WriteString("  " + promptSty.Render(label+": ") + m.prompt.View() + "\n")
+	case modeSend:
+		label := "send"
+		if names := m.targets(); len(names) > 1 {
+			label = fmt.Sprintf("send to %d sessions", len(names))
+		} else if len(names) == 1 {
+			label = "send to " + names[0]
+		}
+		s.WriteString("  " + promptSty.Render(label+": ") + m.prompt.View() + "\n")
+	case modeSearch:
+		s.WriteString("  " + promptSty.Render("/") + m.prompt.View() + "\n")
+	case modeEdit:
+		s.WriteString("  " + promptSty.Render(fmt.Sprintf("describe %s: ", m.target)) + m.prompt.View() + "\n")
+	case modeRename:
+		s.WriteString("  " + promptSty.Render(fmt.Sprintf("rename %s: ", m.target)) + m.prompt.View() + "\n")
+	case modeIssue:
+		s.WriteString("  " + promptSty.Render("new session from issue: ") + m.prompt.View() + "\n")
+	case modeProject:
+		label := "move to project"
+		if names := m.targets(); len(names) > 1 {
+			label = fmt.Sprintf("move %d sessions to project", len(names))
+		} else if len(names) == 1 {
+			label = "move " + names[0] + " to project"
+		}
+		s.WriteString("  " + promptSty.Render(label+": ") + m.prompt.View() + "\n")
+	case modeDownload:
+		s.WriteString("  " + promptSty.Render(fmt.Sprintf("download from %s: ", m.target)) + m.prompt.View() + "\n")
 	default:
-		if m.creating {
+		if m.bulk != nil {
+			s.WriteString(m.bulk.View())
+		} else if m.notice != "" {
+			s.WriteString("  " + dimStyle.Render(m.notice) + "\n")
+		} else if m.creating {
 			s.WriteString("  " + dimStyle.Render("creating session...") + "\n")
-		} else if m.summarizing == "all" {
-			s.WriteString("  " + dimStyle.Render("summarizing all sessions...") + "\n")
+		} else if m.progress != nil {
+			s.WriteString(m.progress.View("esc"))
+		} else if m.previewFocus {
+			s.WriteString("  " + dimStyle.Render(m.previewHelp()) + "\n")
 		} else {
-			s.WriteString("  " + dimStyle.Render("↑↓ select  enter attach  c new  s summarize  d delete  q quit") + "\n")
+			s.WriteString("  " + dimStyle.Render(m.footerHelp()) + "\n")
 		}
 	}
+	s.WriteString(m.statusBar())
 
 	return s.String()
 }
+
+// ruleStyle is the style of the line between the list and the preview,
+// which lights up while the preview has focus.
+func (m DashboardModel) ruleStyle() lipgloss.Style {
+	if m.previewFocus {
+		return promptSty
+	}
+	return dimStyle
+}
+
+// setErr shows err in the status bar and keeps it in the error log.
+func (m *DashboardModel) setErr(err error) {
+	m.err = err
+	m.errlog.add(err)
+}
+
+// errorText explains m.err, with a hint at what to do about it.
+func (m DashboardModel) errorText() (msg, hint string) {
+	msg = m.err.Error()
+	if e := asBadResponse(m.err); e != nil {
+		msg = "bad response from " + m.api.String() + ": " + e.Err.Error()
+		hint = "a proxy in the way, or another version? " + helpKey(m.keys.Errors) + " for details"
+	}
+	if e := asAPIError(m.err); e != nil {
+		switch {
+		case e.Unreachable():
+			msg = "cannot reach server at " + m.api.String()
+			hint = "is claude-host running? retrying every few seconds"
+		case e.Unauthorized():
+			msg = "unauthorized: " + e.Endpoint
+			hint = "set $CLAUDE_HOST_TOKEN or [auth] token in " + configPath()
+		case e.NotFound():
+			msg = "session not found: " + e.Endpoint
+			hint = "it may have been deleted elsewhere; the list has been refreshed"
+		}
+	}
+	return msg, hint
+}
+
+func countDead(sessions []Session) int {
+	n := 0
+	for _, s := range sessions {
+		if !s.Alive {
+			n++
+		}
+	}
+	return n
+}
+
+func (m DashboardModel) footerHelp() string {
+	k := m.keys
+	if len(m.marked) > 0 {
+		return fmt.Sprintf("%d marked  %s mark  %s all  %s delete  %s summarize  %s tag  %s send  esc clear",
+			len(m.marked), helpKey(k.Mark), helpKey(k.MarkAll), helpKey(k.Delete), helpKey(k.Summarize), helpKey(k.Tag), helpKey(k.Send))
+	}
+	if m.cursorWaiting() {
+		return fmt.Sprintf("%s approve  %s deny  %s attach  %s send  %s help  %s quit",
+			helpKey(k.Approve), helpKey(k.Deny), helpKey(k.Attach), helpKey(k.Send), helpKey(k.Help), helpKey(k.Quit))
+	}
+	if m.cursor < len(m.sessions) && !m.sessions[m.cursor].Alive {
+		return fmt.Sprintf("%s restart  %s purge  %s hide exited  %s quit",
+			helpKey(k.Restart), helpKey(k.Delete), helpKey(k.ShowAll), helpKey(k.Quit))
+	}
+	nav := helpKey(k.Up) + "/" + helpKey(k.Down)
+	if slices.Contains(k.Up, "up") && slices.Contains(k.Down, "down") {
+		nav = "↑↓"
+	}
+	all := "all"
+	if m.showAll {
+		all = "running"
+	}
+	return fmt.Sprintf("%s select  %s attach  %s new  %s delete  %s %s  %s help  %s quit",
+		nav, helpKey(k.Attach), helpKey(k.Create), helpKey(k.Delete), helpKey(k.ShowAll), all, helpKey(k.Help), helpKey(k.Quit))
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DetailView is the full-screen "i" view of one session. It is an overlay
+// like the finder; the session and snapshot come from the dashboard, which
+// keeps refreshing them while the view is open.
+type DetailView struct {
+	name   string
+	scroll int          // snapshot lines scrolled back from the bottom
+	events []AuditEvent // the session's latest, on servers that keep them
+}
+
+type detailCloseMsg struct{}
+
+// detailActionMsg closes the view and replays the key on the dashboard, so
+// attach, rename and delete behave exactly as they do from the list.
+type detailActionMsg tea.KeyMsg
+
+func (d DetailView) Update(msg tea.KeyMsg, k KeyMap) (DetailView, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", msg.String() == "q", k.Matches(msg, k.Detail):
+		return d, func() tea.Msg { return detailCloseMsg{} }
+	case k.Matches(msg, k.Attach), k.Matches(msg, k.Rename), k.Matches(msg, k.Delete),
+		k.Matches(msg, k.Edit), k.Matches(msg, k.Send), k.Matches(msg, k.Restart):
+		return d, func() tea.Msg { return detailActionMsg(msg) }
+	case k.Matches(msg, k.Up), msg.String() == "pgup":
+		d.scroll += 5
+	case k.Matches(msg, k.Down), msg.String() == "pgdown":
+		d.scroll = max(0, d.scroll-5)
+	}
+	return d, nil
+}
+
+func (d DetailView) View(sess Session, snapshot, lastChange string, k KeyMap, width, height int) string {
+	var s strings.Builder
+	s.WriteString("  " + titleStyle.Render(sess.Name))
+	if !sess.Alive {
+		s.WriteString("  " + deadStyle.Render(sess.exitLabel()))
+	}
+	s.WriteString("\n\n")
+
+	field := func(label, value string) {
+		if value == "" {
+			value = dimStyle.Render("-")
+		}
+		s.WriteString(fmt.Sprintf("  %s %s\n", dimStyle.Render(fmt.Sprintf("%-12s", label)), normStyle.Render(value)))
+	}
+	field("command", sess.Command)
+	field("directory", sess.Cwd)
+	if sess.Project != "" {
+		field("project", sess.Project)
+	}
+	if sess.Git != nil {
+		field("git", sess.Git.String())
+	}
+	field("created", sess.CreatedAt+"  ("+timeAgo(sess.CreatedAt)+")")
+	if t := sess.Tokens; t != nil {
+		field("tokens", fmt.Sprintf("%s in, %s out, %s cache write, %s cache read", formatCount(t.Input), formatCount(t.Output), formatCount(t.CacheWrite), formatCount(t.CacheRead)))
+		field("cost", "~"+formatCost(t.CostUSD))
+	}
+	if sess.Status != "" {
+		field("status", sess.Status)
+	}
+	field("activity", lastChange)
+	if len(sess.Tags) > 0 {
+		s.WriteString(fmt.Sprintf("  %s %s\n", dimStyle.Render(fmt.Sprintf("%-12s", "tags")), renderChips(sess.Tags)))
+	} else {
+		field("tags", "")
+	}
+	if len(sess.Env) > 0 {
+		keys := make([]string, 0, len(sess.Env))
+		for k := range sess.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			label := ""
+			if i == 0 {
+				label = "env"
+			}
+			s.WriteString(fmt.Sprintf("  %s %s\n", dimStyle.Render(fmt.Sprintf("%-12s", label)), normStyle.Render(k+"="+sess.Env[k])))
+		}
+	} else {
+		field("env", "")
+	}
+	for i, ev := range slices.Backward(d.events) {
+		label := ""
+		if i == len(d.events)-1 {
+			label = "events"
+		}
+		s.WriteString(fmt.Sprintf("  %s %s %s\n", dimStyle.Render(fmt.Sprintf("%-12s", label)), tStyle.Render(fmt.Sprintf("%-8s", timeAgo(ev.Time))), normStyle.Render(ev.String())))
+	}
+	s.WriteString("\n")
+
+	descW := 72
+	if width > 8 {
+		descW = width - 6
+	}
+	if sess.Description != "" {
+		for _, line := range wrapText(sess.Description, descW) {
+			s.WriteString("  " + normStyle.Render(line) + "\n")
+		}
+		s.WriteString("\n")
+	}
+
+	used := strings.Count(s.String(), "\n") + 6 // separator, footer, title block
+	lines := strings.Split(strings.TrimRight(snapshot, "\n"), "\n")
+	avail := max(5, height-used)
+	end := max(0, len(lines)-d.scroll)
+	start := max(0, end-avail)
+	s.WriteString("  " + dimStyle.Render(strings.Repeat("─", max(10, min(descW, 100)))) + "\n")
+	for _, line := range lines[start:end] {
+		if width > 4 {
+			line = truncate(line, width-4)
+		}
+		s.WriteString("  " + previewStyle.Render(line) + "\n")
+	}
+	s.WriteString("\n  " + dimStyle.Render(fmt.Sprintf("%s attach  %s edit  %s rename  %s delete  %s send  ↑↓ scroll  esc back",
+		helpKey(k.Attach), helpKey(k.Edit), helpKey(k.Rename), helpKey(k.Delete), helpKey(k.Send))) + "\n")
+	return s.String()
+}
+
+// wrapText breaks text into lines at most width cells wide at word
+// boundaries.
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line != "" && textWidth(line)+1+textWidth(word) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// doctor checks each step of reaching a server in turn, so "cannot reach
+// server" can be pinned on DNS, the network, TLS, the server or the token.
+// A step that fails skips the steps that need it.
+
+// clockSkewLimit is how far the server's clock may be from ours before
+// doctor complains; share links and TLS both depend on clocks agreeing.
+const clockSkewLimit = 5 * time.Second
+
+type checkResult int
+
+const (
+	checkPass checkResult = iota
+	checkWarn
+	checkFail
+	checkSkip
+)
+
+type doctorCheck struct {
+	name   string
+	result checkResult
+	detail string
+}
+
+var checkMarks = [...]string{checkPass: "✓", checkWarn: "!", checkFail: "✗", checkSkip: "-"}
+
+func (c doctorCheck) String() string {
+	return fmt.Sprintf("%s %-9s %s", checkMarks[c.result], c.name, c.detail)
+}
+
+// runDoctor checks the path to api's server, printing each result to w as it
+// goes, and returns how many failed.
+func runDoctor(ctx context.Context, api *APIClient, w io.Writer) int {
+	failed := 0
+	report := func(name string, result checkResult, format string, args ...any) {
+		c := doctorCheck{name: name, result: result, detail: fmt.Sprintf(format, args...)}
+		fmt.Fprintln(w, c)
+		if result == checkFail {
+			failed++
+		}
+	}
+	fmt.Fprintf(w, "checking %s\n\n", api)
+
+	reached := doctorNetwork(ctx, api, report)
+
+	var health *Health
+	switch {
+	case !reached:
+		report("health", checkSkip, "server not reachable")
+	default:
+		h, err := api.Health(ctx)
+		switch e := asAPIError(err); {
+		case err == nil:
+			health = h
+			report("health", checkPass, "server %s, %dms", h.Version, h.Latency.Milliseconds())
+		case e != nil && e.NotFound():
+			report("health", checkWarn, "no /api/health; an older or Node server?")
+		default:
+			report("health", checkFail, "%v", err)
+			reached = false
+		}
+	}
+
+	if !reached {
+		report("auth", checkSkip, "server not reachable")
+		report("websocket", checkSkip, "server not reachable")
+	} else {
+		authed := true
+		_, err := api.ListSessions(ctx)
+		switch e := asAPIError(err); {
+		case err == nil && api.token == "":
+			report("auth", checkPass, "no token needed")
+		case err == nil:
+			report("auth", checkPass, "token accepted")
+		case e != nil && e.Unauthorized() && api.token == "":
+			report("auth", checkFail, "the server wants a token; set $CLAUDE_HOST_TOKEN or [auth] token in %s", configPath())
+			authed = false
+		case e != nil && e.Unauthorized():
+			report("auth", checkFail, "token rejected (%d)", e.Status)
+			authed = false
+		default:
+			report("auth", checkFail, "%v", err)
+			authed = false
+		}
+		ectx, cancel := context.WithCancel(ctx)
+		_, err = api.Events(ectx)
+		cancel()
+		switch {
+		case err == nil:
+			report("websocket", checkPass, "upgraded /ws/events")
+		case !authed:
+			report("websocket", checkSkip, "needs auth")
+		default:
+			report("websocket", checkFail, "%v", err)
+		}
+	}
+
+	switch {
+	case health == nil:
+		report("clock", checkSkip, "needs /api/health")
+	default:
+		// The server read its clock about halfway through the round trip.
+		skew := time.Until(health.Time.Add(-health.Latency / 2)).Round(time.Millisecond)
+		if skew.Abs() > clockSkewLimit {
+			report("clock", checkFail, "server is %s %s", skew.Abs(), aheadOrBehind(skew))
+		} else {
+			report("clock", checkPass, "within %s", clockSkewLimit)
+		}
+	}
+	return failed
+}
+
+func aheadOrBehind(d time.Duration) string {
+	if d > 0 {
+		return "ahead"
+	}
+	return "behind"
+}
+
+// doctorNetwork checks DNS, the connection and TLS, and reports whether the server's
+// port could be reached.
+func doctorNetwork(ctx context.Context, api *APIClient, report func(string, checkResult, string, ...any)) bool {
+	if api.socket != "" {
+		report("dns", checkSkip, "unix socket")
+		if api.conn.SSH == nil {
+			if _, err := os.Stat(api.socket); err != nil {
+				report("socket", checkFail, "%v", err)
+				return false
+			}
+		}
+	}
+	u, err := url.Parse(api.baseURL)
+	if err != nil {
+		report("url", checkFail, "%v", err)
+		return false
+	}
+	host, port := u.Hostname(), u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(host, port)
+
+	viaProxy := false
+	if api.conn.Proxy != nil && api.dial == nil {
+		if p, _ := api.conn.Proxy(&http.Request{URL: u}); p != nil {
+			viaProxy = true
+			report("dns", checkSkip, "through proxy %s", p.Redacted())
+		}
+	}
+	switch {
+	case api.socket != "", viaProxy:
+	case api.conn.SSH != nil:
+		report("dns", checkSkip, "resolved at the far end of ssh %s", api.conn.SSH.dest)
+	case net.ParseIP(host) != nil:
+		report("dns", checkSkip, "%s is an address", host)
+	default:
+		ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			report("dns", checkFail, "%v", err)
+			report("connect", checkSkip, "no address")
+			report("tls", checkSkip, "not connected")
+			return false
+		}
+		report("dns", checkPass, "%s → %s", host, strings.Join(addrs, ", "))
+	}
+	if viaProxy {
+		// The proxy makes the connection; the REST check covers it.
+		report("connect", checkSkip, "through proxy")
+		report("tls", checkSkip, "through proxy")
+		return true
+	}
+
+	dial := api.dial
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+	dctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := dial(dctx, "tcp", addr)
+	if err != nil {
+		report("connect", checkFail, "%v", err)
+		report("tls", checkSkip, "not connected")
+		return false
+	}
+	defer conn.Close()
+	target := addr
+	if api.socket != "" {
+		target = api.socket
+	}
+	report("connect", checkPass, "connected to %s in %dms", target, time.Since(start).Milliseconds())
+
+	if u.Scheme != "https" {
+		report("tls", checkSkip, "plain http")
+		return true
+	}
+	cfg := &tls.Config{}
+	if api.conn.TLS != nil {
+		cfg = api.conn.TLS.Clone()
+	}
+	cfg.ServerName = host
+	tc := tls.Client(conn, cfg)
+	if err := tc.HandshakeContext(dctx); err != nil {
+		report("tls", checkFail, "%v", err)
+		return false
+	}
+	state := tc.ConnectionState()
+	detail := tls.VersionName(state.Version)
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		left := time.Until(cert.NotAfter)
+		detail += fmt.Sprintf(", certificate for %s expires in %s", cert.Subject.CommonName, shortDuration(left))
+		if left < 14*24*time.Hour {
+			report("tls", checkWarn, "%s", detail)
+			return true
+		}
+	}
+	report("tls", checkPass, "%s", detail)
+	return true
+}
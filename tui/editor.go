@@ -0,0 +1,83 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// EditorConfig is [editor] in the config file: how the dashboard's editor
+// key opens a session's working directory on this machine. In the
+// commands, {path} is the directory and {host} the machine it's on: the
+// server's host, or its profile's editor_host.
+type EditorConfig struct {
+	Command      string `toml:"command"`       // for a session on another machine
+	LocalCommand string `toml:"local_command"` // for one on this machine
+}
+
+// Default [editor] commands: VS Code, over its SSH remote when the session
+// is on another machine.
+const (
+	defaultEditorCommand      = "code --remote ssh-remote+{host} {path}"
+	defaultLocalEditorCommand = "code {path}"
+)
+
+// editorArgv fills in an [editor] command template. The template is split
+// into words first, so a path with spaces stays one argument.
+func editorArgv(template, host, path string) []string {
+	r := strings.NewReplacer("{host}", host, "{path}", path)
+	var argv []string
+	for _, f := range strings.Fields(template) {
+		argv = append(argv, r.Replace(f))
+	}
+	return argv
+}
+
+// machine is the host the server behind a runs on, as SSH would reach it,
+// or "" if it's this machine.
+func (a *APIClient) machine() string {
+	if a.conn.SSH != nil {
+		_, addr, ok := strings.Cut(a.conn.SSH.dest, "@")
+		if !ok {
+			addr = a.conn.SSH.dest
+		}
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			return host
+		}
+		return addr
+	}
+	if a.socket != "" {
+		return ""
+	}
+	u, err := url.Parse(a.baseURL)
+	if err != nil {
+		return ""
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return ""
+	}
+	return host
+}
+
+// openEditor opens the session's working directory with the [editor]
+// command for where it runs. profile is the [hosts] entry it came from,
+// whose editor_host names the machine when set.
+func openEditor(ctx context.Context, api *APIClient, cfg EditorConfig, hosts map[string]HostConfig, profile string, s Session) error {
+	if s.Cwd == "" {
+		return fmt.Errorf("%s has no working directory", s.Name)
+	}
+	a, _ := api.route(s.Name)
+	host := cmp.Or(hosts[profile].EditorHost, a.machine())
+	template := cmp.Or(cfg.LocalCommand, defaultLocalEditorCommand)
+	if host != "" {
+		template = cmp.Or(cfg.Command, defaultEditorCommand)
+	}
+	return runQuiet(ctx, editorArgv(template, host, s.Cwd))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The error log keeps the client's recent failures, failed requests and
+// dropped connections, which the status bar only shows one at a time and
+// until the next key. With --verbose each goes to the debug log as well,
+// for failures in attach or the CLI that nothing on screen keeps.
+
+// errorLogLimit is how many errors are kept.
+const errorLogLimit = 100
+
+type loggedError struct {
+	at    time.Time
+	text  string
+	count int // times in a row it happened, as while offline
+}
+
+// errorLog is the list, shared between goroutines. A nil *errorLog records
+// nothing.
+type errorLog struct {
+	mu    sync.Mutex
+	items []loggedError // oldest first
+	log   *slog.Logger
+	path  string // the debug log's file, "" without --verbose
+}
+
+func newErrorLog(log *slog.Logger, path string) *errorLog {
+	return &errorLog{log: log, path: path}
+}
+
+// add records err. Cancellations are the user's doing and aren't kept, and
+// an error like the last one counts again rather than filling the list.
+func (l *errorLog) add(err error) {
+	if l == nil || err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now, text := time.Now(), err.Error()
+	l.log.Error(text)
+	if n := len(l.items); n > 0 && l.items[n-1].text == text {
+		l.items[n-1].at = now
+		l.items[n-1].count++
+		return
+	}
+	l.items = append(l.items, loggedError{at: now, text: text, count: 1})
+	if len(l.items) > errorLogLimit {
+		l.items = slices.Delete(l.items, 0, len(l.items)-errorLogLimit)
+	}
+}
+
+// list returns the errors, newest first, and the debug log's file, if
+// they are logged.
+func (l *errorLog) list() ([]loggedError, string) {
+	if l == nil {
+		return nil, ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := slices.Clone(l.items)
+	slices.Reverse(out)
+	return out, l.path
+}
+
+func (l *errorLog) clear() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = nil
+}
+
+// ErrorPanel is the error log overlay.
+type ErrorPanel struct {
+	items  []loggedError
+	path   string // the log file, "" without --verbose
+	offset int
+}
+
+type errorPanelCloseMsg struct{}
+
+func NewErrorPanel(l *errorLog) ErrorPanel {
+	items, path := l.list()
+	return ErrorPanel{items: items, path: path}
+}
+
+func (p ErrorPanel) Update(msg tea.KeyMsg, k KeyMap, l *errorLog) (ErrorPanel, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", msg.String() == "q", k.Matches(msg, k.Errors):
+		return p, func() tea.Msg { return errorPanelCloseMsg{} }
+	case k.Matches(msg, k.Up):
+		p.offset = max(p.offset-1, 0)
+	case k.Matches(msg, k.Down):
+		p.offset = min(p.offset+1, max(len(p.items)-1, 0))
+	case msg.String() == "c":
+		l.clear()
+		p.items, p.offset = nil, 0
+	}
+	return p, nil
+}
+
+// View lists the errors from offset on, as many as fit in height lines.
+func (p ErrorPanel) View(width, height int) string {
+	var s strings.Builder
+	s.WriteString("  " + titleStyle.Render("errors") + "\n\n")
+	if len(p.items) == 0 {
+		s.WriteString("  " + dimStyle.Render("No errors since the dashboard started.") + "\n\n")
+	}
+	rows := max(height-8, 3)
+	for _, e := range p.items[p.offset:min(p.offset+rows, len(p.items))] {
+		when := e.at.Format("15:04:05")
+		if time.Since(e.at) > 12*time.Hour {
+			when = e.at.Format("Jan 2 15:04")
+		}
+		count := ""
+		if e.count > 1 {
+			count = fmt.Sprintf("×%d", e.count)
+		}
+		text := e.text
+		if width > 26 {
+			text = truncateTail(text, width-26)
+		}
+		s.WriteString(fmt.Sprintf("  %s %s %s\n", tStyle.Render(pad(when, 11)), dimStyle.Render(pad(count, 5)), errSty.Render(text)))
+	}
+	hint := "↑↓ scroll · c clear · esc close"
+	if p.path != "" {
+		hint += " · logged to " + p.path
+	}
+	s.WriteString("\n  " + dimStyle.Render(hint) + "\n")
+	return s.String()
+}
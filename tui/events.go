@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gorilla/websocket"
+)
+
+// SessionEvent is a change to the session list, pushed by servers that
+// offer /ws/events.
+type SessionEvent struct {
+	Type    string `json:"type"` // created, deleted, renamed, exited, archived, updated or viewers
+	Name    string `json:"name"`
+	From    string `json:"from,omitempty"` // the old name, for renamed
+	Owner   string `json:"owner,omitempty"`
+	Viewers int    `json:"viewers,omitempty"` // clients now attached, for viewers
+}
+
+// Events subscribes to session-list changes. The channel is closed when the
+// subscription ends. An error means the server doesn't offer one, and the
+// caller should poll instead.
+func (a *APIClient) Events(ctx context.Context) (<-chan SessionEvent, error) {
+	if a.multi != nil {
+		return a.multi.events(ctx)
+	}
+	dctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	conn, resp, err := a.Dialer("", false).DialContext(dctx, a.wsBase()+"/ws/events", a.WebSocketHeader(""))
+	if err != nil {
+		e := &APIError{Endpoint: "GET /ws/events", Err: err}
+		if resp != nil {
+			e.Status = resp.StatusCode
+		}
+		return nil, e
+	}
+
+	log := a.log().With("server", a.String())
+	log.Debug("events connected")
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	ch := make(chan SessionEvent)
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(pingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				// Unblock the read below.
+				conn.Close()
+				return
+			case <-t.C:
+				conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second))
+			}
+		}
+	}()
+	go func() {
+		defer close(ch)
+		defer close(done)
+		defer conn.Close()
+		for {
+			var ev SessionEvent
+			if err := conn.ReadJSON(&ev); err != nil {
+				if ctx.Err() == nil {
+					log.Info("events connection lost", "err", err)
+				}
+				return
+			}
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// eventsRetryInterval is how long the dashboard polls after losing its
+// subscription before trying to subscribe again.
+const eventsRetryInterval = 30 * time.Second
+
+type eventsUpMsg struct{ ch <-chan SessionEvent }
+type eventsDownMsg struct{ err error }
+type sessionEventMsg SessionEvent
+
+// subscribe opens the dashboard's event subscription.
+func (m DashboardModel) subscribe() tea.Cmd {
+	ctx, api := m.ctx, m.api
+	return func() tea.Msg {
+		ch, err := api.Events(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return eventsDownMsg{err}
+		}
+		return eventsUpMsg{ch}
+	}
+}
+
+// nextEvent waits for the subscription's next event.
+func nextEvent(ch <-chan SessionEvent) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-ch
+		if !ok {
+			return eventsDownMsg{}
+		}
+		return sessionEventMsg(ev)
+	}
+}
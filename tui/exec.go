@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// The server types a session's command into a shell, so the shell outlives
+// it and the exit status never reaches the API. execSession appends an echo
+// of $? and watches the screen for it. The echoed command line itself shows
+// "$?" rather than digits, so it doesn't match.
+const execMarker = "claude-host-exit="
+
+var execMarkerRe = regexp.MustCompile(`(?m)^` + execMarker + `(\d+)\s*$`)
+
+const (
+	execPollInterval = time.Second
+	// execDrain is how long output keeps streaming after the exit status
+	// shows up, so the tail of it isn't cut off.
+	execDrain = 500 * time.Millisecond
+)
+
+// exitError carries a remote command's exit status back to main.
+type exitError struct{ code int }
+
+func (e *exitError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+
+// execSession runs command in a new session, streaming its output to w until
+// it finishes. It returns the session's name and the command's exit status.
+func execSession(ctx context.Context, api *APIClient, opts CreateOptions, w io.Writer, compress bool) (string, int, error) {
+	opts.Command += "; echo " + execMarker + "$?"
+	sess, err := api.CreateSession(ctx, opts)
+	if err != nil {
+		return "", 0, err
+	}
+
+	fctx, stop := context.WithCancel(ctx)
+	defer stop()
+	streamed := make(chan error, 1)
+	go func() { streamed <- followSession(fctx, api, sess.Name, w, compress) }()
+
+	t := time.NewTicker(execPollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return sess.Name, 0, ctx.Err()
+		case err := <-streamed:
+			if err != nil {
+				return sess.Name, 0, err
+			}
+			return sess.Name, 0, fmt.Errorf("%s ended without reporting an exit status", sess.Name)
+		case <-t.C:
+		}
+		text, err := api.GetSnapshot(ctx, sess.Name)
+		if err != nil {
+			continue
+		}
+		if m := execMarkerRe.FindStringSubmatch(text); m != nil {
+			code, _ := strconv.Atoi(m[1])
+			time.Sleep(execDrain)
+			return sess.Name, code, nil
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var exportFormats = []string{"md", "txt", "html"}
+
+// exportLines is how much scrollback an export asks for: all of it, as far
+// as the server will go.
+const exportLines = 50000
+
+// ansiRe matches CSI and OSC sequences and the remaining two-byte escapes.
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;?<=>]*[ -/]*[@-~]|\x1b\][^\a\x1b]*(?:\a|\x1b\\)|\x1b[@-Z\\-_]`)
+
+func stripANSI(s string) string {
+	return ansiRe.ReplaceAllString(s, "")
+}
+
+// cleanTranscript strips escapes, trailing spaces and trailing blank lines
+// from captured output.
+func cleanTranscript(text string) string {
+	lines := strings.Split(stripANSI(text), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+// renderTranscript formats a session's output as a standalone document.
+func renderTranscript(sess Session, text, format string, now time.Time) (string, error) {
+	text = cleanTranscript(text)
+	var meta [][2]string
+	meta = append(meta, [2]string{"command", sess.Command})
+	if sess.Cwd != "" {
+		meta = append(meta, [2]string{"directory", sess.Cwd})
+	}
+	if sess.CreatedAt != "" {
+		meta = append(meta, [2]string{"created", sess.CreatedAt})
+	}
+	meta = append(meta, [2]string{"exported", now.Format(time.RFC3339)})
+
+	var s strings.Builder
+	switch format {
+	case "txt":
+		return text, nil
+	case "md":
+		fmt.Fprintf(&s, "# %s\n\n", sess.Name)
+		if sess.Description != "" {
+			fmt.Fprintf(&s, "%s\n\n", sess.Description)
+		}
+		for _, kv := range meta {
+			fmt.Fprintf(&s, "- **%s:** `%s`\n", kv[0], kv[1])
+		}
+		// Use a fence longer than any backtick run in the output.
+		fence := "```"
+		for strings.Contains(text, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&s, "\n%stext\n%s%s\n", fence, text, fence)
+	case "html":
+		fmt.Fprintf(&s, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(sess.Name))
+		s.WriteString("<style>body{font-family:sans-serif;margin:2em}pre{background:#111;color:#ddd;padding:1em;overflow-x:auto}</style>\n</head>\n<body>\n")
+		fmt.Fprintf(&s, "<h1>%s</h1>\n", html.EscapeString(sess.Name))
+		if sess.Description != "" {
+			fmt.Fprintf(&s, "<p>%s</p>\n", html.EscapeString(sess.Description))
+		}
+		s.WriteString("<ul>\n")
+		for _, kv := range meta {
+			fmt.Fprintf(&s, "<li><b>%s:</b> <code>%s</code></li>\n", kv[0], html.EscapeString(kv[1]))
+		}
+		fmt.Fprintf(&s, "</ul>\n<pre>%s</pre>\n</body>\n</html>\n", html.EscapeString(text))
+	default:
+		return "", fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(exportFormats, ", "))
+	}
+	return s.String(), nil
+}
+
+// defaultExportPath is where an export goes when no path is given:
+// <name>-<timestamp>.<format> in the current directory.
+func defaultExportPath(name, format string, now time.Time) string {
+	name = strings.ReplaceAll(name, "/", "-") // host/name from an aggregate client
+	return fmt.Sprintf("%s-%s.%s", name, now.Format("20060102-150405"), format)
+}
+
+// exportSession fetches a session's full scrollback and writes it to path
+// ("" for the default, "-" for stdout), returning where it went.
+func exportSession(ctx context.Context, api *APIClient, name, format, path string) (string, error) {
+	if !slices.Contains(exportFormats, format) {
+		return "", fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(exportFormats, ", "))
+	}
+	sessions, err := api.ListAllSessions(ctx)
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(sessions, func(s Session) bool { return s.Name == name })
+	if i < 0 {
+		return "", fmt.Errorf("no session named %s", name)
+	}
+	text, err := api.GetScrollback(ctx, name, exportLines)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	doc, err := renderTranscript(sessions[i], text, format, now)
+	if err != nil {
+		return "", err
+	}
+	if path == "-" {
+		_, err := os.Stdout.WriteString(doc)
+		return path, err
+	}
+	if path == "" {
+		path = defaultExportPath(name, format, now)
+	}
+	return path, os.WriteFile(path, []byte(doc), 0o644)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The dashboard can attach somewhere other than its own terminal: a tmux
+// window (`claude-host tmux`), or a pane or tab of another multiplexer or
+// terminal through [dashboard] attach_command. The dashboard stays open
+// either way; the new pane runs `claude-host attach` with this process's
+// connection flags.
+
+// attachArgv is the command line that attaches to name on the host
+// profile from another terminal, ahead of any extra attach flags.
+func (c *cli) attachArgv(host, name string, flags ...string) ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	argv := []string{exe}
+	switch host {
+	case allHosts:
+		argv = append(argv, "--all-hosts")
+	case "":
+		argv = append(argv, "--url", c.baseURL)
+	default:
+		argv = append(argv, "--host", host)
+	}
+	for _, f := range []struct{ flag, value string }{
+		{"--ssh", c.ssh}, {"--proxy", c.proxy}, {"--ca", c.tls.CA}, {"--cert", c.tls.Cert}, {"--key", c.tls.Key},
+	} {
+		if f.value != "" {
+			argv = append(argv, f.flag, f.value)
+		}
+	}
+	if c.tls.InsecureSkipVerify {
+		argv = append(argv, "--insecure-skip-verify")
+	}
+	for _, s := range c.sets {
+		argv = append(argv, "--set", s)
+	}
+	argv = append(argv, "attach")
+	argv = append(argv, flags...)
+	return append(argv, name), nil
+}
+
+// attachEnv is the $CLAUDE_HOST settings, tokens among them, to pass on
+// to the new pane, which gets its multiplexer's environment, not ours.
+func attachEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "CLAUDE_HOST") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// openTmux attaches to name on the host profile in a tmux window.
+func (c *cli) openTmux(ctx context.Context, host, name string) error {
+	argv, err := c.attachArgv(host, name, "--tmux-window")
+	if err != nil {
+		return err
+	}
+	return openTmuxWindow(ctx, name, argv, attachEnv())
+}
+
+// openAttachCommand attaches to name on the host profile by running the
+// [dashboard] attach_command template.
+func (c *cli) openAttachCommand(ctx context.Context, host, name string) error {
+	attach, err := c.attachArgv(host, name)
+	if err != nil {
+		return err
+	}
+	if env := attachEnv(); len(env) > 0 {
+		attach = append(append([]string{"env"}, env...), attach...)
+	}
+	return runQuiet(ctx, attachCommandArgv(c.cfg.Dashboard.AttachCommand, name, attach))
+}
+
+// attachCommandArgv fills in an attach_command template: {name} is the
+// session, and {attach} the command that attaches to it, as separate
+// arguments when it's a word of its own and shell-quoted inside a longer
+// one, like --command={attach}.
+func attachCommandArgv(template, name string, attach []string) []string {
+	quoted := make([]string, len(attach))
+	for i, a := range attach {
+		quoted[i] = shellQuote(a)
+	}
+	r := strings.NewReplacer("{name}", name, "{attach}", strings.Join(quoted, " "))
+	var argv []string
+	for _, f := range strings.Fields(template) {
+		if f == "{attach}" {
+			argv = append(argv, attach...)
+			continue
+		}
+		argv = append(argv, r.Replace(f))
+	}
+	return argv
+}
+
+// runQuiet runs a command to completion, reporting the last line it wrote
+// if it fails.
+func runQuiet(ctx context.Context, argv []string) error {
+	if len(argv) == 0 {
+		return errors.New("empty command")
+	}
+	out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %s", argv[0], msg[strings.LastIndexByte(msg, '\n')+1:])
+		}
+		return fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RemoteFile is a file the server wrote into or read from a session's
+// working directory.
+type RemoteFile struct {
+	Path string `json:"path"` // absolute, on the server
+	Size int64  `json:"size"`
+}
+
+// UploadFile writes src into the session's working directory at path,
+// which is relative to it. A path that is empty or ends in "/" names a
+// directory, and the file keeps filename there. The Node server doesn't
+// take uploads, and answers 404.
+func (a *APIClient) UploadFile(ctx context.Context, name, path, filename string, src io.Reader) (*RemoteFile, error) {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, transferTimeout)
+	defer cancel()
+
+	// Stream the form rather than holding the whole file in memory.
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		part, err := form.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(part, src)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	path = "/api/sessions/" + url.PathEscape(name) + "/files?" + url.Values{"path": {path}}.Encode()
+	resp, err := a.do(ctx, "POST", path, rawBody{pr, form.FormDataContentType()})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var f RemoteFile
+	if err := decodeResponse(resp, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// DownloadFile opens a file in the session's working directory, at path
+// relative to it. The caller must close it. The Node server doesn't serve
+// files, and answers 404.
+func (a *APIClient) DownloadFile(ctx context.Context, name, path string) (io.ReadCloser, error) {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, transferTimeout)
+	resp, err := a.do(ctx, "GET", "/api/sessions/"+url.PathEscape(name)+"/files?"+url.Values{"path": {path}}.Encode(), nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return cancelOnClose{resp.Body, cancel}, nil
+}
+
+// cancelOnClose is a response body that releases its request's context
+// once it's closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// downloadFile copies a file from the session's working directory to dst:
+// a file, a directory to put it in under its own name, or "-" for stdout.
+// It returns where the file went and its size.
+func downloadFile(ctx context.Context, api *APIClient, name, path, dst string) (string, int64, error) {
+	if path == "" || strings.HasSuffix(path, "/") {
+		return "", 0, errors.New("give the path of a file in the session's working directory")
+	}
+	body, err := api.DownloadFile(ctx, name, path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer body.Close()
+	if dst == "-" {
+		n, err := io.Copy(os.Stdout, body)
+		return dst, n, err
+	}
+	if st, err := os.Stat(dst); dst == "" || strings.HasSuffix(dst, string(os.PathSeparator)) || err == nil && st.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(path))
+	}
+	// Write alongside and rename, so a failed copy leaves nothing behind.
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".download-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return dst, n, nil
+}
+
+// remoteSpec matches "name:path" in cp's arguments, the name optionally
+// qualified by its host. Anything else is a local path; "./a:b" names a
+// local file with a colon in it.
+var remoteSpec = regexp.MustCompile(`^([A-Za-z0-9_-]+(?:/[A-Za-z0-9_-]+)?):(.*)$`)
+
+// parseRemote splits a "name:path" argument.
+func parseRemote(arg string) (name, path string, ok bool) {
+	m := remoteSpec.FindStringSubmatch(arg)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// copyFile is `claude-host cp src dst`, where one side is a session's
+// "name:path".
+func copyFile(ctx context.Context, api *APIClient, src, dst string) error {
+	name, path, ok := parseRemote(dst)
+	if !ok {
+		name, path, ok := parseRemote(src)
+		if !ok {
+			return errors.New("one side must be a session, as name:path")
+		}
+		local, n, err := downloadFile(ctx, api, name, path, dst)
+		if err != nil {
+			return err
+		}
+		if local != "-" {
+			fmt.Fprintf(os.Stderr, "%s -> %s (%s)\n", src, local, sizeLabel(n))
+		}
+		return nil
+	}
+	if _, _, ok := parseRemote(src); ok {
+		return errors.New("can't copy between two sessions")
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if st, err := f.Stat(); err == nil && st.IsDir() {
+		return fmt.Errorf("%s is a directory", src)
+	}
+	rf, err := api.UploadFile(ctx, name, path, filepath.Base(src), f)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s -> %s:%s (%s)\n", src, name, rf.Path, sizeLabel(rf.Size))
+	return nil
+}
+
+// sizeLabel is formatBytes, but exact for small files.
+func sizeLabel(n int64) string {
+	if n < 1<<10 {
+		return fmt.Sprintf("%d bytes", n)
+	}
+	return formatBytes(n)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FinderModel is a ctrl-p style fuzzy finder over sessions. It is composed
+// into the dashboard as an overlay and reports back via finderPickMsg and
+// finderCloseMsg.
+type FinderModel struct {
+	query   []rune
+	items   []Session
+	matches []finderMatch
+	cursor  int
+	width   int
+	height  int
+}
+
+type finderMatch struct {
+	session   Session
+	score     int
+	positions []int // rune indexes into session.Name to highlight
+}
+
+type finderPickMsg string // session name to attach
+type finderCloseMsg struct{}
+
+func NewFinder(sessions []Session, width, height int) FinderModel {
+	f := FinderModel{width: width, height: height}
+	f.SetItems(sessions)
+	return f
+}
+
+// SetItems replaces the candidate list (e.g. after a refresh) and re-ranks.
+func (f *FinderModel) SetItems(sessions []Session) {
+	f.items = sessions
+	f.refilter()
+}
+
+func (f *FinderModel) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+func (f *FinderModel) refilter() {
+	q := string(f.query)
+	f.matches = f.matches[:0]
+	for _, s := range f.items {
+		if q == "" {
+			f.matches = append(f.matches, finderMatch{session: s})
+			continue
+		}
+		nameScore, pos, nameOK := fuzzyScore(q, s.Name)
+		descScore, _, descOK := fuzzyScore(q, s.Description)
+		if !nameOK && !descOK {
+			continue
+		}
+		// Prefer name hits: a description-only match ranks below an
+		// equally good name match.
+		score := nameScore
+		if !nameOK || descScore/2 > nameScore {
+			score = descScore / 2
+			pos = nil
+		}
+		f.matches = append(f.matches, finderMatch{session: s, score: score, positions: pos})
+	}
+	if q != "" {
+		sort.SliceStable(f.matches, func(i, j int) bool {
+			return f.matches[i].score > f.matches[j].score
+		})
+	}
+	if f.cursor >= len(f.matches) {
+		f.cursor = max(0, len(f.matches)-1)
+	}
+}
+
+func (f FinderModel) Update(msg tea.KeyMsg) (FinderModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		return f, func() tea.Msg { return finderCloseMsg{} }
+	case "enter":
+		if f.cursor < len(f.matches) {
+			name := f.matches[f.cursor].session.Name
+			return f, func() tea.Msg { return finderPickMsg(name) }
+		}
+	case "up", "ctrl+k", "ctrl+p":
+		if f.cursor > 0 {
+			f.cursor--
+		}
+	case "down", "ctrl+j", "ctrl+n":
+		if f.cursor < len(f.matches)-1 {
+			f.cursor++
+		}
+	case "backspace":
+		if len(f.query) > 0 {
+			f.query = f.query[:len(f.query)-1]
+			f.cursor = 0
+			f.refilter()
+		}
+	case "ctrl+u":
+		f.query = nil
+		f.cursor = 0
+		f.refilter()
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			f.query = append(f.query, msg.Runes...)
+			f.cursor = 0
+			f.refilter()
+		}
+	}
+	return f, nil
+}
+
+func (f FinderModel) View() string {
+	var s strings.Builder
+
+	s.WriteString("  " + promptSty.Render("> ") + string(f.query) + dimStyle.Render("█"))
+	s.WriteString(dimStyle.Render(fmt.Sprintf("  %d/%d", len(f.matches), len(f.items))) + "\n\n")
+
+	maxRows := 12
+	if f.height > 0 {
+		maxRows = max(3, f.height-10)
+	}
+	start := 0
+	if f.cursor >= maxRows {
+		start = f.cursor - maxRows + 1
+	}
+	end := min(len(f.matches), start+maxRows)
+
+	if len(f.matches) == 0 {
+		s.WriteString(dimStyle.Render("  No matching sessions.") + "\n")
+	}
+	for i := start; i < end; i++ {
+		match := f.matches[i]
+		prefix := "  "
+		nameS := normStyle
+		if i == f.cursor {
+			prefix = "▸ "
+			nameS = selStyle
+		}
+		name := highlightRunes(match.session.Name, match.positions, nameS, matchStyle)
+		gap := strings.Repeat(" ", max(0, 22-textWidth(match.session.Name)))
+		desc := match.session.Description
+		if f.width > 40 {
+			desc = truncate(desc, f.width-34)
+		}
+		s.WriteString(fmt.Sprintf("  %s%s%s %s\n", prefix, name, gap, dimStyle.Render(desc)))
+	}
+
+	s.WriteString("\n  " + dimStyle.Render("type to filter  ↑↓ select  enter attach  esc close") + "\n")
+	return s.String()
+}
+
+func highlightRunes(s string, positions []int, base, hl lipgloss.Style) string {
+	if len(positions) == 0 {
+		return base.Render(s)
+	}
+	marked := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		marked[p] = true
+	}
+	var b strings.Builder
+	for i, r := range []rune(s) {
+		if marked[i] {
+			b.WriteString(hl.Render(string(r)))
+		} else {
+			b.WriteString(base.Render(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// Scoring weights, loosely modelled on fzf's v1 algorithm.
+const (
+	scoreMatch       = 16
+	bonusBoundary    = 8
+	bonusConsecutive = 6
+	bonusFirstChar   = 4
+	penaltyGap       = 1
+	penaltyLeading   = 1
+)
+
+// fuzzyScore reports whether every rune of pattern appears in text in order
+// (case-insensitive), and if so how good the match is. Matches on word
+// boundaries and runs of consecutive characters score higher; gaps and a late
+// start score lower. It tries every start position for the first rune and keeps
+// the best-scoring alignment.
+func fuzzyScore(pattern, text string) (int, []int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+	lt := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	if len(p) > len(t) {
+		return 0, nil, false
+	}
+
+	best := -1 << 31
+	var bestPos []int
+	found := false
+	for start := 0; start < len(lt); start++ {
+		if lt[start] != p[0] {
+			continue
+		}
+		score, pos, ok := scoreFrom(p, t, lt, start)
+		if ok && score > best {
+			best, bestPos, found = score, pos, true
+		}
+	}
+	return best, bestPos, found
+}
+
+func scoreFrom(p, t, lt []rune, start int) (int, []int, bool) {
+	pos := make([]int, 0, len(p))
+	score := -start * penaltyLeading
+	prev := -1
+	pi := 0
+	for ti := start; ti < len(lt) && pi < len(p); ti++ {
+		if lt[ti] != p[pi] {
+			continue
+		}
+		score += scoreMatch
+		if isBoundary(t, ti) {
+			score += bonusBoundary
+			if pi == 0 {
+				score += bonusFirstChar
+			}
+		}
+		if prev >= 0 {
+			if ti == prev+1 {
+				score += bonusConsecutive
+			} else {
+				score -= (ti - prev - 1) * penaltyGap
+			}
+		}
+		pos = append(pos, ti)
+		prev = ti
+		pi++
+	}
+	return score, pos, pi == len(p)
+}
+
+func isBoundary(t []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := t[i-1]
+	switch prev {
+	case ' ', '-', '_', '/', '.', ':':
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(t[i])
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/term"
+)
+
+// The server sizes the session to its smallest client, so a follower that
+// isn't on a terminal claims a generous size rather than the PTY default.
+const (
+	followCols = 200
+	followRows = 50
+)
+
+// followSession copies a session's live output to w until the session
+// exits or ctx is cancelled. Unlike attach it reads nothing from stdin and
+// leaves the terminal alone, so the output can be piped.
+func followSession(ctx context.Context, api *APIClient, name string, w io.Writer, compress bool) error {
+	cols, rows := followCols, followRows
+	if tw, th, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		cols, rows = tw, th
+	}
+	conn, resp, err := dialAttach(ctx, api, name, compress, cols, rows)
+	if err != nil {
+		e := &APIError{Endpoint: "GET /ws/sessions/" + name, Err: err}
+		if resp != nil {
+			e.Status = resp.StatusCode
+		}
+		return e
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	go func() {
+		t := time.NewTicker(pingInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				// Unblock the read below.
+				conn.Close()
+				return
+			case <-t.C:
+				conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second))
+			}
+		}
+	}()
+
+	for {
+		msg, err := conn.Read()
+		if err != nil {
+			// The server closes the socket, with or without a close
+			// frame, when the session's process exits.
+			var ce *websocket.CloseError
+			if ctx.Err() != nil || errors.As(err, &ce) {
+				return nil
+			}
+			return fmt.Errorf("connection lost: %w", err)
+		}
+		if _, err := w.Write(msg); err != nil {
+			return err
+		}
+	}
+}
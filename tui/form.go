@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CreateForm collects the options for a new session. Like the finder it is
+// an overlay composed into the dashboard, reporting back via formSubmitMsg
+// and formCancelMsg.
+type CreateForm struct {
+	inputs []textinput.Model
+	focus  int
+	err    string
+}
+
+const (
+	fieldCommand = iota
+	fieldDescription
+	fieldCwd
+	fieldEnv
+	fieldProject
+)
+
+var formLabels = []string{"command", "description", "directory", "env", "project"}
+
+type formSubmitMsg CreateOptions
+type formCancelMsg struct{}
+
+// NewCreateForm starts the form filled in from preset, a blank session or
+// one from a template.
+func NewCreateForm(preset CreateOptions) CreateForm {
+	f := CreateForm{inputs: make([]textinput.Model, len(formLabels))}
+	for i := range f.inputs {
+		ti := textinput.New()
+		ti.Prompt = ""
+		ti.CharLimit = 512
+		ti.Width = 50
+		ti.Cursor.SetMode(cursor.CursorStatic)
+		f.inputs[i] = ti
+	}
+	if preset.Command == "" {
+		preset.Command = "claude"
+	}
+	f.inputs[fieldCommand].SetValue(preset.Command)
+	f.inputs[fieldDescription].SetValue(preset.Description)
+	f.inputs[fieldCwd].SetValue(preset.Cwd)
+	f.inputs[fieldEnv].SetValue(formatEnv(preset.Env))
+	f.inputs[fieldProject].SetValue(preset.Project)
+	for i := range f.inputs {
+		f.inputs[i].CursorEnd()
+	}
+	f.inputs[fieldCwd].Placeholder = "server default"
+	f.inputs[fieldEnv].Placeholder = "KEY=value KEY2=value"
+	f.inputs[fieldProject].Placeholder = "none"
+	f.inputs[fieldCommand].Focus()
+	return f
+}
+
+func (f CreateForm) Update(msg tea.Msg) (CreateForm, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc", "ctrl+c":
+			return f, func() tea.Msg { return formCancelMsg{} }
+		case "enter":
+			opts, err := f.options()
+			if err != nil {
+				f.err = err.Error()
+				return f, nil
+			}
+			return f, func() tea.Msg { return formSubmitMsg(opts) }
+		case "tab", "down":
+			return f.setFocus((f.focus + 1) % len(f.inputs)), nil
+		case "shift+tab", "up":
+			return f.setFocus((f.focus + len(f.inputs) - 1) % len(f.inputs)), nil
+		}
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	f.err = ""
+	return f, cmd
+}
+
+func (f CreateForm) setFocus(i int) CreateForm {
+	f.inputs[f.focus].Blur()
+	f.focus = i
+	f.inputs[f.focus].Focus()
+	return f
+}
+
+func (f CreateForm) options() (CreateOptions, error) {
+	opts := CreateOptions{
+		Command:     strings.TrimSpace(f.inputs[fieldCommand].Value()),
+		Description: strings.TrimSpace(f.inputs[fieldDescription].Value()),
+		Cwd:         strings.TrimSpace(f.inputs[fieldCwd].Value()),
+		Project:     strings.TrimSpace(f.inputs[fieldProject].Value()),
+	}
+	if opts.Command == "" {
+		return opts, fmt.Errorf("command is required")
+	}
+	env, err := parseEnv(strings.Fields(f.inputs[fieldEnv].Value()))
+	if err != nil {
+		return opts, err
+	}
+	opts.Env = env
+	return opts, nil
+}
+
+// parseEnv turns KEY=value pairs into a map.
+func parseEnv(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	env := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("env: expected KEY=value, got %q", p)
+		}
+		env[k] = v
+	}
+	return env, nil
+}
+
+// formatEnv is the inverse of parseEnv, in key order.
+func formatEnv(env map[string]string) string {
+	pairs := make([]string, 0, len(env))
+	for _, k := range slices.Sorted(maps.Keys(env)) {
+		pairs = append(pairs, k+"="+env[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (f CreateForm) View() string {
+	var s strings.Builder
+	s.WriteString("  " + titleStyle.Render("new session") + "\n\n")
+	for i, in := range f.inputs {
+		label := dimStyle.Render(fmt.Sprintf("%-12s", formLabels[i]))
+		if i == f.focus {
+			label = promptSty.Render(fmt.Sprintf("%-12s", formLabels[i]))
+		}
+		s.WriteString("  " + label + in.View() + "\n")
+	}
+	s.WriteString("\n")
+	if f.err != "" {
+		s.WriteString("  " + errSty.Render("! "+f.err) + "\n\n")
+	}
+	s.WriteString("  " + dimStyle.Render("tab next field  enter create  esc cancel") + "\n")
+	return s.String()
+}
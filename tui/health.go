@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Health is GET /api/health, which answers without a token so clients can
+// tell a server that's down from one that rejects them.
+type Health struct {
+	Version string    `json:"version"`
+	Time    time.Time `json:"time"` // the server's clock
+
+	Latency time.Duration `json:"-"` // round trip, measured by the client
+}
+
+// buildVersion is the module version this binary was built from, or its
+// commit for a build from a checkout.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var rev, dirty string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value[:min(12, len(s.Value))]
+		case "vcs.modified":
+			if s.Value == "true" {
+				dirty = "-dirty"
+			}
+		}
+	}
+	if rev == "" {
+		return "devel"
+	}
+	return rev + dirty
+}
+
+func (s *server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Health{Version: buildVersion(), Time: time.Now()})
+}
+
+// Health asks the server its version and clock, timing the round trip. The
+// Node server answers 404.
+func (a *APIClient) Health(ctx context.Context) (*Health, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := a.send(ctx, "GET", "/api/health", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var h Health
+	if err := decodeResponse(resp, &h); err != nil {
+		return nil, err
+	}
+	h.Latency = time.Since(start)
+	return &h, nil
+}
+
+type healthMsg struct {
+	health      *Health
+	unsupported bool // the server has no health endpoint; stop asking
+}
+
+// fetchHealth refreshes the status bar's server version and latency.
+func (m DashboardModel) fetchHealth() tea.Cmd {
+	if m.noHealth {
+		return nil
+	}
+	ctx, api := m.ctx, m.api
+	return func() tea.Msg {
+		h, err := api.Health(ctx)
+		if e := asAPIError(err); e != nil && e.NotFound() {
+			return healthMsg{unsupported: true}
+		}
+		if err != nil {
+			return nil
+		}
+		return healthMsg{health: h}
+	}
+}
+
+// statusBar is the last line of the dashboard: where it's connected and how
+// well, or what just went wrong.
+func (m DashboardModel) statusBar() string {
+	var s string
+	if m.err != nil {
+		msg, hint := m.errorText()
+		s = errSty.Render("! " + msg)
+		if hint != "" {
+			s += dimStyle.Render("  " + hint)
+		}
+	} else {
+		parts := []string{m.api.String()}
+		if m.health != nil {
+			parts = append(parts, "server "+m.health.Version, fmt.Sprintf("%dms", m.health.Latency.Milliseconds()))
+		}
+		switch {
+		case m.offline != nil:
+			parts = append(parts, "offline")
+		case m.events != nil:
+			parts = append(parts, "live")
+		case !m.listedAt.IsZero():
+			parts = append(parts, "refreshed "+shortDuration(time.Since(m.listedAt).Round(time.Second))+" ago")
+		}
+		s = dimStyle.Render(strings.Join(parts, " · "))
+	}
+	if m.width > 4 {
+		s = lipgloss.NewStyle().MaxWidth(m.width - 4).Render(s)
+	}
+	return "  " + s + "\n"
+}
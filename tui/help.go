@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// helpView renders the full-screen key reference shown by "?".
+func helpView(k KeyMap) string {
+	var s strings.Builder
+	section := func(title string, entries []helpEntry) {
+		s.WriteString("  " + titleStyle.Render(title) + "\n\n")
+		keyW := 0
+		for _, e := range entries {
+			keyW = max(keyW, textWidth(e.key))
+		}
+		for _, e := range entries {
+			s.WriteString(fmt.Sprintf("    %s  %s\n", promptSty.Render(pad(e.key, keyW)), normStyle.Render(e.desc)))
+		}
+		s.WriteString("\n")
+	}
+	section("dashboard", k.DashboardHelp())
+	section("attached", k.AttachHelp())
+	s.WriteString("  " + dimStyle.Render(fmt.Sprintf("keys can be changed in %s", configPath())) + "\n\n")
+	s.WriteString("  " + dimStyle.Render("esc close") + "\n")
+	return s.String()
+}
+
+// attachHelpView draws the ctrl-a ? reference in a box over the middle of a
+// w x h terminal, for RunAttach to show while output is paused.
+func attachHelpView(k KeyMap, w, h int) string {
+	entries := append(k.AttachHelp(), helpEntry{k.PrefixName() + " ?", "this help"})
+	keyW := 0
+	for _, e := range entries {
+		keyW = max(keyW, textWidth(e.key))
+	}
+	lines := []string{"attached", ""}
+	for _, e := range entries {
+		lines = append(lines, pad(e.key, keyW)+"  "+e.desc)
+	}
+	lines = append(lines, "", "any key to close")
+	inner := 0
+	for _, line := range lines {
+		inner = max(inner, textWidth(line))
+	}
+	inner = max(1, min(inner, w-4))
+
+	top := max(1, (h-len(lines))/2)
+	left := max(1, (w-inner-4)/2+1)
+	var b strings.Builder
+	b.WriteString("\033[?25l\033[0m")
+	row := func(i int, s string) {
+		fmt.Fprintf(&b, "\033[%d;%dH%s", top+i, left, s)
+	}
+	row(0, "┌"+strings.Repeat("─", inner+2)+"┐")
+	for i, line := range lines {
+		line = pad(truncate(line, inner), inner)
+		switch i {
+		case 0:
+			line = "\033[1m" + line + "\033[0m"
+		case len(lines) - 1:
+			line = "\033[2m" + line + "\033[0m"
+		}
+		row(i+1, "│ "+line+" │")
+	}
+	row(len(lines)+1, "└"+strings.Repeat("─", inner+2)+"┘")
+	return b.String()
+}
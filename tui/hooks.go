@@ -0,0 +1,113 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HookConfig is [hooks.<pattern>] in the config: shell commands the
+// dashboard runs when a session whose name matches goes idle or exits.
+// For each event the most specific pattern that sets a hook wins.
+type HookConfig struct {
+	OnIdle  string `toml:"on_idle"`  // the screen has been still for [notify] idle_seconds
+	OnExit  string `toml:"on_exit"`  // the process exited, with any status if on_error is unset
+	OnError string `toml:"on_error"` // the process exited with a non-zero status
+}
+
+// hookTimeout bounds how long a hook may run.
+const hookTimeout = 5 * time.Minute
+
+// command is the hook for event, one of the webhook events idle, exited
+// and crashed, or "".
+func (h HookConfig) command(event string) string {
+	switch event {
+	case hookIdle:
+		return h.OnIdle
+	case hookExited:
+		return h.OnExit
+	case hookCrashed:
+		return cmp.Or(h.OnError, h.OnExit)
+	}
+	return ""
+}
+
+// hookFor finds the hook for event on the session name, which may be
+// qualified by host, or "" if there is none.
+func hookFor(hooks map[string]HookConfig, name, event string) string {
+	_, bare, ok := strings.Cut(name, "/")
+	if !ok {
+		bare = name
+	}
+	var best, command string
+	for pattern, h := range hooks {
+		c := h.command(event)
+		if ok, _ := path.Match(pattern, bare); !ok || c == "" {
+			continue
+		}
+		if command == "" || moreSpecific(pattern, best, bare) {
+			best, command = pattern, c
+		}
+	}
+	return command
+}
+
+// moreSpecific reports whether pattern a says more about name than b does.
+func moreSpecific(a, b, name string) bool {
+	switch {
+	case a == name || b == name:
+		return a == name
+	case len(a) != len(b):
+		return len(a) > len(b)
+	}
+	return a < b
+}
+
+// hookEnv describes the session and the event to a hook.
+func hookEnv(s Session, event string) []string {
+	env := []string{
+		"CLAUDE_HOST_EVENT=" + event,
+		"CLAUDE_HOST_SESSION=" + s.BareName(),
+		"CLAUDE_HOST_HOST=" + s.Host,
+		"CLAUDE_HOST_COMMAND=" + s.Command,
+		"CLAUDE_HOST_CWD=" + s.Cwd,
+		"CLAUDE_HOST_DESCRIPTION=" + s.Description,
+		"CLAUDE_HOST_PROJECT=" + s.Project,
+		"CLAUDE_HOST_TAGS=" + strings.Join(s.Tags, ","),
+		"CLAUDE_HOST_CREATED_AT=" + s.CreatedAt,
+	}
+	if s.ExitStatus != nil {
+		env = append(env, "CLAUDE_HOST_EXIT_STATUS="+strconv.Itoa(*s.ExitStatus))
+	}
+	return env
+}
+
+// runHook runs the session's hook for event, if it has one, in the
+// background. Its output is dropped, since the dashboard owns the
+// terminal.
+func runHook(ctx context.Context, hooks map[string]HookConfig, s Session, event string) {
+	command := hookFor(hooks, s.Name, event)
+	if command == "" {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Env = append(os.Environ(), hookEnv(s, event)...)
+		cmd.Run()
+	}()
+}
+
+// exitEvent is the hook event for a session whose process has exited.
+func exitEvent(s Session) string {
+	if s.ExitStatus != nil && *s.ExitStatus != 0 {
+		return hookCrashed
+	}
+	return hookExited
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// HostMenu switches the dashboard to another [hosts] profile, or to all of
+// them at once.
+type HostMenu struct {
+	names   []string
+	hosts   map[string]HostConfig
+	current string
+	cursor  int
+}
+
+type hostPickMsg string
+type hostMenuCloseMsg struct{}
+
+func NewHostMenu(hosts map[string]HostConfig, current string) HostMenu {
+	menu := HostMenu{hosts: hosts, current: current}
+	for name := range hosts {
+		menu.names = append(menu.names, name)
+	}
+	sort.Strings(menu.names)
+	if len(menu.names) > 1 {
+		menu.names = append(menu.names, allHosts)
+	}
+	for i, name := range menu.names {
+		if name == current {
+			menu.cursor = i
+		}
+	}
+	return menu
+}
+
+func (h HostMenu) Update(msg tea.KeyMsg) (HostMenu, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		return h, func() tea.Msg { return hostMenuCloseMsg{} }
+	case "up", "k":
+		if h.cursor > 0 {
+			h.cursor--
+		}
+	case "down", "j":
+		if h.cursor < len(h.names)-1 {
+			h.cursor++
+		}
+	case "enter":
+		if h.cursor < len(h.names) && h.names[h.cursor] != h.current {
+			name := h.names[h.cursor]
+			return h, func() tea.Msg { return hostPickMsg(name) }
+		}
+		return h, func() tea.Msg { return hostMenuCloseMsg{} }
+	}
+	return h, nil
+}
+
+func (h HostMenu) View() string {
+	var s strings.Builder
+	s.WriteString("  " + titleStyle.Render("switch host") + "\n\n")
+	if len(h.names) == 0 {
+		s.WriteString("  " + dimStyle.Render(fmt.Sprintf("No hosts configured. Add [hosts.<name>] tables with a url to %s.", configPath())) + "\n")
+		s.WriteString("\n  " + dimStyle.Render("esc close") + "\n")
+		return s.String()
+	}
+	width := len("all hosts")
+	for _, name := range h.names {
+		width = max(width, textWidth(name))
+	}
+	for i, name := range h.names {
+		prefix := "  "
+		style := normStyle
+		if i == h.cursor {
+			prefix = "▸ "
+			style = selStyle
+		}
+		mark := "  "
+		if name == h.current {
+			mark = activeStyle.Render("● ")
+		}
+		label, url := name, h.hosts[name].URL
+		if name == allHosts {
+			label, url = "all hosts", "merged list"
+		}
+		s.WriteString(fmt.Sprintf("  %s%s%s  %s\n", prefix, mark, style.Render(pad(label, width)), dimStyle.Render(url)))
+	}
+	s.WriteString("\n  " + dimStyle.Render("↑↓ select  enter switch  esc close") + "\n")
+	return s.String()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GitHubConfig is [github] in the config file, for sessions started from
+// an issue.
+type GitHubConfig struct {
+	Token string `toml:"token"` // default $GITHUB_TOKEN; public issues need none
+	// Repos maps "owner/repo" to its checkout on the server, where the
+	// session starts, e.g. [github.repos] "acme/api" = "~/src/api".
+	Repos map[string]string `toml:"repos"`
+}
+
+// githubAPI is where issues are fetched from.
+const githubAPI = "https://api.github.com"
+
+// issueBodyLimit caps how much of an issue's body goes into the prompt,
+// which travels on the session's command line.
+const issueBodyLimit = 8000
+
+// issueRef matches owner/repo#123 or the issue's URL.
+var issueRef = regexp.MustCompile(`^(?:https://github\.com/)?([\w.-]+/[\w.-]+)(?:#|/issues/)(\d+)$`)
+
+// parseIssueRef splits an issue reference into its repository and number.
+func parseIssueRef(ref string) (repo string, number int, err error) {
+	m := issueRef.FindStringSubmatch(strings.TrimSpace(ref))
+	if m == nil {
+		return "", 0, fmt.Errorf("%q is not an issue: use owner/repo#123 or its URL", ref)
+	}
+	number, _ = strconv.Atoi(m[2])
+	return m[1], number, nil
+}
+
+type githubIssue struct {
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// fetchIssue gets an issue from the GitHub API.
+func fetchIssue(ctx context.Context, token, repo string, number int) (*githubIssue, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/repos/%s/issues/%d", githubAPI, repo, number), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "claude-host")
+	if token = cmp.Or(token, os.Getenv("GITHUB_TOKEN")); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return nil, fmt.Errorf("github: %s#%d: %s", repo, number, cmp.Or(e.Message, resp.Status))
+	}
+	var issue githubIssue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return nil, fmt.Errorf("github: %w", err)
+	}
+	return &issue, nil
+}
+
+// issuePrompt is what Claude is started with for an issue.
+func issuePrompt(repo string, number int, issue *githubIssue) string {
+	body := strings.TrimSpace(issue.Body)
+	if len(body) > issueBodyLimit {
+		body = strings.ToValidUTF8(body[:issueBodyLimit], "") + "\n[truncated; see the issue for the rest]"
+	}
+	prompt := fmt.Sprintf("Work on GitHub issue %s#%d: %s\n%s", repo, number, issue.Title, issue.HTMLURL)
+	if body != "" {
+		prompt += "\n\n" + body
+	}
+	return prompt
+}
+
+// issueSessionName names a session after an issue, e.g. api-123.
+func issueSessionName(repo string, number int) string {
+	name := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_' {
+			return r
+		}
+		return '-'
+	}, path.Base(repo))
+	return fmt.Sprintf("%s-%d", name, number)
+}
+
+// createFromIssue starts Claude on a GitHub issue: in the repository's
+// directory from [github] repos, with the issue as its first prompt, in a
+// session named after it and in a project named after the repository.
+// opts can add to that, as the flags of `new` do. If the name is taken the
+// session keeps the server's, and is returned along with the error.
+func createFromIssue(ctx context.Context, api *APIClient, cfg GitHubConfig, ref string, opts CreateOptions) (*Session, error) {
+	repo, number, err := parseIssueRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := fetchIssue(ctx, cfg.Token, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	opts.Command = "claude " + shellQuote(issuePrompt(repo, number, issue))
+	opts.Cwd = cmp.Or(opts.Cwd, cfg.Repos[repo])
+	opts.Description = cmp.Or(opts.Description, issue.Title)
+	opts.Project = cmp.Or(opts.Project, path.Base(repo))
+	s, err := api.CreateSession(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	name := issueSessionName(repo, number)
+	if err := api.RenameSession(ctx, s.Name, name); err != nil {
+		return s, fmt.Errorf("kept the name %s: %w", s.Name, err)
+	}
+	if s.Host != "" {
+		name = s.Host + "/" + name
+	}
+	s.Name = name
+	return s, nil
+}
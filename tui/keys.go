@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// KeyMap holds the resolved keybindings for the dashboard and the attach
+// control layer. Dashboard bindings use bubbletea key names ("ctrl+p",
+// "enter", "up"); the attach prefix and detach key are raw bytes since
+// RunAttach reads stdin directly.
+type KeyMap struct {
+	Prefix byte // attach control prefix, default ctrl-a
+	Detach byte // pressed after Prefix to detach
+
+	Up            []string
+	Down          []string
+	Attach        []string
+	Create        []string
+	Delete        []string
+	Rename        []string
+	Edit          []string
+	Restart       []string
+	Share         []string
+	ShowAll       []string
+	Archive       []string
+	ShowArchived  []string
+	AllUsers      []string
+	Mark          []string
+	MarkAll       []string
+	Tag           []string
+	Send          []string
+	Sort          []string
+	Detail        []string
+	Wall          []string
+	Export        []string
+	Download      []string
+	Editor        []string
+	Group         []string
+	Collapse      []string
+	Expand        []string
+	Project       []string
+	Issue         []string
+	Focus         []string
+	Conversation  []string
+	Search        []string
+	Approve       []string
+	Deny          []string
+	Notifications []string
+	Errors        []string
+	TagFilter     []string
+	Hosts         []string
+	Help          []string
+	Summarize     []string
+	SummarizeAll  []string
+	AutoSummarize []string
+	Find          []string
+	Quit          []string
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Prefix:        0x01,
+		Detach:        'd',
+		Up:            []string{"k", "up"},
+		Down:          []string{"j", "down"},
+		Attach:        []string{"enter"},
+		Create:        []string{"c"},
+		Delete:        []string{"d"},
+		Rename:        []string{"r"},
+		Edit:          []string{"e"},
+		Restart:       []string{"R"},
+		Share:         []string{"L"},
+		ShowAll:       []string{"a"},
+		Archive:       []string{"z"},
+		ShowArchived:  []string{"Z"},
+		AllUsers:      []string{"U"},
+		Mark:          []string{" "},
+		MarkAll:       []string{"*"},
+		Tag:           []string{"t"},
+		Send:          []string{"m"},
+		Sort:          []string{"o"},
+		Detail:        []string{"i"},
+		Wall:          []string{"w"},
+		Export:        []string{"x"},
+		Download:      []string{"D"},
+		Editor:        []string{"E"},
+		Group:         []string{"g"},
+		Collapse:      []string{"h", "left"},
+		Expand:        []string{"l", "right"},
+		Project:       []string{"P"},
+		Issue:         []string{"I"},
+		Focus:         []string{"tab"},
+		Conversation:  []string{"v"},
+		Search:        []string{"/"},
+		Approve:       []string{"y"},
+		Deny:          []string{"n"},
+		Notifications: []string{"n"}, // when no prompt is waiting to be denied
+		Errors:        []string{"!"},
+		TagFilter:     []string{"T"},
+		Hosts:         []string{"H"},
+		Help:          []string{"?"},
+		Summarize:     []string{"s"},
+		SummarizeAll:  []string{"S"},
+		AutoSummarize: []string{"A"},
+		Find:          []string{"ctrl+p"},
+		Quit:          []string{"q", "ctrl+c"},
+	}
+}
+
+// NewKeyMap applies the user's overrides on top of the defaults.
+func NewKeyMap(kc KeyConfig) (KeyMap, error) {
+	km := DefaultKeyMap()
+	if kc.Prefix != "" {
+		b, err := parseControlKey(kc.Prefix)
+		if err != nil {
+			return km, fmt.Errorf("keys.prefix: %w", err)
+		}
+		km.Prefix = b
+	}
+	if kc.Detach != "" {
+		b, err := parseRawKey(kc.Detach)
+		if err != nil {
+			return km, fmt.Errorf("keys.detach: %w", err)
+		}
+		km.Detach = b
+	}
+	for _, o := range []struct {
+		dst *[]string
+		src keyList
+	}{
+		{&km.Up, kc.Up},
+		{&km.Down, kc.Down},
+		{&km.Attach, kc.Attach},
+		{&km.Create, kc.Create},
+		{&km.Delete, kc.Delete},
+		{&km.Rename, kc.Rename},
+		{&km.Edit, kc.Edit},
+		{&km.Restart, kc.Restart},
+		{&km.Share, kc.Share},
+		{&km.ShowAll, kc.ShowAll},
+		{&km.Archive, kc.Archive},
+		{&km.ShowArchived, kc.ShowArchived},
+		{&km.AllUsers, kc.AllUsers},
+		{&km.Mark, kc.Mark},
+		{&km.MarkAll, kc.MarkAll},
+		{&km.Tag, kc.Tag},
+		{&km.Send, kc.Send},
+		{&km.Sort, kc.Sort},
+		{&km.Detail, kc.Detail},
+		{&km.Wall, kc.Wall},
+		{&km.Export, kc.Export},
+		{&km.Download, kc.Download},
+		{&km.Editor, kc.Editor},
+		{&km.Group, kc.Group},
+		{&km.Collapse, kc.Collapse},
+		{&km.Expand, kc.Expand},
+		{&km.Project, kc.Project},
+		{&km.Issue, kc.Issue},
+		{&km.Focus, kc.Focus},
+		{&km.Conversation, kc.Conversation},
+		{&km.Search, kc.Search},
+		{&km.Approve, kc.Approve},
+		{&km.Deny, kc.Deny},
+		{&km.Notifications, kc.Notifications},
+		{&km.Errors, kc.Errors},
+		{&km.TagFilter, kc.TagFilter},
+		{&km.Hosts, kc.Hosts},
+		{&km.Help, kc.Help},
+		{&km.Summarize, kc.Summarize},
+		{&km.SummarizeAll, kc.SummarizeAll},
+		{&km.AutoSummarize, kc.AutoSummarize},
+		{&km.Find, kc.Find},
+		{&km.Quit, kc.Quit},
+	} {
+		if len(o.src) > 0 {
+			*o.dst = normalizeKeys(o.src)
+		}
+	}
+	return km, nil
+}
+
+// Matches reports whether msg is one of the given bindings.
+func (KeyMap) Matches(msg tea.KeyMsg, binding []string) bool {
+	return slices.Contains(binding, msg.String())
+}
+
+// PrefixName is the human-readable attach prefix, e.g. "ctrl-a".
+func (k KeyMap) PrefixName() string {
+	return rawKeyName(k.Prefix)
+}
+
+// DetachHint describes the detach sequence, e.g. "ctrl-a d".
+func (k KeyMap) DetachHint() string {
+	return k.PrefixName() + " " + rawKeyName(k.Detach)
+}
+
+type helpEntry struct {
+	key  string
+	desc string
+}
+
+// DashboardHelp lists every dashboard binding for the help overlay.
+func (k KeyMap) DashboardHelp() []helpEntry {
+	all := func(binding []string) string {
+		names := make([]string, len(binding))
+		for i, b := range binding {
+			names[i] = helpKey([]string{b})
+		}
+		return strings.Join(names, " / ")
+	}
+	return []helpEntry{
+		{all(k.Up), "move up"},
+		{all(k.Down), "move down"},
+		{all(k.Attach), "attach to session"},
+		{all(k.Find), "fuzzy find and attach"},
+		{all(k.Detail), "session details"},
+		{all(k.Wall), "wall of live previews (1-9 attaches)"},
+		{all(k.Focus), "focus the preview to scroll it (pgup/pgdn, g/G)"},
+		{all(k.Search), "search the preview (n/N older/newer match)"},
+		{all(k.Conversation), "preview Claude's conversation instead of the screen"},
+		{all(k.Create), "new session (from a template, if any)"},
+		{all(k.Issue), "new session working on a GitHub issue"},
+		{all(k.Rename), "rename session"},
+		{all(k.Edit), "edit description"},
+		{all(k.Delete), "kill or delete session (purge exited)"},
+		{all(k.Restart), "restart session's command in place"},
+		{all(k.Share), "copy a time-limited link others can attach with"},
+		{all(k.ShowAll), "show / hide exited sessions"},
+		{all(k.Archive), "archive session, stopping it (again to unarchive)"},
+		{all(k.ShowArchived), "show / hide archived sessions"},
+		{all(k.AllUsers), "show every user's sessions (admins)"},
+		{all(k.Send), "send a line of input without attaching"},
+		{all(k.Export), "export transcript to a Markdown file"},
+		{all(k.Download), "download a file from the session's directory"},
+		{all(k.Editor), "open the session's directory in your editor"},
+		{all(k.Group), "group sessions by project"},
+		{all(k.Collapse) + " / " + all(k.Expand), "collapse / expand a project (enter on its header too)"},
+		{all(k.Project), "move session to a project (on a header, the whole project)"},
+		{all(k.Approve) + " / " + all(k.Deny), "answer a waiting permission prompt"},
+		{all(k.Notifications), "notifications: idle and exited sessions, failures, reconnects"},
+		{all(k.Errors), "recent errors: failed requests and dropped connections"},
+		{all(k.Summarize), "summarize session"},
+		{all(k.SummarizeAll), "summarize all sessions"},
+		{all(k.AutoSummarize), "auto-summarize sessions as they change"},
+		{all(k.Mark), "mark session for bulk actions"},
+		{all(k.MarkAll), "mark / unmark all"},
+		{all(k.Tag), "add tags (-tag removes)"},
+		{all(k.TagFilter), "filter by tag"},
+		{all(k.Hosts), "switch host profile"},
+		{all(k.Sort), "sort by position / name / age / activity"},
+		{"esc", "clear marks / tag filter"},
+		{all(k.Help), "this help"},
+		{all(k.Quit), "quit"},
+	}
+}
+
+// AttachHelp lists the control bindings available after the prefix key.
+func (k KeyMap) AttachHelp() []helpEntry {
+	p := k.PrefixName()
+	return []helpEntry{
+		{p + " " + rawKeyName(k.Detach), "detach"},
+		{p + " k", "delete this session (y to confirm)"},
+		{p + " n / " + p + " p", "next / previous session"},
+		{p + " s", "choose a session to switch to"},
+		{p + " c", "new session like this one, and switch to it"},
+		{p + " R", "start / stop recording to an asciicast file"},
+		{p + " [", "copy mode (j/k scroll, v select, y copy, q exit)"},
+		{p + " t", "show / hide the status bar"},
+		{p + " r", "redraw the screen from the session"},
+		{p + " l", "clear the local screen"},
+		{p + " ctrl-z", "suspend (fg to resume)"},
+		{p + " " + p, "send " + p + " to the session"},
+	}
+}
+
+// helpKey is the first binding, formatted for the footer.
+func helpKey(binding []string) string {
+	if len(binding) == 0 {
+		return "?"
+	}
+	if binding[0] == " " {
+		return "space"
+	}
+	return strings.ReplaceAll(binding[0], "+", "-")
+}
+
+// normalizeKeys accepts "ctrl-x" as well as bubbletea's "ctrl+x".
+func normalizeKeys(keys []string) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		if k == "space" {
+			k = " "
+		}
+		for _, mod := range []string{"ctrl-", "alt-", "shift-"} {
+			if strings.HasPrefix(strings.ToLower(k), mod) {
+				k = strings.ToLower(mod[:len(mod)-1]) + "+" + k[len(mod):]
+			}
+		}
+		out[i] = k
+	}
+	return out
+}
+
+// parseControlKey parses "ctrl-a", "ctrl+a", "C-a" or "^A" into its control
+// byte.
+func parseControlKey(s string) (byte, error) {
+	l := strings.ToLower(strings.TrimSpace(s))
+	var letter string
+	switch {
+	case strings.HasPrefix(l, "ctrl-"), strings.HasPrefix(l, "ctrl+"):
+		letter = l[5:]
+	case strings.HasPrefix(l, "c-"):
+		letter = l[2:]
+	case strings.HasPrefix(l, "^"):
+		letter = l[1:]
+	default:
+		return 0, fmt.Errorf("%q is not a control key (expected e.g. \"ctrl-b\")", s)
+	}
+	if len(letter) != 1 {
+		return 0, fmt.Errorf("%q is not a control key (expected e.g. \"ctrl-b\")", s)
+	}
+	c := letter[0]
+	if !(c >= 'a' && c <= 'z') && !(c >= '@' && c <= '_') {
+		return 0, fmt.Errorf("%q is not a control key (expected e.g. \"ctrl-b\")", s)
+	}
+	return c & 0x1f, nil
+}
+
+// parseRawKey accepts a single printable character or a control key.
+func parseRawKey(s string) (byte, error) {
+	if len(s) == 1 && s[0] >= 0x20 && s[0] < 0x7f {
+		return s[0], nil
+	}
+	return parseControlKey(s)
+}
+
+func rawKeyName(b byte) string {
+	if b < 0x20 {
+		return "ctrl-" + string(rune(b|0x60))
+	}
+	return string(rune(b))
+}
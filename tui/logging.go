@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// With --verbose the client logs what it does to logPath through log/slog,
+// as text: every API request, attach connections dropping and coming back,
+// the dashboard's live updates and outages, and each error it shows. A
+// problem with a remote server can then be read back afterwards. Without
+// --verbose nothing is logged.
+
+// discardLog is the logger without --verbose.
+var discardLog = slog.New(slog.DiscardHandler)
+
+// logPath is the debug log file, $XDG_CACHE_HOME/claude-host/log.
+func logPath() string {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(dir, "claude-host", "log")
+}
+
+// logMaxSize is how big the log grows before it is moved to log.1 and
+// started again.
+const logMaxSize = 10 << 20
+
+// openLog appends debug logging to path. Several clients may share the
+// file, so each line carries the process ID.
+func openLog(path string) (*slog.Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Size() > logMaxSize {
+		os.Rename(path, path+".1")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	h := slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(h).With("pid", os.Getpid()), nil
+}
+
+// log is the client's debug log.
+func (a *APIClient) log() *slog.Logger {
+	if a.conn.Log == nil {
+		return discardLog
+	}
+	return a.conn.Log
+}
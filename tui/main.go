@@ -1,43 +1,135 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	baseURL := "http://localhost:3000"
-	if v := os.Getenv("CLAUDE_HOST"); v != "" {
-		baseURL = v
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := newRootCmd().ExecuteContext(ctx)
+	stop()
+	var exit *exitError
+	if errors.As(err, &exit) {
+		os.Exit(exit.code)
 	}
-	if len(os.Args) > 1 {
-		baseURL = os.Args[1]
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
 	}
+}
+
+// tuiOptions is the configuration runTUI needs beyond the API client.
+type tuiOptions struct {
+	keys      KeyMap
+	approve   *approver
+	attach    AttachOptions
+	notify    NotifyConfig
+	hooks     map[string]HookConfig // [hooks], run by the watcher
+	columns   []string
+	host      string                // active [hosts] profile, shown in the header
+	hosts     map[string]HostConfig // profiles the host menu offers
+	templates map[string]Template   // presets the create key offers
+	summarize SummarizeConfig       // [summarize] options
+	editor    EditorConfig          // [editor] commands
+	github    GitHubConfig          // [github], for sessions from issues
+	poll      time.Duration         // how often to refresh without live events
+	errlog    *errorLog             // recent errors, for the error panel
+	connect   func(host string) (*APIClient, error)
+	// external, if set, attaches somewhere other than this terminal, as
+	// `claude-host tmux` and [dashboard] attach_command do, and the
+	// dashboard stays up.
+	external func(ctx context.Context, host, name string) error
+}
+
+// runTUI runs the dashboard, dropping into attach and back until the user
+// quits, reconnecting when they switch host.
+func runTUI(ctx context.Context, api *APIClient, opts tuiOptions) error {
+	for {
+		host, err := runHost(ctx, api, opts)
+		if err != nil || host == "" {
+			return err
+		}
+		if api, err = opts.connect(host); err != nil {
+			return err
+		}
+		opts.host = host
+	}
+}
 
-	api := NewAPIClient(baseURL)
+// runHost runs the dashboard against one server until the user quits or
+// picks another host, which it returns. Requests still in flight when the
+// dashboard exits are cancelled. The session watcher keeps running
+// throughout, so notifications arrive while attached too.
+func runHost(ctx context.Context, api *APIClient, opts tuiOptions) (string, error) {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	notes := newNotifications()
+	watch := newWatcher(api, opts.notify, opts.hooks, opts.poll, newAutoSummarizer(api, opts.summarize, notes), notes)
+	go watch.run(ctx)
 
+	// notice is how the last attach ended, if the dashboard should say.
+	notice := ""
 	for {
-		m := NewDashboard(api)
-		p := tea.NewProgram(m, tea.WithAltScreen())
+		dctx, cancel := context.WithCancel(ctx)
+		m := NewDashboard(dctx, api, opts, watch)
+		m.notice, notice = notice, ""
+		p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 		final, err := p.Run()
+		cancel()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
+			return "", err
 		}
 
 		result := final.(DashboardModel).result
 		switch result.Action {
 		case ActionQuit:
-			return
+			return "", nil
+		case ActionSwitchHost:
+			api.log().Info("switching host", "host", result.Host)
+			return result.Host, nil
 		case ActionAttach:
+			api.log().Debug("dashboard attaching", "session", result.SessionName)
+			if opts.external != nil {
+				if err := opts.external(ctx, opts.host, result.SessionName); err != nil {
+					notice = result.SessionName + ": " + err.Error()
+				}
+				continue
+			}
 			fmt.Print("\033[2J\033[H")
-			// Set terminal title with detach hint (visible in tab/title bar)
-			fmt.Printf("\033]2;%s · ctrl-a d to detach\007", result.SessionName)
-			RunAttach(api, result.SessionName)
-			fmt.Print("\033]2;\007") // reset title
+			watch.SetAttached(result.SessionName)
+			switch res, status := attachWithTitle(ctx, api, result.SessionName, opts.keys, opts.attach); res {
+			case Exited:
+				notice = result.SessionName + ": " + exitText(status)
+			case Disconnected:
+				notice = result.SessionName + ": connection lost"
+			}
+			watch.SetAttached("")
 			fmt.Print("\033[2J\033[H")
 		}
 	}
 }
+
+// attachWithTitle wraps RunAttach, setting the terminal title to the session
+// name with a detach hint (visible in tab/title bar).
+func attachWithTitle(ctx context.Context, api *APIClient, name string, keys KeyMap, opts AttachOptions) (AttachResult, *int) {
+	setTitle(name, keys, 0)
+	defer fmt.Print("\033]2;\007") // reset title
+	return RunAttach(ctx, api, name, keys, opts)
+}
+
+// setTitle shows the session name and detach hint in the title bar, with the
+// connection's round-trip latency once it is known.
+func setTitle(name string, keys KeyMap, latency time.Duration) {
+	if latency > 0 {
+		fmt.Printf("\033]2;%s · %dms · %s to detach\007", name, latency.Milliseconds(), keys.DetachHint())
+		return
+	}
+	fmt.Printf("\033]2;%s · %s to detach\007", name, keys.DetachHint())
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A manifest declares a set of sessions for `claude-host up`, e.g.
+//
+//	project: sprint-12
+//	sessions:
+//	  - name: auth
+//	    cwd: ~/src/api
+//	    prompt: Move the login handler onto the new session store.
+//	    env:
+//	      CLAUDE_MODEL: opus
+//	  - name: docs
+//	    command: claude --permission-mode acceptEdits
+//	    cwd: ~/src/docs
+//
+// up creates the sessions that don't exist, restarts those that have
+// exited and leaves running ones be, so it can be run again as the
+// manifest changes.
+type Manifest struct {
+	Project  string            `yaml:"project"` // default project for the sessions
+	Sessions []ManifestSession `yaml:"sessions"`
+}
+
+// ManifestSession is one session in a manifest.
+type ManifestSession struct {
+	Name        string            `yaml:"name"`
+	Command     string            `yaml:"command"` // default claude
+	Prompt      string            `yaml:"prompt"`  // shell-quoted onto the command
+	Cwd         string            `yaml:"cwd"`     // on the server; ~ is its home
+	Env         map[string]string `yaml:"env"`
+	Description string            `yaml:"description"`
+	Project     string            `yaml:"project"`
+	Host        string            `yaml:"host"` // profile to create it on, with --all-hosts
+}
+
+// options is the create request for the session.
+func (s ManifestSession) options() CreateOptions {
+	return CreateOptions{
+		Command:     s.command(),
+		Cwd:         s.Cwd,
+		Env:         s.Env,
+		Description: s.Description,
+		Project:     s.Project,
+		Host:        s.Host,
+	}
+}
+
+// command is the command line the session runs, prompt and all.
+func (s ManifestSession) command() string {
+	cmd := cmp.Or(s.Command, "claude")
+	if s.Prompt != "" {
+		cmd += " " + shellQuote(s.Prompt)
+	}
+	return cmd
+}
+
+// loadManifest reads and checks a manifest file.
+func loadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decodeManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// decodeManifest parses a manifest, refusing keys it doesn't know, and
+// checks the sessions' names.
+func decodeManifest(data []byte) (*Manifest, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	m := &Manifest{}
+	if err := dec.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(m.Sessions) == 0 {
+		return nil, fmt.Errorf("sessions: expected a list of sessions")
+	}
+	seen := map[string]bool{}
+	for i := range m.Sessions {
+		s := &m.Sessions[i]
+		where := fmt.Sprintf("sessions[%d]", i)
+		if !validSessionName.MatchString(s.Name) {
+			return nil, fmt.Errorf("%s.name: %q must be letters, digits, - and _", where, s.Name)
+		}
+		if seen[s.Host+"/"+s.Name] {
+			return nil, fmt.Errorf("%s: %s is declared twice", where, s.Name)
+		}
+		seen[s.Host+"/"+s.Name] = true
+		s.Project = cmp.Or(s.Project, m.Project)
+	}
+	return m, nil
+}
+
+// upAction is what up does, or did, to a session.
+type upAction string
+
+const (
+	upCreate    upAction = "create"
+	upRestart   upAction = "restart"
+	upRecreate  upAction = "recreate"
+	upUpdate    upAction = "update"
+	upUnchanged upAction = "unchanged"
+	upDiffers   upAction = "differs"
+	upArchived  upAction = "archived"
+	upPrune     upAction = "prune"
+)
+
+// upStep is one change up makes to bring the server in line with the
+// manifest.
+type upStep struct {
+	Action  upAction
+	Name    string // as listed, qualified by host on an aggregate client
+	Session ManifestSession
+	Detail  string
+}
+
+// planUp works out the steps that bring sessions, as listed, in line with
+// the manifest. Running sessions whose command or env no longer match are
+// replaced with recreate, and left as they are otherwise. With prune,
+// sessions in the manifest's projects that it doesn't declare are
+// deleted.
+func planUp(m *Manifest, sessions []Session, api *APIClient, recreate, prune bool) []upStep {
+	byName := map[string]Session{}
+	for _, s := range sessions {
+		byName[s.Name] = s
+	}
+	var steps []upStep
+	declared := map[string]bool{}
+	projects := map[string]bool{}
+	for _, ms := range m.Sessions {
+		name := ms.Name
+		if host := cmp.Or(ms.Host, api.DefaultHost()); host != "" {
+			name = host + "/" + name
+		}
+		declared[name] = true
+		if ms.Project != "" {
+			projects[ms.Project] = true
+		}
+		step := upStep{Name: name, Session: ms}
+		s, ok := byName[name]
+		switch {
+		case !ok:
+			step.Action = upCreate
+		case s.Archived:
+			step.Action, step.Detail = upArchived, "unarchive it to manage it here"
+		case s.Command != ms.command() || !maps.Equal(s.Env, ms.Env) && len(s.Env)+len(ms.Env) > 0:
+			what := "command"
+			if s.Command == ms.command() {
+				what = "env"
+			}
+			if recreate || !s.Alive {
+				step.Action, step.Detail = upRecreate, what+" changed"
+			} else {
+				step.Action, step.Detail = upDiffers, what+" changed; --recreate replaces it"
+			}
+		case !s.Alive:
+			step.Action = upRestart
+		case s.Description != ms.Description || s.Project != ms.Project:
+			step.Action = upUpdate
+			switch {
+			case s.Description == ms.Description:
+				step.Detail = "project"
+			case s.Project == ms.Project:
+				step.Detail = "description"
+			default:
+				step.Detail = "description and project"
+			}
+		default:
+			step.Action = upUnchanged
+		}
+		steps = append(steps, step)
+	}
+	if prune {
+		var extra []upStep
+		for _, s := range sessions {
+			if s.Project != "" && projects[s.Project] && !declared[s.Name] {
+				extra = append(extra, upStep{Action: upPrune, Name: s.Name, Detail: "not in the manifest"})
+			}
+		}
+		sort.Slice(extra, func(i, j int) bool { return extra[i].Name < extra[j].Name })
+		steps = append(steps, extra...)
+	}
+	return steps
+}
+
+// apply carries out the step.
+func (st upStep) apply(ctx context.Context, api *APIClient) error {
+	switch st.Action {
+	case upCreate:
+		return createNamed(ctx, api, st.Session)
+	case upRecreate:
+		if err := api.DeleteSession(ctx, st.Name); err != nil {
+			return err
+		}
+		return createNamed(ctx, api, st.Session)
+	case upRestart:
+		if err := api.RestartSession(ctx, st.Name); err != nil {
+			return err
+		}
+		return st.updateMetadata(ctx, api)
+	case upUpdate:
+		return st.updateMetadata(ctx, api)
+	case upPrune:
+		return api.DeleteSession(ctx, st.Name)
+	}
+	return nil
+}
+
+// updateMetadata brings the session's description and project in line.
+func (st upStep) updateMetadata(ctx context.Context, api *APIClient) error {
+	if err := api.UpdateDescription(ctx, st.Name, st.Session.Description); err != nil {
+		return err
+	}
+	return api.SetProject(ctx, st.Name, st.Session.Project)
+}
+
+// createNamed creates the session and gives it its declared name. The
+// server picks names, so if the rename fails the session is deleted
+// again, rather than left for the next run to duplicate.
+func createNamed(ctx context.Context, api *APIClient, ms ManifestSession) error {
+	s, err := api.CreateSession(ctx, ms.options())
+	if err != nil {
+		return err
+	}
+	name := s.Name
+	if s.Host != "" {
+		name = s.Host + "/" + name
+	}
+	if err := api.RenameSession(ctx, name, ms.Name); err != nil {
+		api.DeleteSession(ctx, name)
+		return err
+	}
+	return nil
+}
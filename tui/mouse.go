@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const doubleClickInterval = 400 * time.Millisecond
+
+// Terminals wider than this get the list and a tall preview side by side.
+const sideBySideWidth = 120
+
+func (m DashboardModel) sideBySide() bool {
+	return m.width > sideBySideWidth
+}
+
+// listWidth is the width of the session list in the side-by-side layout.
+func (m DashboardModel) listWidth() int {
+	return max(64, m.width*2/5)
+}
+
+// rowLayout mirrors the layout in View: the screen line each session row
+// starts on (-1 in a collapsed project), and the first line of the preview
+// pane when it is below the list.
+func (m DashboardModel) rowLayout() (rows []int, previewTop int) {
+	y := 3 // blank line, title, blank line
+	y += strings.Count(m.offlineView(), "\n")
+	if len(m.sessions) == 0 {
+		y++
+	}
+	rows = make([]int, len(m.sessions))
+	for i := range rows {
+		rows[i] = -1
+	}
+	for _, r := range m.rows() {
+		if !r.header {
+			rows[r.session] = y
+			if m.detailLine(m.sessions[r.session]) != "" {
+				y++
+			}
+		}
+		y++
+	}
+	return rows, y + 2 // blank line and separator
+}
+
+// sessionAt returns the index of the session drawn on screen line y, or -1.
+func (m DashboardModel) sessionAt(y int) int {
+	rows, _ := m.rowLayout()
+	for i, top := range rows {
+		if top < 0 {
+			continue
+		}
+		height := 1
+		if m.detailLine(m.sessions[i]) != "" {
+			height++
+		}
+		if y >= top && y < top+height {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m DashboardModel) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	_, previewTop := m.rowLayout()
+	inPreview := m.snapshot != "" && msg.Y >= previewTop
+	if m.sideBySide() {
+		inPreview = m.snapshot != "" && msg.X >= m.listWidth()
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		if inPreview {
+			m.preview.LineUp(3)
+			return m, nil
+		}
+		if m.move(-1) {
+			m.snapshot = ""
+			return m, m.fetchSnapshot()
+		}
+	case tea.MouseButtonWheelDown:
+		if inPreview {
+			m.preview.LineDown(3)
+			return m, nil
+		}
+		if m.move(1) {
+			m.snapshot = ""
+			return m, m.fetchSnapshot()
+		}
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return m, nil
+		}
+		i := m.sessionAt(msg.Y)
+		if i < 0 || inPreview {
+			return m, nil
+		}
+		now := time.Now()
+		double := i == m.lastRow && now.Sub(m.lastClick) < doubleClickInterval
+		m.lastClick, m.lastRow = now, i
+		if double && m.sessions[i].Alive {
+			m.result = DashboardResult{Action: ActionAttach, SessionName: m.sessions[i].Name}
+			return m, tea.Quit
+		}
+		if i != m.cursor || m.onHeader {
+			m.cursor, m.onHeader = i, false
+			m.snapshot = ""
+			return m, m.fetchSnapshot()
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// allHosts is the host menu entry, and --host value, that aggregates every
+// profile into one dashboard.
+const allHosts = "*"
+
+// An aggregate client fans list calls out to one client per host and merges
+// the results, qualifying each session's name as "host/name". Calls that
+// take a name are routed to the host it names. Session names can't contain
+// "/", so the qualified form is unambiguous.
+type multiHost struct {
+	names []string // sorted
+	peers map[string]*APIClient
+
+	mu   sync.Mutex
+	errs map[string]error // hosts the last list call couldn't reach
+}
+
+// NewMultiClient returns a client over several hosts.
+func NewMultiClient(peers map[string]*APIClient) *APIClient {
+	mh := &multiHost{peers: peers}
+	var urls []string
+	var log *slog.Logger
+	for name, p := range peers {
+		mh.names = append(mh.names, name)
+		urls = append(urls, p.String())
+		log = p.conn.Log
+	}
+	sort.Strings(mh.names)
+	sort.Strings(urls)
+	// The peers share the one debug log.
+	return &APIClient{baseURL: strings.Join(urls, ", "), multi: mh, conn: ConnOptions{Log: log}}
+}
+
+// route returns the client a session name belongs to and its name there.
+// A name that doesn't resolve to a host is left on the aggregate client,
+// where requests fail as not found.
+func (a *APIClient) route(name string) (*APIClient, string) {
+	if a.multi == nil {
+		return a, name
+	}
+	host, bare, ok := strings.Cut(name, "/")
+	if p := a.multi.peers[host]; ok && p != nil {
+		return p, bare
+	}
+	return a, name
+}
+
+// Hosts lists the aggregated hosts, or nil for a single-host client.
+func (a *APIClient) Hosts() []string {
+	if a.multi == nil {
+		return nil
+	}
+	return a.multi.names
+}
+
+// DefaultHost is where sessions are created when no host is given: the
+// first host alphabetically.
+func (a *APIClient) DefaultHost() string {
+	if a.multi == nil || len(a.multi.names) == 0 {
+		return ""
+	}
+	return a.multi.names[0]
+}
+
+// UnreachableHosts lists the hosts the last list call failed on.
+func (a *APIClient) UnreachableHosts() []string {
+	if a.multi == nil {
+		return nil
+	}
+	a.multi.mu.Lock()
+	defer a.multi.mu.Unlock()
+	var out []string
+	for _, name := range a.multi.names {
+		if a.multi.errs[name] != nil {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// list queries every host concurrently, with exited sessions if all is
+// set. Hosts that fail are left out of the result; it's only an error if
+// none answered.
+func (mh *multiHost) list(ctx context.Context, all bool) ([]Session, error) {
+	results := make([][]Session, len(mh.names))
+	errs := make([]error, len(mh.names))
+	var wg sync.WaitGroup
+	for i, name := range mh.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sessions, err := mh.peers[name].listSessions(ctx, all)
+			for j := range sessions {
+				sessions[j].Host = name
+				sessions[j].Name = name + "/" + sessions[j].Name
+			}
+			results[i], errs[i] = sessions, err
+		}()
+	}
+	wg.Wait()
+
+	mh.mu.Lock()
+	mh.errs = map[string]error{}
+	for i, name := range mh.names {
+		if errs[i] != nil {
+			mh.errs[name] = errs[i]
+		}
+	}
+	mh.mu.Unlock()
+
+	if n := len(mh.names); n > 0 && !slices.ContainsFunc(errs, func(err error) bool { return err == nil }) {
+		if errors.Is(errs[0], context.Canceled) {
+			return nil, errs[0]
+		}
+		return nil, fmt.Errorf("no host reachable: %w", errors.Join(errs...))
+	}
+	var out []Session
+	for _, r := range results {
+		out = append(out, r...)
+	}
+	return out, nil
+}
+
+// events merges every host's subscription, qualifying names. It fails if any
+// host doesn't offer one, since that host would still need polling, and the
+// merged stream ends as soon as any host's does.
+func (mh *multiHost) events(ctx context.Context) (<-chan SessionEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	out := make(chan SessionEvent)
+	var wg sync.WaitGroup
+	for _, name := range mh.names {
+		ch, err := mh.peers[name].Events(ctx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel()
+			for ev := range ch {
+				ev.Name = name + "/" + ev.Name
+				if ev.From != "" {
+					ev.From = name + "/" + ev.From
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+	return out, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The notification panel keeps what happened while the user looked
+// elsewhere: sessions going quiet or exiting, summaries failing and the
+// server coming back, newest first. The list belongs to the watcher, so it
+// outlives each dashboard between attaches; the header counts what hasn't
+// been seen.
+
+// Kinds of notification.
+const (
+	noteIdle      = "idle"
+	noteExited    = "exited"
+	noteCrashed   = "crashed"
+	noteSummarize = "summarize"
+	noteServer    = "server"
+)
+
+// notificationLimit is how many notifications are kept.
+const notificationLimit = 200
+
+type notification struct {
+	at      time.Time
+	kind    string
+	session string // "" for the server's
+	text    string
+}
+
+// notifications is the list, shared between goroutines.
+type notifications struct {
+	mu     sync.Mutex
+	items  []notification // oldest first
+	unread int
+}
+
+func newNotifications() *notifications {
+	return &notifications{}
+}
+
+// add records a notification about session, "" for the server.
+func (n *notifications) add(kind, session, format string, args ...any) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.items = append(n.items, notification{at: time.Now(), kind: kind, session: session, text: fmt.Sprintf(format, args...)})
+	if len(n.items) > notificationLimit {
+		n.items = slices.Delete(n.items, 0, len(n.items)-notificationLimit)
+	}
+	n.unread = min(n.unread+1, len(n.items))
+}
+
+// Unread is how many notifications came since the panel was last open.
+func (n *notifications) Unread() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.unread
+}
+
+// read returns every notification, newest first, marking them read.
+func (n *notifications) read() []notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.unread = 0
+	out := slices.Clone(n.items)
+	slices.Reverse(out)
+	return out
+}
+
+func (n *notifications) clear() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.items, n.unread = nil, 0
+}
+
+// NotificationPanel is the notification list overlay.
+type NotificationPanel struct {
+	items  []notification
+	unread int // how many at the top are new
+	cursor int
+}
+
+type notificationsCloseMsg struct{}
+
+// notificationJumpMsg selects the notification's session in the list.
+type notificationJumpMsg string
+
+func NewNotificationPanel(n *notifications) NotificationPanel {
+	unread := n.Unread()
+	return NotificationPanel{items: n.read(), unread: unread}
+}
+
+func (p NotificationPanel) Update(msg tea.KeyMsg, k KeyMap, n *notifications) (NotificationPanel, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", msg.String() == "q", k.Matches(msg, k.Notifications):
+		return p, func() tea.Msg { return notificationsCloseMsg{} }
+	case k.Matches(msg, k.Up):
+		p.cursor = max(p.cursor-1, 0)
+	case k.Matches(msg, k.Down):
+		p.cursor = min(p.cursor+1, max(len(p.items)-1, 0))
+	case msg.String() == "enter":
+		if p.cursor < len(p.items) && p.items[p.cursor].session != "" {
+			name := p.items[p.cursor].session
+			return p, func() tea.Msg { return notificationJumpMsg(name) }
+		}
+	case msg.String() == "c":
+		n.clear()
+		p.items, p.unread, p.cursor = nil, 0, 0
+	}
+	return p, nil
+}
+
+// noteStyle colors a notification by how much it wants attention.
+func noteStyle(kind string) lipgloss.Style {
+	switch kind {
+	case noteCrashed, noteSummarize:
+		return errSty
+	case noteIdle:
+		return promptSty
+	case noteServer:
+		return activeStyle
+	}
+	return tStyle
+}
+
+// View lists the notifications, as many as fit in height lines.
+func (p NotificationPanel) View(width, height int) string {
+	var s strings.Builder
+	s.WriteString("  " + titleStyle.Render("notifications") + "\n\n")
+	if len(p.items) == 0 {
+		s.WriteString("  " + dimStyle.Render("Nothing yet. Sessions going idle or exiting, failed summaries and reconnects show up here.") + "\n\n")
+	}
+	rows := max(height-8, 3)
+	start := max(0, min(p.cursor-rows/2, len(p.items)-rows))
+	for i := start; i < min(start+rows, len(p.items)); i++ {
+		note := p.items[i]
+		prefix := "  "
+		if i == p.cursor {
+			prefix = "▸ "
+		}
+		mark := " "
+		if i < p.unread {
+			mark = markStyle.Render("●")
+		}
+		when := note.at.Format("15:04:05")
+		if time.Since(note.at) > 12*time.Hour {
+			when = note.at.Format("Jan 2 15:04")
+		}
+		text := note.text
+		if width > 30 {
+			text = truncateTail(text, width-30)
+		}
+		s.WriteString(fmt.Sprintf("  %s%s %s  %s\n", prefix, mark, tStyle.Render(pad(when, 11)), noteStyle(note.kind).Render(text)))
+	}
+	s.WriteString("\n  " + dimStyle.Render("enter go to session · c clear · esc close") + "\n")
+	return s.String()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// While the server is unreachable the dashboard stops its regular polling
+// and retries on its own schedule, backing off from offlineMinRetry to
+// offlineMaxRetry, with a banner that counts down to the next attempt.
+const (
+	offlineMinRetry = time.Second
+	offlineMaxRetry = 30 * time.Second
+)
+
+// offlineMsg reports that listing sessions failed to reach the server.
+type offlineMsg struct{ err error }
+
+// offlineTickMsg redraws the countdown and fires the retry when it's due.
+type offlineTickMsg time.Time
+
+// outage tracks the server being unreachable.
+type outage struct {
+	since   time.Time
+	err     error
+	delay   time.Duration
+	retryAt time.Time
+	trying  bool // a retry is in flight
+}
+
+// next schedules the retry after a failed attempt.
+func (o *outage) next(err error, now time.Time) {
+	o.err = err
+	o.delay = min(max(o.delay*2, offlineMinRetry), offlineMaxRetry)
+	o.retryAt = now.Add(o.delay)
+	o.trying = false
+}
+
+func offlineTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return offlineTickMsg(t)
+	})
+}
+
+// offlineView is the banner shown for as long as the server is unreachable.
+func (m DashboardModel) offlineView() string {
+	if m.offline == nil {
+		return ""
+	}
+	retry := "retrying now"
+	if !m.offline.trying {
+		wait := max(time.Until(m.offline.retryAt).Round(time.Second), time.Second)
+		retry = fmt.Sprintf("retrying in %s", shortDuration(wait))
+	}
+	hint := "is claude-host running?"
+	if !m.listedAt.IsZero() {
+		hint = fmt.Sprintf("last reached %s ago; the list below may be out of date", shortDuration(time.Since(m.listedAt)))
+	}
+	return "  " + errSty.Render(fmt.Sprintf("! server unreachable at %s — %s", m.api.String(), retry)) + "\n" +
+		"  " + dimStyle.Render("  "+hint) + "\n\n"
+}
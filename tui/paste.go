@@ -0,0 +1,71 @@
+package main
+
+import "bytes"
+
+// Bracketed paste: while the app in the session asks for it, tmux passes
+// the request on and the local terminal wraps each paste in these markers.
+// Attach collects a paste whole, so a prefix key inside it is pasted rather
+// than obeyed and the session gets the paste as one uninterrupted run of
+// frames, start marker to end.
+var (
+	pasteStart = []byte("\033[200~")
+	pasteEnd   = []byte("\033[201~")
+)
+
+const (
+	// pasteChunk is the largest frame a paste is sent in, so one huge
+	// paste doesn't become one huge message for the server to buffer.
+	pasteChunk = 16 << 10
+	// pasteMax is as much of a paste as is held waiting for its end
+	// marker; past that it is sent as it arrives.
+	pasteMax = 1 << 20
+)
+
+// pasteBuffer collects a bracketed paste from the input stream.
+type pasteBuffer struct {
+	buf []byte // the paste so far, from its start marker
+}
+
+// add appends input from the start of the paste onward, returning how much
+// of p it took, which is all of it unless the paste ended partway.
+func (b *pasteBuffer) add(p []byte) (n int, done bool) {
+	// The end marker may have been split across reads.
+	from := max(0, len(b.buf)-len(pasteEnd)+1)
+	b.buf = append(b.buf, p...)
+	k := bytes.Index(b.buf[from:], pasteEnd)
+	if k < 0 {
+		return len(p), false
+	}
+	end := from + k + len(pasteEnd)
+	n = len(p) - (len(b.buf) - end)
+	b.buf = b.buf[:end]
+	return n, true
+}
+
+// flush returns what can be sent of an unfinished paste, keeping back what
+// might be the start of its end marker.
+func (b *pasteBuffer) flush() []byte {
+	keep := min(len(b.buf), len(pasteEnd)-1)
+	keep += len(b.buf) - keep - completeUTF8(b.buf[:len(b.buf)-keep])
+	out := bytes.Clone(b.buf[:len(b.buf)-keep])
+	b.buf = append(b.buf[:0], b.buf[len(b.buf)-keep:]...)
+	return out
+}
+
+// sendChunked sends p in frames of at most pasteChunk bytes, never
+// splitting a character between them.
+func sendChunked(p []byte, send func([]byte) error) error {
+	for len(p) > 0 {
+		n := len(p)
+		if n > pasteChunk {
+			if n = completeUTF8(p[:pasteChunk]); n == 0 {
+				n = pasteChunk
+			}
+		}
+		if err := send(p[:n]); err != nil {
+			return err
+		}
+		p = p[n:]
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// castEvent is one [elapsed, type, data] line of an asciicast v2 file.
+type castEvent struct {
+	Time float64
+	Kind string
+	Data string
+}
+
+func (e *castEvent) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("expected [time, type, data], got %d fields", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[1], &e.Kind); err != nil {
+		return err
+	}
+	return json.Unmarshal(raw[2], &e.Data)
+}
+
+// Playback speed is doubled or halved by +/-, within these bounds.
+const (
+	minPlaySpeed = 0.125
+	maxPlaySpeed = 16
+)
+
+// playCast replays an asciicast v2 file to the terminal. space pauses, +/-
+// change speed and q quits.
+func playCast(ctx context.Context, path string, speed float64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !sc.Scan() {
+		return fmt.Errorf("%s: empty file", path)
+	}
+	var hdr castHeader
+	if err := json.Unmarshal(sc.Bytes(), &hdr); err != nil {
+		return fmt.Errorf("%s: bad header: %w", path, err)
+	}
+	if hdr.Version != 2 {
+		return fmt.Errorf("%s: unsupported asciicast version %d", path, hdr.Version)
+	}
+
+	fd := int(os.Stdin.Fd())
+	keys := make(chan byte, 16)
+	if term.IsTerminal(fd) {
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer term.Restore(fd, oldState)
+		go func() {
+			buf := make([]byte, 64)
+			for {
+				n, err := os.Stdin.Read(buf)
+				if err != nil {
+					return
+				}
+				for _, b := range buf[:n] {
+					keys <- b
+				}
+			}
+		}()
+	}
+	if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil && hdr.Width > 0 && (w < hdr.Width || h < hdr.Height) {
+		playStatus(fmt.Sprintf("recorded at %dx%d, terminal is %dx%d", hdr.Width, hdr.Height, w, h))
+	}
+	defer os.Stdout.WriteString("\033[0m\r\n")
+
+	// pos is how far into the recording we are; it advances with wall time
+	// scaled by speed, and stands still while paused.
+	var pos float64
+	paused := false
+	mark := time.Now()
+	advance := func() {
+		now := time.Now()
+		if !paused {
+			pos += now.Sub(mark).Seconds() * speed
+		}
+		mark = now
+	}
+
+	for sc.Scan() {
+		var ev castEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			return fmt.Errorf("%s: bad event: %w", path, err)
+		}
+		for {
+			advance()
+			var timer <-chan time.Time
+			if !paused {
+				wait := (ev.Time - pos) / speed
+				if wait <= 0 {
+					break
+				}
+				timer = time.After(time.Duration(wait * float64(time.Second)))
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-timer:
+			case b := <-keys:
+				advance()
+				switch b {
+				case 'q', 0x03, 0x1b:
+					return nil
+				case ' ':
+					paused = !paused
+					if paused {
+						playStatus("paused")
+					}
+				case '+', '=':
+					speed = min(speed*2, maxPlaySpeed)
+					playStatus(fmt.Sprintf("speed %gx", speed))
+				case '-':
+					speed = max(speed/2, minPlaySpeed)
+					playStatus(fmt.Sprintf("speed %gx", speed))
+				}
+			}
+		}
+		if ev.Kind == "o" {
+			os.Stdout.WriteString(ev.Data)
+		}
+	}
+	return sc.Err()
+}
+
+// playStatus briefly shows a message on the bottom row, as attach does.
+func playStatus(text string) {
+	_, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(os.Stdout, "\0337\033[%d;1H\033[2K\033[7m %s \033[0m\0338", h, text)
+}
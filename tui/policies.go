@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Policies reports a server's policies and how its sessions stand against
+// them, from GET /api/policies.
+type Policies struct {
+	IdleHours   float64 `json:"idle_hours"` // 0 when idle sessions are left alone
+	IdleAction  string  `json:"idle_action"`
+	MaxSessions int     `json:"max_sessions"` // 0 for no cap
+	Running     int     `json:"running"`
+	Reaped      int     `json:"reaped"` // sessions reaped since the server started
+	// NextReap is the running session that will be reaped first if it
+	// stays quiet, nil if none will.
+	NextReap *PolicyReap `json:"next_reap,omitempty"`
+}
+
+type PolicyReap struct {
+	Name string `json:"name"`
+	At   string `json:"at"` // RFC 3339
+}
+
+// Policies fetches the server's session policies. Servers without any
+// answer 404.
+func (a *APIClient) Policies(ctx context.Context) (*Policies, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "GET", "/api/policies", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var p Policies
+	if err := decodeResponse(resp, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+type policiesMsg struct{ p *Policies }
+
+// fetchPolicies loads the server's policies for the title line. A failure
+// leaves the last ones shown.
+func (m DashboardModel) fetchPolicies() tea.Cmd {
+	ctx, api := m.ctx, m.api
+	return func() tea.Msg {
+		p, err := api.Policies(ctx)
+		if err != nil {
+			return nil
+		}
+		return policiesMsg{p}
+	}
+}
+
+// policyView summarises the server's policies for the title line, e.g.
+// "3/5 running · kill after 8h idle, next quiet-otter in 2h".
+func (m DashboardModel) policyView() string {
+	p := m.policies
+	if p == nil || (p.MaxSessions == 0 && p.IdleHours == 0) {
+		return ""
+	}
+	var parts []string
+	if p.MaxSessions > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d running", p.Running, p.MaxSessions))
+	}
+	if p.IdleHours > 0 {
+		idle := fmt.Sprintf("%s after %s idle", p.IdleAction, shortDuration(time.Duration(p.IdleHours*float64(time.Hour))))
+		if p.NextReap != nil {
+			if at, err := time.Parse(time.RFC3339, p.NextReap.At); err == nil {
+				idle += fmt.Sprintf(", next %s in %s", p.NextReap.Name, shortDuration(max(time.Until(at), 0)))
+			}
+		}
+		parts = append(parts, idle)
+	}
+	out := dimStyle.Render("  " + strings.Join(parts, " · "))
+	if p.MaxSessions > 0 && p.Running >= p.MaxSessions {
+		out = errSty.Render("  " + strings.Join(parts, " · "))
+	}
+	return out
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Predictive echo, after mosh: characters typed during attach are drawn
+// straight away, underlined, instead of waiting a round trip for the
+// session to echo them. The server's output is watched for the echo, which
+// replaces the prediction.
+//
+// Only printable characters are predicted. Anything else, like Enter or an
+// arrow key, starts a new epoch, and an epoch's predictions aren't shown
+// until the session has echoed one of them, so nothing typed at a prompt
+// that doesn't echo, such as a password prompt, appears. Predictions that
+// go unconfirmed for too long are taken back and the screen repainted.
+
+// Predictive echo modes ([attach] predict in the config).
+const (
+	predictOff      = "off"      // the default
+	predictAdaptive = "adaptive" // when echoes take longer than predictThreshold
+	predictAlways   = "always"
+)
+
+const (
+	predictThreshold = 30 * time.Millisecond
+	predictExpiry    = time.Second // at least; longer on a slow link
+)
+
+type prediction struct {
+	r     rune
+	at    time.Time
+	epoch int
+}
+
+// predictor tracks what has been typed but not yet echoed. It is not safe
+// for concurrent use; attach guards it with its output lock.
+type predictor struct {
+	mode      string
+	pending   []prediction
+	epoch     int
+	confirmed int           // the latest epoch with an echoed prediction
+	srtt      time.Duration // smoothed time from keystroke to echo
+	drawn     bool          // predictions are on screen, the cursor saved before them
+	hidden    bool          // the session has hidden the cursor
+	esc       escState
+	csi       []byte
+}
+
+func newPredictor(mode string) *predictor {
+	return &predictor{mode: mode, confirmed: -1}
+}
+
+// typed notes input sent to the session.
+func (p *predictor) typed(data []byte) {
+	if p.mode == predictOff || p.mode == "" {
+		return
+	}
+	now := time.Now()
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		if r == utf8.RuneError || !unicode.IsPrint(r) || textWidth(string(r)) != 1 || p.hidden {
+			p.interrupt()
+			continue
+		}
+		p.pending = append(p.pending, prediction{r: r, at: now, epoch: p.epoch})
+	}
+}
+
+// interrupt starts a new epoch, for input that can't be predicted.
+func (p *predictor) interrupt() {
+	p.epoch++
+}
+
+// output notes output from the session, confirming the predictions it
+// echoes. It reports whether predictions already drawn have expired, in
+// which case the screen needs repainting.
+func (p *predictor) output(data []byte) (expired bool) {
+	if p.mode == predictOff || p.mode == "" {
+		return false
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		if !p.text(r) || len(p.pending) == 0 || p.pending[0].r != r {
+			continue
+		}
+		d := time.Since(p.pending[0].at)
+		if p.srtt == 0 {
+			p.srtt = d
+		} else {
+			p.srtt = (7*p.srtt + d) / 8
+		}
+		p.confirmed = max(p.confirmed, p.pending[0].epoch)
+		p.pending = p.pending[1:]
+	}
+	expiry := max(predictExpiry, 3*p.srtt)
+	for len(p.pending) > 0 && time.Since(p.pending[0].at) > expiry {
+		expired = expired || p.pending[0].epoch == p.confirmed
+		p.pending = p.pending[1:]
+	}
+	return expired && p.active()
+}
+
+// text follows escape sequences in output, noting when the cursor is shown
+// or hidden, and reports whether r is text.
+func (p *predictor) text(r rune) bool {
+	switch p.esc {
+	case escStart:
+		switch r {
+		case '[':
+			p.esc, p.csi = escCSI, p.csi[:0]
+		case ']':
+			p.esc = escOSC
+		default:
+			p.esc = escNone
+		}
+		return false
+	case escCSI:
+		if r < 0x40 || r > 0x7e {
+			p.csi = utf8.AppendRune(p.csi, r)
+			return false
+		}
+		p.esc = escNone
+		if string(p.csi) == "?25" && (r == 'l' || r == 'h') {
+			p.hidden = r == 'l'
+		}
+		return false
+	case escOSC:
+		if r == 0x07 {
+			p.esc = escNone
+		} else if r == 0x1b {
+			p.esc = escOSCEnd
+		}
+		return false
+	case escOSCEnd:
+		p.esc = escNone
+		return false
+	}
+	if r == 0x1b {
+		p.esc = escStart
+		return false
+	}
+	return unicode.IsPrint(r)
+}
+
+// active reports whether predictions are being shown at all.
+func (p *predictor) active() bool {
+	switch p.mode {
+	case predictAlways:
+		return true
+	case predictAdaptive:
+		return p.srtt >= predictThreshold
+	}
+	return false
+}
+
+// undraw returns what takes predictions off the screen: the cursor goes
+// back where the session left it, though the characters stay until the
+// session draws over them. Anything else written to the terminal must
+// follow it.
+func (p *predictor) undraw() string {
+	if !p.drawn {
+		return ""
+	}
+	p.drawn = false
+	return "\0338"
+}
+
+// draw returns what shows the pending predictions at the cursor.
+func (p *predictor) draw() string {
+	if !p.active() || p.hidden {
+		return p.undraw()
+	}
+	var b strings.Builder
+	for _, pr := range p.pending {
+		if pr.epoch == p.confirmed {
+			b.WriteRune(pr.r)
+		}
+	}
+	if b.Len() == 0 {
+		return p.undraw()
+	}
+	out := p.undraw() + "\0337\033[4m" + b.String()
+	p.drawn = true
+	return out
+}
+
+// reset forgets every prediction, for when the screen is cleared.
+func (p *predictor) reset() {
+	p.pending = nil
+	p.drawn = false
+	p.interrupt()
+}
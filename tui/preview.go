@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The preview pane is a viewport over the cursor session's snapshot. It
+// follows the bottom of the screen until it is scrolled; tab focuses it so
+// the movement keys scroll instead of moving the cursor, and "/" searches.
+// While focused it holds the last previewScrollback lines of output rather
+// than just the screen. For Claude sessions it can show the conversation
+// instead, laid out by renderTurns.
+//
+// Lines of a screen that weren't on it when the preview last showed that
+// session flash in freshStyle for flashDuration, so new output stands out,
+// including on coming back to a session after looking at others.
+
+const previewScrollback = 2000
+
+const flashDuration = 2 * time.Second
+
+// flashDoneMsg ends the flash started at the time given.
+type flashDoneMsg time.Time
+
+// previewHeight is the most lines the preview may take.
+func (m DashboardModel) previewHeight() int {
+	if m.sideBySide() {
+		// Everything below the header except the footer and status bar,
+		// and the empty line after the status bar's newline.
+		return max(5, m.height-3-strings.Count(m.offlineView(), "\n")-4)
+	}
+	maxLines := 10
+	if m.height > 0 {
+		avail := m.height - len(m.sessions) - 10
+		if avail > 3 {
+			maxLines = min(avail, 18)
+		}
+	}
+	return maxLines
+}
+
+func (m DashboardModel) previewWidth() int {
+	if m.sideBySide() {
+		return m.width - m.listWidth() - 6
+	}
+	return max(20, m.width-4)
+}
+
+// syncPreview loads the snapshot into the viewport, highlighting search
+// matches. A new session's snapshot starts at the bottom, and so does a
+// refresh of one that hadn't been scrolled.
+func (m *DashboardModel) syncPreview() {
+	name := ""
+	if m.cursor < len(m.sessions) {
+		name = m.sessions[m.cursor].Name
+	}
+	follow := name != m.previewName || m.preview.AtBottom()
+	m.previewName = name
+
+	var styled []previewLine
+	if m.turns != nil {
+		styled = renderTurns(m.turns, m.previewWidth())
+	} else {
+		flash := m.freshName == name && time.Now().Before(m.flashUntil)
+		for i, line := range strings.Split(strings.TrimRight(m.snapshot, "\n"), "\n") {
+			style := previewStyle
+			if flash && m.fresh[i] {
+				style = freshStyle
+			}
+			styled = append(styled, previewLine{line, style})
+		}
+	}
+	var re *regexp.Regexp
+	if m.search != "" {
+		re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(m.search))
+	}
+	m.matches = nil
+	lines := make([]string, len(styled))
+	for i, line := range styled {
+		if re != nil && re.MatchString(line.text) {
+			m.matches = append(m.matches, i)
+			lines[i] = highlightMatches(line.text, re, line.style)
+		} else {
+			lines[i] = line.style.Render(line.text)
+		}
+	}
+	m.match = min(m.match, max(0, len(m.matches)-1))
+
+	m.preview.Width = m.previewWidth()
+	m.preview.Height = m.previewHeight()
+	if !m.sideBySide() {
+		m.preview.Height = min(len(lines), m.preview.Height)
+	}
+	m.preview.SetContent(strings.Join(lines, "\n"))
+	if follow {
+		m.preview.GotoBottom()
+	}
+}
+
+// flashFresh compares the session's screen with what the preview last
+// showed of it, starting a flash of the lines that are new.
+func (m *DashboardModel) flashFresh(name, screen string) tea.Cmd {
+	before, ok := m.seen[name]
+	m.seen[name] = screen
+	m.fresh, m.freshName = nil, name
+	if !ok {
+		return nil
+	}
+	m.fresh = freshLines(before, screen)
+	if len(m.fresh) == 0 {
+		return nil
+	}
+	m.flashUntil = time.Now().Add(flashDuration)
+	return tea.Tick(flashDuration, func(t time.Time) tea.Msg { return flashDoneMsg(t) })
+}
+
+// freshLines marks the lines of screen that weren't in before. Repeats
+// count, and output that has only scrolled up isn't new; blank lines never
+// are.
+func freshLines(before, screen string) map[int]bool {
+	count := map[string]int{}
+	for _, line := range strings.Split(before, "\n") {
+		count[strings.TrimRight(line, " ")]++
+	}
+	fresh := map[int]bool{}
+	for i, line := range strings.Split(strings.TrimRight(screen, "\n"), "\n") {
+		line = strings.TrimRight(line, " ")
+		switch {
+		case count[line] > 0:
+			count[line]--
+		case strings.TrimSpace(line) != "":
+			fresh[i] = true
+		}
+	}
+	return fresh
+}
+
+func highlightMatches(line string, re *regexp.Regexp, style lipgloss.Style) string {
+	var s strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(line, -1) {
+		s.WriteString(style.Render(line[last:loc[0]]))
+		s.WriteString(foundStyle.Render(line[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	s.WriteString(style.Render(line[last:]))
+	return s.String()
+}
+
+// previewPane renders the viewport, indented to line up with the list.
+func (m DashboardModel) previewPane() string {
+	var s strings.Builder
+	for _, line := range strings.Split(m.preview.View(), "\n") {
+		s.WriteString("  " + line + "\n")
+	}
+	return s.String()
+}
+
+// showMatch scrolls the current search match to the middle of the pane.
+func (m *DashboardModel) showMatch() {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.preview.SetYOffset(m.matches[m.match] - m.preview.Height/2)
+}
+
+// updatePreview handles keys while the preview has focus. Keys it doesn't
+// use fall through to the dashboard, so enter still attaches.
+func (m DashboardModel) updatePreview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	k := m.keys
+	switch s := msg.String(); {
+	case k.Matches(msg, k.Focus), s == "esc" && m.search == "":
+		m.previewFocus = false
+		return m, m.fetchSnapshot()
+	case s == "esc":
+		m.search = ""
+		m.syncPreview()
+	case k.Matches(msg, k.Up):
+		m.preview.LineUp(1)
+	case k.Matches(msg, k.Down):
+		m.preview.LineDown(1)
+	case s == "pgup", s == "b":
+		m.preview.PageUp()
+	case s == "pgdown", s == " ":
+		m.preview.PageDown()
+	case s == "ctrl+u":
+		m.preview.HalfPageUp()
+	case s == "ctrl+d":
+		m.preview.HalfPageDown()
+	case s == "g", s == "home":
+		m.preview.GotoTop()
+	case s == "G", s == "end":
+		m.preview.GotoBottom()
+	case k.Matches(msg, k.Search):
+		m.prompt = newPrompt(m.search)
+		m.mode = modeSearch
+	case s == "n" && len(m.matches) > 0:
+		// Matches run top to bottom and the newest output is at the
+		// bottom, so n steps back through older output.
+		m.match = (m.match - 1 + len(m.matches)) % len(m.matches)
+		m.showMatch()
+	case s == "N" && len(m.matches) > 0:
+		m.match = (m.match + 1) % len(m.matches)
+		m.showMatch()
+	default:
+		return m.updateNormal(msg)
+	}
+	return m, nil
+}
+
+func (m DashboardModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeNormal
+		return m, nil
+	case "enter":
+		m.mode = modeNormal
+		m.search = strings.TrimSpace(m.prompt.Value())
+		m.match = 0
+		m.syncPreview()
+		if len(m.matches) > 0 {
+			m.match = len(m.matches) - 1
+			m.showMatch()
+		} else if m.search != "" {
+			m.err = fmt.Errorf("no match for %q in the preview", m.search)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+// previewHelp is the footer while the preview has focus.
+func (m DashboardModel) previewHelp() string {
+	k := m.keys
+	help := fmt.Sprintf("%s/%s scroll  pgup/pgdn page  g/G top/bottom  %s search  %s back",
+		helpKey(k.Up), helpKey(k.Down), helpKey(k.Search), helpKey(k.Focus))
+	if m.search != "" {
+		n := 0
+		if len(m.matches) > 0 {
+			n = m.match + 1
+		}
+		help = fmt.Sprintf("%q %d/%d  n/N older/newer  esc clear  ", m.search, n, len(m.matches)) + help
+	}
+	return help
+}
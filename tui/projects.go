@@ -0,0 +1,236 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The grouped view lists sessions under a header for each project, which
+// can be collapsed to hide them. The cursor steps through headers as well
+// as sessions; on a header it stays on the project's first session, with
+// onHeader set, so m.cursor always indexes m.sessions. Sessions in a
+// collapsed project stay in m.sessions, only undrawn, so marks and bulk
+// actions still reach them.
+
+// noProject is the header over sessions that aren't in a project.
+const noProject = "(no project)"
+
+// groupByProject orders sessions by project, keeping their order within
+// each. Sessions without a project come last.
+func groupByProject(sessions []Session) []Session {
+	out := slices.Clone(sessions)
+	slices.SortStableFunc(out, func(a, b Session) int {
+		if (a.Project == "") != (b.Project == "") {
+			if a.Project == "" {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(strings.ToLower(a.Project), strings.ToLower(b.Project))
+	})
+	return out
+}
+
+// listRow is a line of the session list: a session, or, in the grouped
+// view, the header of the project that session starts.
+type listRow struct {
+	session int // index into m.sessions
+	header  bool
+}
+
+// rows lays out the list: every session, or in the grouped view each
+// project's header followed by its sessions unless it is collapsed.
+func (m DashboardModel) rows() []listRow {
+	rows := make([]listRow, 0, len(m.sessions))
+	for i, s := range m.sessions {
+		if m.grouped && (i == 0 || m.sessions[i-1].Project != s.Project) {
+			rows = append(rows, listRow{session: i, header: true})
+		}
+		if !m.grouped || !m.collapsed[s.Project] {
+			rows = append(rows, listRow{session: i})
+		}
+	}
+	return rows
+}
+
+// move steps the cursor dir rows through the list, reporting whether it
+// moved.
+func (m *DashboardModel) move(dir int) bool {
+	rows := m.rows()
+	at := slices.Index(rows, listRow{session: m.cursor, header: m.onHeader})
+	next := at + dir
+	if at < 0 || next < 0 || next >= len(rows) {
+		return false
+	}
+	m.cursor, m.onHeader = rows[next].session, rows[next].header
+	return true
+}
+
+// projectStart is the index of the first session in the project, or -1.
+func (m DashboardModel) projectStart(project string) int {
+	return slices.IndexFunc(m.sessions, func(s Session) bool { return s.Project == project })
+}
+
+// projectSessions are the names of the sessions in the project.
+func (m DashboardModel) projectSessions(project string) []string {
+	var names []string
+	for _, s := range m.sessions {
+		if s.Project == project {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// setCollapsed collapses or expands the cursor's project, leaving the
+// cursor on its header, and remembers it for next time.
+func (m DashboardModel) setCollapsed(collapse bool) (DashboardModel, tea.Cmd) {
+	if !m.grouped || m.cursor >= len(m.sessions) {
+		return m, nil
+	}
+	project := m.sessions[m.cursor].Project
+	if collapse == m.collapsed[project] && m.onHeader {
+		return m, nil
+	}
+	if collapse {
+		m.collapsed[project] = true
+	} else {
+		delete(m.collapsed, project)
+	}
+	m.cursor, m.onHeader = m.projectStart(project), true
+	st := LoadState()
+	st.Collapsed = nil
+	for p := range m.collapsed {
+		st.Collapsed = append(st.Collapsed, p)
+	}
+	slices.Sort(st.Collapsed)
+	return m, func() tea.Msg {
+		SaveState(st)
+		return nil
+	}
+}
+
+// updateHeader handles keys on a project header. Bulk actions apply to
+// every session in the project, by marking them first; those that act on
+// a single session have nothing to act on. Anything else it leaves to
+// updateNormal.
+func (m DashboardModel) updateHeader(msg tea.KeyMsg) (DashboardModel, tea.Cmd, bool) {
+	k := m.keys
+	project := m.sessions[m.cursor].Project
+	switch {
+	case k.Matches(msg, k.Attach):
+		model, cmd := m.setCollapsed(!m.collapsed[project])
+		return model, cmd, true
+	case k.Matches(msg, k.Mark):
+		names := m.projectSessions(project)
+		all := true
+		for _, name := range names {
+			all = all && m.marked[name]
+		}
+		if m.marked == nil {
+			m.marked = map[string]bool{}
+		}
+		for _, name := range names {
+			if all {
+				delete(m.marked, name)
+			} else {
+				m.marked[name] = true
+			}
+		}
+		return m, nil, true
+	case k.Matches(msg, k.Delete), k.Matches(msg, k.Archive), k.Matches(msg, k.Summarize),
+		k.Matches(msg, k.Export), k.Matches(msg, k.Tag), k.Matches(msg, k.Send), k.Matches(msg, k.Project):
+		if len(m.marked) == 0 {
+			m.marked = map[string]bool{}
+			for _, name := range m.projectSessions(project) {
+				m.marked[name] = true
+			}
+		}
+		return m, nil, false
+	case k.Matches(msg, k.Detail), k.Matches(msg, k.Rename), k.Matches(msg, k.Edit),
+		k.Matches(msg, k.Restart), k.Matches(msg, k.Share), k.Matches(msg, k.Download),
+		k.Matches(msg, k.Editor), k.Matches(msg, k.Focus), k.Matches(msg, k.Search),
+		k.Matches(msg, k.Approve):
+		m.notice = "select a session in the project first"
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// renderHeader renders the header of the project session i starts: its
+// name, how many sessions it has and how they're doing.
+func (m DashboardModel) renderHeader(i int) string {
+	project := m.sessions[i].Project
+	prefix, nameS := "  ", promptSty
+	if i == m.cursor && m.onHeader {
+		prefix, nameS = "▸ ", selStyle
+	}
+	fold := "[-]"
+	if m.collapsed[project] {
+		fold = "[+]"
+	}
+	var total, running, waiting int
+	var last time.Time
+	for _, s := range m.sessions[i:] {
+		if s.Project != project {
+			break
+		}
+		total++
+		if s.Alive {
+			running++
+			if t := m.watch.LastChange(s.Name); t.After(last) {
+				last = t
+			}
+		}
+		if m.waiting[s.Name] {
+			waiting++
+		}
+	}
+	parts := []string{fmt.Sprintf("%d sessions", total)}
+	if total == 1 {
+		parts[0] = "1 session"
+	}
+	if running < total {
+		parts = append(parts, fmt.Sprintf("%d running", running))
+	}
+	line := "  " + prefix + dimStyle.Render(fold+" ") + nameS.Render(cmp.Or(project, noProject)) + dimStyle.Render("  "+strings.Join(parts, ", "))
+	if running > 0 {
+		if activity := activityLabel(last); strings.HasPrefix(activity, "●") {
+			line += "  " + activeStyle.Render(activity)
+		} else {
+			line += "  " + tStyle.Render(activity)
+		}
+	}
+	if waiting > 0 {
+		line += " " + waitStyle.Render(fmt.Sprintf(" %d waiting for approval ", waiting))
+	}
+	return line
+}
+
+// updateProject handles the project prompt, moving the targeted sessions
+// into the project typed, or out of any if it's left empty.
+func (m DashboardModel) updateProject(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.mode = modeNormal
+		return m, nil
+	case "enter":
+		m.mode = modeNormal
+		project := strings.TrimSpace(m.prompt.Value())
+		names := m.targets()
+		m.marked = nil
+		api := m.api
+		return m, runBulk(m.ctx, "move", names, func(ctx context.Context, name string) error {
+			return api.SetProject(ctx, name, project)
+		}, m.lister())
+	}
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
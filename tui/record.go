@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// castHeader is the first line of an asciicast v2 file.
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// recorder writes session output to an asciicast v2 file: a JSON header
+// line followed by one [elapsed, type, data] event per line.
+type recorder struct {
+	mu    sync.Mutex
+	path  string
+	f     *os.File
+	w     *bufio.Writer
+	start time.Time
+	runes runeJoiner // so no event splits a character
+}
+
+func newRecorder(path string, width, height int, title string) (*recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &recorder{path: path, f: f, w: bufio.NewWriter(f), start: time.Now()}
+	hdr, _ := json.Marshal(castHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": os.Getenv("TERM"), "SHELL": os.Getenv("SHELL")},
+	})
+	r.w.Write(hdr)
+	r.w.WriteByte('\n')
+	return r, nil
+}
+
+// defaultCastPath names a recording after the session and start time.
+func defaultCastPath(session string) string {
+	session = strings.ReplaceAll(session, "/", "-") // host/name from an aggregate client
+	return fmt.Sprintf("%s-%s.cast", session, time.Now().Format("20060102-150405"))
+}
+
+// Output records bytes written to the terminal.
+func (r *recorder) Output(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if data := r.runes.Next(p); len(data) > 0 {
+		r.event("o", string(data))
+	}
+}
+
+// Resize records a terminal size change.
+func (r *recorder) Resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+func (r *recorder) event(kind, data string) {
+	line, _ := json.Marshal([]any{time.Since(r.start).Seconds(), kind, data})
+	r.w.Write(line)
+	r.w.WriteByte('\n')
+}
+
+// Close flushes and closes the file. It is safe to call more than once.
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	if len(r.runes.pending) > 0 {
+		r.event("o", string(r.runes.pending))
+		r.runes.pending = nil
+	}
+	err := r.w.Flush()
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	r.f = nil
+	return err
+}
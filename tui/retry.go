@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// retryPolicy controls how often a failed request is tried again. Only
+// requests that are safe to repeat are retried, and only on failures that
+// another attempt might fix: the server being unreachable or a gateway in
+// front of it giving up.
+type retryPolicy struct {
+	attempts int           // total tries, 1 for no retries
+	backoff  time.Duration // wait before the first retry, doubling after
+}
+
+const maxRetryBackoff = 5 * time.Second
+
+var defaultRetry = retryPolicy{attempts: 3, backoff: 250 * time.Millisecond}
+
+func newRetryPolicy(cfg RetryConfig) retryPolicy {
+	p := defaultRetry
+	if cfg.Attempts != nil {
+		p.attempts = max(*cfg.Attempts, 1)
+	}
+	if cfg.BackoffMS != nil {
+		p.backoff = time.Duration(max(*cfg.BackoffMS, 0)) * time.Millisecond
+	}
+	return p
+}
+
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+func retryable(err error) bool {
+	e := asAPIError(err)
+	if e == nil {
+		return false
+	}
+	switch e.Status {
+	case 0, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// wait sleeps before retry n (counting from 0), with jitter so clients
+// that lost the server together don't come back in lockstep. It reports
+// false if ctx ends first.
+func (p retryPolicy) wait(ctx context.Context, n int) bool {
+	d := min(p.backoff<<n, maxRetryBackoff)
+	if d > 0 {
+		d = d/2 + rand.N(d/2+1)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,719 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	bolt "go.etcd.io/bbolt"
+)
+
+// serveRefreshInterval is how often the server checks tmux for sessions
+// whose process has exited.
+const serveRefreshInterval = time.Second
+
+// snapshotLines is how much history a snapshot includes above the screen,
+// as on the Node server.
+const snapshotLines = 50
+
+// transcriptInterval is how often running sessions' output is stored, which
+// bounds how much is lost if the server and tmux go down together.
+const transcriptInterval = 30 * time.Second
+
+// server is the embedded implementation of the claude-host API: the REST
+// surface under /api/sessions and the /ws/sessions and /ws/events
+// WebSockets.
+type server struct {
+	accounts map[string]account // by bearer token; empty for an open server
+	sessions *sessionStore
+	bridges  *ptyBridges
+	metrics  *metrics
+	webhooks *webhooks
+	git      *gitCache
+	procs    *procSampler
+	claude   *claudeLogs
+	status   *statusTracker
+	linkKey  []byte // signs share links
+	loopback bool   // listening only on the loopback interface
+	upgrader websocket.Upgrader
+}
+
+func newServer(accounts map[string]account, sessions *sessionStore, hooks *webhooks, linkKey []byte) *server {
+	mt := newMetrics()
+	bridges := newPTYBridges(mt)
+	bridges.onViewers = sessions.viewersChanged
+	bridges.exitStatus = sessions.exitStatus
+	return &server{
+		accounts: accounts,
+		sessions: sessions,
+		webhooks: hooks,
+		git:      newGitCache(),
+		procs:    newProcSampler(),
+		claude:   newClaudeLogs(),
+		status:   newStatusTracker(),
+		linkKey:  linkKey,
+		bridges:  bridges,
+		metrics:  mt,
+		upgrader: websocket.Upgrader{
+			EnableCompression: true,
+			// Only attach clients offer any; see attachproto.go.
+			Subprotocols: []string{attachProtocol, legacyProtocol},
+			// An open server has no token to stop a page in the browser
+			// typing into a session, so upgrades from other sites are
+			// refused. Clients other than browsers send no Origin.
+			CheckOrigin: sameOrigin,
+		},
+	}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/sessions", s.listSessions)
+	mux.HandleFunc("POST /api/sessions", s.createSession)
+	mux.HandleFunc("DELETE /api/sessions/{name}", s.deleteSession)
+	mux.HandleFunc("PATCH /api/sessions/{name}", s.patchSession)
+	mux.HandleFunc("POST /api/sessions/{name}/kill", s.killSession)
+	mux.HandleFunc("POST /api/sessions/{name}/restart", s.restartSession)
+	mux.HandleFunc("POST /api/sessions/{name}/archive", s.archiveSession)
+	mux.HandleFunc("POST /api/sessions/{name}/unarchive", s.archiveSession)
+	mux.HandleFunc("POST /api/sessions/{name}/input", s.sendInput)
+	mux.HandleFunc("PATCH /api/sessions/{name}/metadata", s.patchMetadata)
+	mux.HandleFunc("GET /api/sessions/{name}/snapshot", s.snapshot)
+	mux.HandleFunc("GET /api/sessions/{name}/scrollback", s.scrollback)
+	mux.HandleFunc("GET /api/sessions/{name}/stats", s.stats)
+	mux.HandleFunc("GET /api/sessions/{name}/conversation", s.conversation)
+	mux.HandleFunc("POST /api/sessions/{name}/summarize", s.summarize)
+	mux.HandleFunc("POST /api/sessions/{name}/share", s.shareSession)
+	mux.HandleFunc("GET /api/sessions/{name}/events", s.sessionAuditLog)
+	mux.HandleFunc("POST /api/sessions/{name}/files", s.uploadFile)
+	mux.HandleFunc("GET /api/sessions/{name}/files", s.downloadFile)
+	mux.HandleFunc("GET /api/events", s.auditLog)
+	mux.HandleFunc("GET /api/policies", s.policies)
+	mux.HandleFunc("GET /api/me", s.whoami)
+	mux.HandleFunc("GET /metrics", s.serveMetrics)
+	mux.HandleFunc("GET /api/webhooks", s.listWebhooks)
+	mux.HandleFunc("POST /api/webhooks", s.createWebhook)
+	mux.HandleFunc("DELETE /api/webhooks/{id}", s.deleteWebhook)
+	mux.HandleFunc("POST /api/webhooks/{id}/test", s.testWebhook)
+	mux.HandleFunc("GET /ws/sessions/{name}", s.attach)
+	mux.HandleFunc("GET /ws/events", s.events)
+	// Health answers without a token, so clients can tell a server that's
+	// down from one that rejects them.
+	root := http.NewServeMux()
+	root.HandleFunc("GET /api/health", s.health)
+	root.Handle("/", s.authenticate(mux))
+	return s.guard(root)
+}
+
+// guard refuses requests a web page could make on the user's behalf: those
+// from a name the loopback server doesn't go by, as after DNS rebinding,
+// and changes sent from another site or in a form's content type. Without
+// a token these would otherwise run commands as the local admin.
+func (s *server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.loopback && !loopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, "unrecognised Host "+r.Host)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if !sameOrigin(r) {
+				writeError(w, http.StatusForbidden, "cross-origin request refused")
+				return
+			}
+			if !jsonRequest(r) {
+				writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sameOrigin reports whether r has no Origin, as from the CLI, or one on
+// the host it was sent to.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// jsonRequest reports whether r's body is declared as JSON, which a form
+// can't send across origins. File uploads are multipart and rely on the
+// Origin check alone.
+func jsonRequest(r *http.Request) bool {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt == "multipart/form-data" && strings.HasSuffix(r.URL.Path, "/files") {
+		return true
+	}
+	return mt == "application/json"
+}
+
+// loopbackHost reports whether the Host header names this machine.
+func loopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// run refreshes session liveness and Claude sessions' statuses, reaps idle
+// sessions and stores transcripts until ctx ends.
+func (s *server) run(ctx context.Context) {
+	t := time.NewTicker(serveRefreshInterval)
+	defer t.Stop()
+	save := time.NewTicker(transcriptInterval)
+	defer save.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.sessions.saveTranscripts()
+			return
+		case now := <-t.C:
+			s.sessions.refresh()
+			s.sessions.reap(now)
+			s.status.update(s.sessions.list(true, true), s.claude)
+		case <-save.C:
+			s.sessions.saveTranscripts()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError replies in the {"error": "..."} shape the client reads.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// writeStoreError maps a session store error to a response.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNoSession):
+		writeError(w, http.StatusNotFound, "Not found")
+	case errors.Is(err, errSessionExists), errors.Is(err, errNotRunning):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, errSessionLimit):
+		writeError(w, http.StatusTooManyRequests, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+// session looks up the {name} in the request path, replying 404 if there
+// is no such session or it belongs to someone else.
+func (s *server) session(w http.ResponseWriter, r *http.Request) (Session, bool) {
+	sess, err := s.sessions.get(r.PathValue("name"))
+	if err == nil && !requestUser(r).canAccess(sess) {
+		err = errNoSession
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return Session{}, false
+	}
+	return sess, true
+}
+
+// listSessions lists the user's own sessions, or with ?users=all, which
+// only admins may ask for, everyone's.
+func (s *server) listSessions(w http.ResponseWriter, r *http.Request) {
+	q, u := r.URL.Query(), requestUser(r)
+	everyone := q.Get("users") == "all"
+	if everyone && !u.admin {
+		writeError(w, http.StatusForbidden, "only admins can list other users' sessions")
+		return
+	}
+	out := []Session{}
+	viewers := s.bridges.viewers()
+	tokens := s.claude.usage(s.sessions.list(true, true))
+	for _, sess := range s.sessions.list(q.Get("all") == "1", q.Get("archived") == "1") {
+		if everyone || u.lists(sess) {
+			sess.Viewers = viewers[sess.Name]
+			sess.Tokens = tokens[sess.Name]
+			sess.Status = s.status.status(sess)
+			if sess.Alive {
+				sess.Git = s.git.lookup(sess.Cwd)
+			}
+			if u.link != "" {
+				// A link is for watching; the env may hold secrets.
+				sess.Env = nil
+			}
+			out = append(out, sess)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *server) createSession(w http.ResponseWriter, r *http.Request) {
+	var opts CreateOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	sess, err := s.sessions.create(opts, requestUser(r).name)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.audit(r, sess, auditCreated, sess.Command)
+	writeJSON(w, http.StatusCreated, sess)
+}
+
+func (s *server) deleteSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	if err := s.sessions.delete(sess.Name); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.audit(r, sess, auditDeleted, "")
+	s.metrics.forget(sess.Name)
+	s.procs.forget(sess.Name)
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// patchSession renames a session and/or replaces its description.
+func (s *server) patchSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	name := sess.Name
+	if body.Description != nil {
+		err := s.sessions.update(name, func(sess *Session) { sess.Description = *body.Description })
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+	}
+	if body.Name != nil && *body.Name != name {
+		if err := s.sessions.rename(name, *body.Name); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		s.bridges.rename(name, *body.Name)
+		s.metrics.rename(name, *body.Name)
+		s.procs.rename(name, *body.Name)
+		renamed := sess
+		renamed.Name = *body.Name
+		s.audit(r, renamed, auditRenamed, "from "+name)
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// killSession stops the session's process but keeps it, exited, with its
+// transcript.
+func (s *server) killSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	if err := s.sessions.kill(sess.Name); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.audit(r, sess, auditKilled, "")
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// archiveSession serves /archive, which hides the session from the list
+// unless ?archived=1 is asked for, stopping it if need be, and /unarchive.
+// Its transcript stays either way.
+func (s *server) archiveSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	archive := strings.HasSuffix(r.URL.Path, "/archive")
+	if err := s.sessions.archive(sess.Name, archive); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if archive {
+		s.audit(r, sess, auditArchived, "")
+	} else {
+		s.audit(r, sess, auditUnarchived, "")
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (s *server) restartSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	if err := s.sessions.restart(sess.Name); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.audit(r, sess, auditRestarted, "")
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func (s *server) sendInput(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Data string `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if !sess.Alive {
+		writeError(w, http.StatusConflict, "session is not running")
+		return
+	}
+	if err := tmuxSendKeys(sess.Name, body.Data); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// patchMetadata replaces a session's tags and/or project; either can be
+// left out to keep it.
+func (s *server) patchMetadata(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	var md struct {
+		Tags    []string `json:"tags"`
+		Project *string  `json:"project"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&md); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	err := s.sessions.update(sess.Name, func(sess *Session) {
+		if md.Tags != nil {
+			sess.Tags = md.Tags
+		}
+		if md.Project != nil {
+			sess.Project = strings.TrimSpace(*md.Project)
+		}
+	})
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// capture returns a session's screen with lines of history. Once it has
+// exited that comes from its stored transcript.
+func (s *server) capture(sess Session, lines int) string {
+	if sess.Alive {
+		if text, err := tmuxCapture(sess.Name, lines); err == nil {
+			return text
+		}
+	}
+	text := strings.TrimRight(s.sessions.transcript(sess.Name), "\n")
+	if text == "" {
+		return "[session not running]"
+	}
+	all := strings.Split(text, "\n")
+	return strings.Join(all[max(len(all)-lines, 0):], "\n") + "\n"
+}
+
+// snapshot serves the session's screen, tagged with a hash of it so a
+// client that already has it gets 304 Not Modified instead.
+func (s *server) snapshot(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	text := s.capture(sess, snapshotLines)
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	etag := fmt.Sprintf(`"%016x"`, h.Sum64())
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"text": text})
+}
+
+// etagMatches reports whether an If-None-Match header names etag.
+func etagMatches(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == etag || t == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) scrollback(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	lines := 2000
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "lines must be a positive integer")
+			return
+		}
+		lines = min(n, tmuxHistory)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"text": s.capture(sess, lines)})
+}
+
+// summarize asks claude for a one-line description of what the session is
+// doing and stores it. The body may pick the model, prompt and length. A
+// session that isn't running is a 409, and claude failing, timing out or
+// saying nothing a 502 with what it printed to stderr.
+func (s *server) summarize(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	var req SummarizeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	prompt, err := summaryPrompt(req, sess)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !sess.Alive {
+		writeError(w, http.StatusConflict, "session is not running")
+		return
+	}
+	text := s.capture(sess, 200)
+	if strings.TrimSpace(text) == "" {
+		writeError(w, http.StatusConflict, "nothing on the session's screen to summarize")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), summarizeTimeout)
+	defer cancel()
+	args := []string{"-p", prompt}
+	if req.Model != "" {
+		args = append(args, "--model", req.Model)
+	}
+	cmd := exec.CommandContext(ctx, "claude", args...)
+	cmd.Stdin = strings.NewReader(text)
+	start := time.Now()
+	out, err := cmd.Output()
+	desc := clipSummary(string(out), req.MaxLength)
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		err = fmt.Errorf("claude timed out after %s", summarizeTimeout)
+	case errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0:
+		err = fmt.Errorf("claude failed: %s", bytes.TrimSpace(exitErr.Stderr))
+	case err != nil:
+		err = fmt.Errorf("claude failed: %w", err)
+	case desc == "":
+		err = errors.New("claude gave no summary")
+	}
+	s.metrics.summarized(time.Since(start), err)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	s.sessions.update(sess.Name, func(sess *Session) { sess.Description = desc })
+	s.audit(r, sess, auditSummarized, "")
+	writeJSON(w, http.StatusOK, map[string]string{"description": desc})
+}
+
+func (s *server) attach(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	if !sess.Alive {
+		writeError(w, http.StatusConflict, "session is not running")
+		return
+	}
+	cols, _ := strconv.Atoi(r.URL.Query().Get("cols"))
+	rows, _ := strconv.Atoi(r.URL.Query().Get("rows"))
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer s.metrics.connected("attach")()
+	s.audit(r, sess, auditAttached, "")
+	s.bridges.attach(sess.Name, conn, cols, rows)
+	s.audit(r, sess, auditDetached, "")
+}
+
+// events pushes changes to the sessions the user can access until the
+// client disconnects.
+func (s *server) events(w http.ResponseWriter, r *http.Request) {
+	u := requestUser(r)
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	defer s.metrics.connected("events")()
+	ch, stop := s.sessions.subscribe()
+	defer stop()
+	closed := make(chan struct{})
+	go func() {
+		// Reading handles pings and notices the client going away.
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-closed:
+			return
+		case ev := <-ch:
+			if !u.hears(ev) {
+				continue
+			}
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// listen opens addr, which is host:port or unix:///path/to.sock. A socket
+// file left behind by an unclean exit is removed first.
+func listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, unixScheme); ok {
+		os.Remove(path)
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		os.Chmod(path, 0o600)
+		return ln, nil
+	}
+	return net.Listen("tcp", addr)
+}
+
+// serveOptions configure the embedded server.
+type serveOptions struct {
+	addr   string // host:port or unix:///path/to.sock
+	token  string // the admin's token when there are no users
+	users  map[string]ServeUser
+	dir    string // where the database lives
+	policy policy
+}
+
+// serve runs the embedded server until ctx ends.
+func serve(ctx context.Context, opts serveOptions) error {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return errors.New("tmux is not installed or not in PATH")
+	}
+	path := filepath.Join(opts.dir, "serve.db")
+	db, err := openSessionDB(path)
+	if errors.Is(err, bolt.ErrTimeout) {
+		return fmt.Errorf("%s is in use; is another claude-host serve running?", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	defer db.Close()
+	sessions, err := newSessionStore(db, opts.policy)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	hooks, err := newWebhooks(db, sessions)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	linkKey, err := db.linkKey()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	accounts := newAccounts(opts.token, opts.users)
+	s := newServer(accounts, sessions, hooks, linkKey)
+	// Listen last, so nothing above can fail and leave the port held.
+	ln, err := listen(opts.addr)
+	if err != nil {
+		return err
+	}
+	if a, ok := ln.Addr().(*net.TCPAddr); ok {
+		s.loopback = a.IP.IsLoopback()
+	}
+	done := make(chan struct{})
+	go func() {
+		s.run(ctx)
+		close(done)
+	}()
+	go hooks.run(ctx)
+	defer func() { <-done }()
+	srv := &http.Server{Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	where := "http://" + ln.Addr().String()
+	if ln.Addr().Network() == "unix" {
+		where = opts.addr
+	}
+	log.Printf("claude-host serving at %s", where)
+	switch {
+	case len(opts.users) > 0:
+		log.Printf("%d users; sessions are private to their owner", len(opts.users))
+	case opts.token == "":
+		log.Printf("no token set: any client that can connect has full access")
+	}
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The server keeps an audit trail of what is done to sessions: who did it,
+// when, and from where. It outlives the sessions themselves.
+
+// Audit actions.
+const (
+	auditCreated    = "created"
+	auditAttached   = "attached"
+	auditDetached   = "detached"
+	auditRenamed    = "renamed"
+	auditKilled     = "killed"
+	auditRestarted  = "restarted"
+	auditArchived   = "archived"
+	auditUnarchived = "unarchived"
+	auditDeleted    = "deleted"
+	auditSummarized = "summarized"
+	auditUploaded   = "uploaded"
+	auditDownloaded = "downloaded"
+)
+
+// auditMax is how many events the server keeps, dropping the oldest.
+const auditMax = 10000
+
+// auditLimit is how many events a request gets unless it asks for fewer.
+const auditLimit = 100
+
+// audit records that the request's user did action to sess.
+func (s *server) audit(r *http.Request, sess Session, action, detail string) {
+	ev := AuditEvent{
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Session: sess.Name,
+		Owner:   sess.Owner,
+		Action:  action,
+		User:    requestUser(r).name,
+		Remote:  remoteHost(r),
+		Detail:  detail,
+	}
+	if err := s.sessions.db.putAudit(&ev); err != nil {
+		log.Printf("auditing %s %s: %v", action, sess.Name, err)
+	}
+}
+
+// remoteHost is the address the request came from, without its port, or
+// "local" over a unix socket.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil || host == "" {
+		if r.RemoteAddr == "" || r.RemoteAddr == "@" {
+			return "local"
+		}
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// auditQuery reads ?after= and ?limit= from the request, replying 400 if
+// they aren't numbers.
+func auditQuery(w http.ResponseWriter, r *http.Request) (after uint64, limit int, ok bool) {
+	q := r.URL.Query()
+	limit = auditLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return 0, 0, false
+		}
+		limit = min(n, auditMax)
+	}
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "after must be an event id")
+			return 0, 0, false
+		}
+		after = n
+	}
+	return after, limit, true
+}
+
+// auditLog serves GET /api/events: recent events on every session the user
+// can access, including deleted ones.
+func (s *server) auditLog(w http.ResponseWriter, r *http.Request) {
+	after, limit, ok := auditQuery(w, r)
+	if !ok {
+		return
+	}
+	u := requestUser(r)
+	out, err := s.sessions.db.audit(after, limit, func(ev AuditEvent) bool {
+		return u.canAccess(Session{Name: ev.Session, Owner: ev.Owner})
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// sessionAuditLog serves GET /api/sessions/{name}/events.
+func (s *server) sessionAuditLog(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	after, limit, ok := auditQuery(w, r)
+	if !ok {
+		return
+	}
+	out, err := s.sessions.db.audit(after, limit, func(ev AuditEvent) bool {
+		return ev.Session == sess.Name
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/gorilla/websocket"
+)
+
+// ptyBridges shares one `tmux attach` PTY per session between all of its
+// WebSocket clients, sized to the smallest of them so they don't fight
+// over the size, as tmux itself does by default.
+type ptyBridges struct {
+	mu      sync.Mutex
+	bridges map[string]*ptyBridge
+	metrics *metrics
+	// onViewers is told a session's new viewer count whenever a client
+	// attaches or leaves. It is called without pb.mu held.
+	onViewers func(name string, n int)
+	// exitStatus reports whether a session's process has exited, and how,
+	// once its PTY has closed. It is called without pb.mu held.
+	exitStatus func(name string) (status *int, exited bool)
+}
+
+type ptyBridge struct {
+	name    string
+	pty     *os.File
+	cmd     *exec.Cmd
+	clients map[*bridgeClient]bool
+}
+
+// bridgeQueue is how much output is held for a client that is behind
+// before it is dropped in favour of a repaint.
+const bridgeQueue = 1 << 20
+
+// bridgeEndWait is how long a client has to take the last of a session's
+// output once its PTY has closed.
+const bridgeEndWait = 5 * time.Second
+
+type bridgeClient struct {
+	conn       *websocket.Conn
+	binary     bool // speaking attachProtocol
+	cols, rows int
+	writeMu    sync.Mutex
+
+	// Output waiting to be sent, guarded by mu; see ackWindow.
+	mu          sync.Mutex
+	ready       sync.Cond
+	queue       [][]byte
+	queued      int
+	sent, acked uint64
+	behind      bool // the queue overflowed, and a repaint is owed
+	told        bool // the client knows it's behind
+	closed      bool
+	ending      bool            // the PTY has closed; close once the queue drains
+	final       *controlMessage // sent before closing, if set
+}
+
+// enqueue queues output for the client's writer.
+func (c *bridgeClient) enqueue(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.behind {
+		// The repaint will cover it.
+		return
+	}
+	c.queue = append(c.queue, data)
+	c.queued += len(data)
+	if c.queued > bridgeQueue {
+		c.queue, c.queued = nil, 0
+		c.behind, c.told = true, false
+	}
+	c.ready.Signal()
+}
+
+// end closes the connection once the client has been sent the output
+// already queued, then final if it isn't nil.
+func (c *bridgeClient) end(final *controlMessage) {
+	c.mu.Lock()
+	c.ending, c.final = true, final
+	if c.behind {
+		// There's nothing left to repaint from.
+		c.queue, c.queued = nil, 0
+	}
+	c.ready.Signal()
+	c.mu.Unlock()
+	time.AfterFunc(bridgeEndWait, func() { c.conn.Close() })
+}
+
+// ack records that the client has written out n bytes of output.
+func (c *bridgeClient) ack(n uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n > c.acked && n <= c.sent {
+		c.acked = n
+		c.ready.Signal()
+	}
+}
+
+// unacked is how much output the client has yet to acknowledge. Clients
+// on the old protocol can't, and are left to TCP. Callers hold c.mu.
+func (c *bridgeClient) unacked() uint64 {
+	if !c.binary {
+		return 0
+	}
+	return c.sent - c.acked
+}
+
+// canWrite reports whether the writer has anything to do. Callers hold
+// c.mu.
+func (c *bridgeClient) canWrite() bool {
+	switch {
+	case c.closed:
+		return true
+	case c.ending && len(c.queue) == 0:
+		return true
+	case c.behind:
+		// Tell the client straight away, but only repaint once it has
+		// drawn everything already sent.
+		return !c.told || c.unacked() == 0
+	default:
+		return len(c.queue) > 0 && c.unacked() < ackWindow
+	}
+}
+
+// writer sends queued output to the client until stop is called, calling
+// repaint when the client needs the whole screen redrawn.
+func (c *bridgeClient) writer(repaint func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		for !c.canWrite() {
+			c.ready.Wait()
+		}
+		if c.closed {
+			return
+		}
+		if c.ending && len(c.queue) == 0 {
+			c.closed = true
+			c.mu.Unlock()
+			if c.final != nil {
+				c.control(*c.final)
+			}
+			c.conn.Close()
+			c.mu.Lock()
+			return
+		}
+		var err error
+		switch {
+		case c.behind && !c.told:
+			c.told = true
+			c.mu.Unlock()
+			err = c.control(controlMessage{Type: controlCatchingUp})
+			c.mu.Lock()
+		case c.behind:
+			c.behind = false
+			c.mu.Unlock()
+			repaint()
+			err = c.control(controlMessage{Type: controlCaughtUp})
+			c.mu.Lock()
+		default:
+			data := c.queue[0]
+			c.queue = c.queue[1:]
+			c.queued -= len(data)
+			c.sent += uint64(len(data))
+			c.mu.Unlock()
+			err = c.send(data)
+			c.mu.Lock()
+		}
+		if err != nil {
+			// The reader notices the connection is gone and stops us.
+			c.conn.Close()
+			c.closed = true
+			return
+		}
+	}
+}
+
+// stop ends the writer.
+func (c *bridgeClient) stop() {
+	c.mu.Lock()
+	c.closed = true
+	c.queue = nil
+	c.ready.Signal()
+	c.mu.Unlock()
+}
+
+// control sends a control message, which only clients on attachProtocol
+// understand.
+func (c *bridgeClient) control(msg controlMessage) error {
+	if !c.binary {
+		return nil
+	}
+	data, _ := json.Marshal(msg)
+	return c.write(websocket.BinaryMessage, encodeFrame(frameControl, data))
+}
+
+func (c *bridgeClient) send(data []byte) error {
+	if c.binary {
+		return c.write(websocket.BinaryMessage, encodeFrame(frameData, data))
+	}
+	return c.write(websocket.TextMessage, data)
+}
+
+func (c *bridgeClient) write(kind int, msg []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(kind, msg)
+}
+
+func newPTYBridges(mt *metrics) *ptyBridges {
+	return &ptyBridges{bridges: map[string]*ptyBridge{}, metrics: mt}
+}
+
+// attach serves a client on conn until it disconnects or the session's
+// PTY exits. cols and rows are the client's size, 0 if unknown.
+func (pb *ptyBridges) attach(name string, conn *websocket.Conn, cols, rows int) {
+	defer conn.Close()
+	c := &bridgeClient{conn: conn, binary: conn.Subprotocol() == attachProtocol, cols: cols, rows: rows}
+	c.ready.L = &c.mu
+
+	pb.mu.Lock()
+	b := pb.bridges[name]
+	if b == nil {
+		var err error
+		if b, err = pb.spawn(name, c); err != nil {
+			pb.mu.Unlock()
+			c.send([]byte("\r\n[error: failed to attach: " + err.Error() + "]\r\n"))
+			return
+		}
+	}
+	b.clients[c] = true
+	b.resize()
+	viewers := len(b.clients)
+	pb.mu.Unlock()
+	pb.onViewers(name, viewers)
+
+	defer pb.detach(b, c)
+	repaint := func() { tmuxRefreshClient(b.cmd.Process.Pid) }
+	go c.writer(repaint)
+	defer c.stop()
+	setSize := func(cols, rows int) {
+		if cols > 0 && rows > 0 {
+			pb.mu.Lock()
+			c.cols, c.rows = cols, rows
+			b.resize()
+			pb.mu.Unlock()
+		}
+	}
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if c.binary {
+			t, payload, err := decodeFrame(msg)
+			if err != nil {
+				continue
+			}
+			switch t {
+			case frameData:
+				msg = payload
+			case frameResize:
+				if cols, rows, ok := parseResize(payload); ok {
+					setSize(cols, rows)
+				}
+				continue
+			case framePing:
+				c.write(websocket.BinaryMessage, encodeFrame(framePong, payload))
+				continue
+			case frameAck:
+				if n, ok := parseAck(payload); ok {
+					c.ack(n)
+				}
+				continue
+			case frameControl:
+				var ctl controlMessage
+				if json.Unmarshal(payload, &ctl) == nil && ctl.Type == controlRepaint {
+					repaint()
+				}
+				continue
+			default:
+				continue
+			}
+		} else {
+			var ctl struct {
+				Resize []int `json:"resize"`
+			}
+			if json.Unmarshal(msg, &ctl) == nil && len(ctl.Resize) == 2 {
+				setSize(ctl.Resize[0], ctl.Resize[1])
+				continue
+			}
+		}
+		n, _ := b.pty.Write(msg)
+		pb.metrics.streamed(pb.nameOf(b), 0, n)
+	}
+}
+
+// spawn starts the shared PTY for a session's first client. Callers hold
+// pb.mu.
+func (pb *ptyBridges) spawn(name string, first *bridgeClient) (*ptyBridge, error) {
+	cmd := exec.Command(tmuxPath(), "attach", "-t", tmuxTarget(name))
+	cmd.Env = append(tmuxEnv(), "TERM=xterm-256color")
+	size := &pty.Winsize{Cols: 80, Rows: 24}
+	if first.cols > 0 && first.rows > 0 {
+		size = &pty.Winsize{Cols: uint16(first.cols), Rows: uint16(first.rows)}
+	}
+	f, err := pty.StartWithSize(cmd, size)
+	if err != nil {
+		return nil, err
+	}
+	b := &ptyBridge{name: name, pty: f, cmd: cmd, clients: map[*bridgeClient]bool{}}
+	pb.bridges[name] = b
+	go pb.broadcast(b)
+	return b, nil
+}
+
+// broadcast copies PTY output to every client until the PTY closes, then
+// disconnects them all, saying so if the session's process exited. Older clients get output in text frames, so
+// characters split across reads are held back until they're whole.
+func (pb *ptyBridges) broadcast(b *ptyBridge) {
+	buf := make([]byte, 32*1024)
+	var runes runeJoiner
+	for {
+		n, err := b.pty.Read(buf)
+		if data := runes.Next(buf[:n]); len(data) > 0 {
+			pb.mu.Lock()
+			clients := make([]*bridgeClient, 0, len(b.clients))
+			for c := range b.clients {
+				clients = append(clients, c)
+			}
+			name := b.name
+			pb.mu.Unlock()
+			pb.metrics.streamed(name, n, 0)
+			for _, c := range clients {
+				c.enqueue(data)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	b.cmd.Wait()
+	pb.mu.Lock()
+	if pb.bridges[b.name] == b {
+		delete(pb.bridges, b.name)
+	}
+	clients := make([]*bridgeClient, 0, len(b.clients))
+	for c := range b.clients {
+		clients = append(clients, c)
+	}
+	name := b.name
+	clear(b.clients)
+	pb.mu.Unlock()
+	if len(clients) == 0 {
+		return
+	}
+	// The PTY also closes when the server loses the tmux session for
+	// some other reason, which clients treat as a dropped connection.
+	var final *controlMessage
+	if status, exited := pb.exitStatus(name); exited {
+		final = &controlMessage{Type: controlExited, Status: status}
+	}
+	for _, c := range clients {
+		c.end(final)
+	}
+	pb.onViewers(name, 0)
+}
+
+// detach removes a client, killing the PTY when it was the last one.
+func (pb *ptyBridges) detach(b *ptyBridge, c *bridgeClient) {
+	pb.mu.Lock()
+	if _, ok := b.clients[c]; !ok {
+		// broadcast already dropped everyone when the PTY exited.
+		pb.mu.Unlock()
+		return
+	}
+	delete(b.clients, c)
+	name, viewers := b.name, len(b.clients)
+	if viewers > 0 {
+		b.resize()
+	} else {
+		if pb.bridges[b.name] == b {
+			delete(pb.bridges, b.name)
+		}
+		b.cmd.Process.Kill()
+		b.pty.Close()
+	}
+	pb.mu.Unlock()
+	pb.onViewers(name, viewers)
+}
+
+// viewers is how many clients are attached to each session.
+func (pb *ptyBridges) viewers() map[string]int {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	out := map[string]int{}
+	for name, b := range pb.bridges {
+		out[name] = len(b.clients)
+	}
+	return out
+}
+
+// resize sets the PTY to the smallest size among its clients. Callers hold
+// pb.mu.
+func (b *ptyBridge) resize() {
+	cols, rows := 0, 0
+	for c := range b.clients {
+		if c.cols > 0 && (cols == 0 || c.cols < cols) {
+			cols = c.cols
+		}
+		if c.rows > 0 && (rows == 0 || c.rows < rows) {
+			rows = c.rows
+		}
+	}
+	if cols == 0 {
+		cols = 80
+	}
+	if rows == 0 {
+		rows = 24
+	}
+	pty.Setsize(b.pty, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// nameOf is the session b serves, which a rename can change.
+func (pb *ptyBridges) nameOf(b *ptyBridge) string {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	return b.name
+}
+
+// rename rekeys a session's bridge after the session is renamed.
+func (pb *ptyBridges) rename(name, newName string) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if b := pb.bridges[name]; b != nil {
+		delete(pb.bridges, name)
+		b.name = newName
+		pb.bridges[newName] = b
+	}
+}
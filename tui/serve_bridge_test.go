@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testBridgeClient connects a bridgeClient on attachProtocol to a client
+// connection, with its writer running.
+func testBridgeClient(t *testing.T) (*bridgeClient, *websocket.Conn) {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{Subprotocols: []string{attachProtocol}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+	d := websocket.Dialer{Subprotocols: []string{attachProtocol}}
+	client, _, err := d.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	conn := <-conns
+	c := &bridgeClient{conn: conn, binary: true}
+	c.ready.L = &c.mu
+	go c.writer(func() {})
+	t.Cleanup(c.stop)
+	return c, client
+}
+
+// readOutput reads data frames until n bytes have arrived, failing if they
+// don't within a second.
+func readOutput(t *testing.T, conn *websocket.Conn, n int) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for got := 0; got < n; {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("after %d of %d bytes: %v", got, n, err)
+		}
+		typ, payload, err := decodeFrame(msg)
+		if err != nil || typ != frameData {
+			t.Fatalf("unexpected frame %v: %v", msg, err)
+		}
+		got += len(payload)
+	}
+}
+
+// progress is how much output c has sent and how many chunks wait.
+func progress(c *bridgeClient) (sent uint64, queued int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sent, len(c.queue)
+}
+
+func TestBridgeClientWindow(t *testing.T) {
+	c, client := testBridgeClient(t)
+	chunk := make([]byte, ackEvery)
+	for range ackWindow/ackEvery + 2 {
+		c.enqueue(chunk)
+	}
+
+	// The first window's worth goes out, then nothing more until an ack.
+	readOutput(t, client, ackWindow)
+	time.Sleep(50 * time.Millisecond)
+	if sent, queued := progress(c); sent != ackWindow || queued != 2 {
+		t.Fatalf("sent %d with %d chunks queued, want %d with 2", sent, queued, ackWindow)
+	}
+
+	// Acknowledging one chunk frees room for exactly one more.
+	c.ack(ackEvery)
+	readOutput(t, client, ackEvery)
+	time.Sleep(50 * time.Millisecond)
+	if sent, queued := progress(c); sent != ackWindow+ackEvery || queued != 1 {
+		t.Fatalf("sent %d with %d chunks queued, want %d with 1", sent, queued, ackWindow+ackEvery)
+	}
+
+	c.ack(ackWindow + ackEvery)
+	readOutput(t, client, ackEvery)
+	if sent, queued := progress(c); sent != ackWindow+2*ackEvery || queued != 0 {
+		t.Fatalf("sent %d with %d chunks queued, want all of it", sent, queued)
+	}
+}
+
+func TestBridgeClientAckOutOfRange(t *testing.T) {
+	c := &bridgeClient{binary: true}
+	c.ready.L = &c.mu
+	c.sent = 100
+	c.ack(200) // more than was sent
+	if c.acked != 0 {
+		t.Errorf("acked %d beyond what was sent", c.acked)
+	}
+	c.ack(60)
+	c.ack(40) // going backwards
+	if c.acked != 60 {
+		t.Errorf("acked = %d, want 60", c.acked)
+	}
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Claude Code logs each conversation as JSONL under
+// ~/.claude/projects/<cwd with every other character a dash>/<id>.jsonl. A
+// session's conversations are those started in its directory after it was
+// created and before the next claude session there was; /clear starts a
+// new file, so there can be several.
+
+// claudeDir is where Claude Code keeps its state: $CLAUDE_CONFIG_DIR or
+// ~/.claude.
+func claudeDir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".claude")
+}
+
+var projectDirChars = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// claudeProjectDir is where Claude Code logs conversations started in cwd.
+func claudeProjectDir(cwd string) string {
+	return filepath.Join(claudeDir(), "projects", projectDirChars.ReplaceAllString(cwd, "-"))
+}
+
+// isClaude reports whether a session's command runs Claude Code.
+func isClaude(command string) bool {
+	fields := strings.Fields(command)
+	return len(fields) > 0 && filepath.Base(fields[0]) == "claude"
+}
+
+// claudeLogs finds the conversation logs of Claude sessions and keeps a
+// running tally of each one's token use and latest turns, reading only what
+// has been appended since it last looked.
+type claudeLogs struct {
+	mu    sync.Mutex
+	files map[string]*claudeLog
+}
+
+type claudeLog struct {
+	start  time.Time // first timestamp in the file
+	offset int64     // bytes tallied so far
+	// Claude Code writes a line per content block, each repeating its
+	// message's usage, so usage is kept per message ID.
+	messages map[string]TokenUsage
+	cost     float64 // Claude Code's own running total, when it logs one
+	turns    []Turn  // the last keepTurns, oldest first
+	last     string  // what the last line recorded, logPrompt to logToolResult
+}
+
+// keepTurns is how many of a conversation's turns are kept for previews.
+const keepTurns = 50
+
+func newClaudeLogs() *claudeLogs {
+	return &claudeLogs{files: map[string]*claudeLog{}}
+}
+
+// usage totals the token use of every Claude session in sessions, which
+// should be all of them so each conversation goes to the right one.
+func (c *claudeLogs) usage(sessions []Session) map[string]*TokenUsage {
+	out := map[string]*TokenUsage{}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, sess := range sessions {
+		var total TokenUsage
+		found := false
+		for _, path := range c.sessionLogs(sessions, i) {
+			log := c.files[path]
+			total.add(log.total())
+			found = true
+		}
+		if found {
+			out[sess.Name] = &total
+		}
+	}
+	return out
+}
+
+// last is what the named session's most recent conversation last
+// recorded, or "" if it hasn't logged one. sessions is all of them, as for
+// usage.
+func (c *claudeLogs) last(sessions []Session, name string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.IndexFunc(sessions, func(s Session) bool { return s.Name == name })
+	if i < 0 {
+		return ""
+	}
+	var last *claudeLog
+	for _, path := range c.sessionLogs(sessions, i) {
+		if log := c.files[path]; last == nil || log.start.After(last.start) {
+			last = log
+		}
+	}
+	if last == nil {
+		return ""
+	}
+	return last.last
+}
+
+// latest returns the last n turns of the named session's most recent
+// conversation, or nil if it hasn't logged one. sessions is all of them, as
+// for usage.
+func (c *claudeLogs) latest(sessions []Session, name string, n int) []Turn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, sess := range sessions {
+		if sess.Name != name {
+			continue
+		}
+		var last *claudeLog
+		for _, path := range c.sessionLogs(sessions, i) {
+			if log := c.files[path]; last == nil || log.start.After(last.start) {
+				last = log
+			}
+		}
+		if last == nil {
+			return nil
+		}
+		return slices.Clone(last.turns[max(0, len(last.turns)-n):])
+	}
+	return nil
+}
+
+// sessionLogs lists the conversation logs belonging to sessions[i]: those
+// started in its directory after it was created and before the next Claude
+// session there was.
+func (c *claudeLogs) sessionLogs(sessions []Session, i int) []string {
+	sess := sessions[i]
+	if !isClaude(sess.Command) || sess.Cwd == "" {
+		return nil
+	}
+	from, err := time.Parse(time.DateTime, sess.CreatedAt)
+	if err != nil {
+		return nil
+	}
+	var until time.Time
+	for _, later := range sessions[i+1:] {
+		if isClaude(later.Command) && later.Cwd == sess.Cwd {
+			until, _ = time.Parse(time.DateTime, later.CreatedAt)
+			break
+		}
+	}
+	return c.logs(sess.Cwd, from, until)
+}
+
+// logs lists the conversation logs started in cwd between from and until
+// (zero for no end), bringing each one's tally up to date.
+func (c *claudeLogs) logs(cwd string, from, until time.Time) []string {
+	paths, _ := filepath.Glob(filepath.Join(claudeProjectDir(expandHome(cwd)), "*.jsonl"))
+	var out []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(from) {
+			continue
+		}
+		log := c.files[path]
+		if log == nil {
+			log = &claudeLog{messages: map[string]TokenUsage{}}
+			c.files[path] = log
+		}
+		if info.Size() != log.offset {
+			log.read(path)
+		}
+		if log.start.IsZero() || log.start.Before(from) || (!until.IsZero() && !log.start.Before(until)) {
+			continue
+		}
+		out = append(out, path)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// claudeLine is the part of a log line the tally and turns need.
+type claudeLine struct {
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"timestamp"`
+	IsMeta      bool      `json:"isMeta"`      // injected context, not typed by the user
+	IsSidechain bool      `json:"isSidechain"` // a subagent's conversation
+	Message     struct {
+		ID      string          `json:"id"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"` // a string, or a list of blocks
+		Usage   *struct {
+			Input      int64 `json:"input_tokens"`
+			Output     int64 `json:"output_tokens"`
+			CacheWrite int64 `json:"cache_creation_input_tokens"`
+			CacheRead  int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	TotalCostUSD float64 `json:"totalCostUSD"`
+}
+
+// read tallies the complete lines appended since the last read.
+func (l *claudeLog) read(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.Seek(l.offset, io.SeekStart); err != nil {
+		return
+	}
+	r := bufio.NewReaderSize(f, 64<<10)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// A partial line is still being written; read it next time.
+			return
+		}
+		l.offset += int64(len(line))
+		if !bytes.Contains(line, []byte(`"timestamp"`)) && !bytes.Contains(line, []byte(`"cost-state"`)) {
+			continue
+		}
+		var cl claudeLine
+		if json.Unmarshal(line, &cl) != nil {
+			continue
+		}
+		if l.start.IsZero() && !cl.Timestamp.IsZero() {
+			l.start = cl.Timestamp
+		}
+		switch {
+		case cl.Type == "cost-state":
+			l.cost = cl.TotalCostUSD
+		case cl.Type == "assistant" && cl.Message.Usage != nil && cl.Message.ID != "":
+			u := cl.Message.Usage
+			l.messages[cl.Message.ID] = TokenUsage{
+				Input:      u.Input,
+				Output:     u.Output,
+				CacheWrite: u.CacheWrite,
+				CacheRead:  u.CacheRead,
+				CostUSD:    estimateCost(cl.Message.Model, u.Input, u.Output, u.CacheWrite, u.CacheRead),
+			}
+		}
+		if (cl.Type == "user" || cl.Type == "assistant") && !cl.IsMeta && !cl.IsSidechain {
+			l.addTurn(cl)
+		}
+	}
+}
+
+// contentBlock is one block of a message's content.
+type contentBlock struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text"`
+	Name  string          `json:"name"`  // tool_use
+	Input json.RawMessage `json:"input"` // tool_use
+}
+
+var commandName = regexp.MustCompile(`<command-name>(.*?)</command-name>`)
+
+// addTurn adds a log line to the conversation's turns. Claude Code logs an
+// assistant reply as a line per content block, and tool results as user
+// lines, so everything the assistant does between two prompts is one turn.
+func (l *claudeLog) addTurn(cl claudeLine) {
+	var blocks []contentBlock
+	var text string
+	if json.Unmarshal(cl.Message.Content, &text) == nil {
+		blocks = []contentBlock{{Type: "text", Text: text}}
+	} else if json.Unmarshal(cl.Message.Content, &blocks) != nil {
+		return
+	}
+	if cl.Type == "user" {
+		var parts []string
+		for _, b := range blocks {
+			if b.Type == "tool_result" {
+				l.last = logToolResult
+			}
+			if b.Type != "text" {
+				continue // tool results, images
+			}
+			if m := commandName.FindStringSubmatch(b.Text); m != nil {
+				parts = append(parts, m[1])
+			} else if !strings.HasPrefix(b.Text, "<") {
+				parts = append(parts, b.Text) // not command output
+			}
+		}
+		if len(parts) > 0 {
+			l.appendTurn(Turn{Role: "user", Text: strings.Join(parts, "\n"), Time: cl.Timestamp})
+			l.last = logPrompt
+		}
+		return
+	}
+	n := len(l.turns)
+	if n == 0 || l.turns[n-1].Role != "assistant" {
+		l.appendTurn(Turn{Role: "assistant", Time: cl.Timestamp})
+		n = len(l.turns)
+	}
+	t := &l.turns[n-1]
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			if strings.TrimSpace(b.Text) == "" {
+				continue
+			}
+			if t.Text != "" {
+				t.Text += "\n\n"
+			}
+			t.Text += strings.TrimSpace(b.Text)
+			l.last = logText
+		case "tool_use":
+			t.Tools = append(t.Tools, toolSummary(b.Name, b.Input))
+			l.last = logToolUse
+		}
+	}
+	t.Time = cl.Timestamp
+}
+
+func (l *claudeLog) appendTurn(t Turn) {
+	l.turns = append(l.turns, t)
+	if len(l.turns) > keepTurns {
+		l.turns = slices.Delete(l.turns, 0, len(l.turns)-keepTurns)
+	}
+}
+
+// toolSummary is a tool call as Claude Code shows it, e.g.
+// "Bash(go test ./...)": the tool and its main argument.
+func toolSummary(name string, input json.RawMessage) string {
+	var args map[string]any
+	json.Unmarshal(input, &args)
+	for _, key := range []string{"command", "file_path", "path", "pattern", "url", "query", "description", "prompt"} {
+		if v, ok := args[key].(string); ok && v != "" {
+			v, _, _ = strings.Cut(v, "\n")
+			return name + "(" + truncate(v, 80) + ")"
+		}
+	}
+	return name
+}
+
+// total is the conversation's token use, costed by Claude Code's own figure
+// when it has logged one.
+func (l *claudeLog) total() TokenUsage {
+	var t TokenUsage
+	for _, u := range l.messages {
+		t.add(u)
+	}
+	if l.cost > 0 {
+		t.CostUSD = l.cost
+	}
+	return t
+}
+
+// Dollars per million tokens by model family, for logs without Claude
+// Code's own total. Cache writes cost 1.25 times input, reads a tenth.
+var modelPrices = []struct {
+	family        string
+	input, output float64
+}{
+	{"opus", 5, 25},
+	{"sonnet", 3, 15},
+	{"haiku", 1, 5},
+}
+
+func estimateCost(model string, input, output, cacheWrite, cacheRead int64) float64 {
+	for _, p := range modelPrices {
+		if strings.Contains(model, p.family) {
+			in := float64(input) + 1.25*float64(cacheWrite) + 0.1*float64(cacheRead)
+			return (in*p.input + float64(output)*p.output) / 1e6
+		}
+	}
+	return 0
+}
+
+// conversation serves the last turns of a Claude session's conversation,
+// from Claude Code's own log rather than the screen.
+func (s *server) conversation(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	if !isClaude(sess.Command) {
+		writeError(w, http.StatusNotFound, "session is not running claude")
+		return
+	}
+	n := 10
+	if v := r.URL.Query().Get("turns"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, "turns must be a positive integer")
+			return
+		}
+	}
+	turns := s.claude.latest(s.sessions.list(true, true), sess.Name, min(n, keepTurns))
+	if turns == nil {
+		writeError(w, http.StatusNotFound, "no conversation logged yet")
+		return
+	}
+	writeJSON(w, http.StatusOK, Conversation{Turns: turns})
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// The embedded server keeps its session records and the transcripts of
+// their output in a bbolt file, so exited sessions and their descriptions
+// survive a restart.
+var (
+	sessionsBucket    = []byte("sessions")    // name -> Session JSON
+	transcriptsBucket = []byte("transcripts") // name -> last captured output
+	webhooksBucket    = []byte("webhooks")    // id -> Webhook JSON
+	metaBucket        = []byte("meta")        // server-wide settings, like linkKeyName
+	auditBucket       = []byte("audit")       // big-endian sequence -> AuditEvent JSON
+)
+
+// linkKeyName is the key share links are signed with.
+var linkKeyName = []byte("link_key")
+
+// dataDir is where the server keeps its database: $XDG_DATA_HOME or
+// ~/.local/share, under claude-host.
+func dataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "claude-host")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", "claude-host")
+}
+
+type sessionDB struct {
+	db *bolt.DB
+}
+
+func openSessionDB(path string) (*sessionDB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: requestTimeout})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{sessionsBucket, transcriptsBucket, webhooksBucket, metaBucket, auditBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sessionDB{db: db}, nil
+}
+
+func (d *sessionDB) Close() error { return d.db.Close() }
+
+// sessions loads every stored record, in creation order.
+func (d *sessionDB) sessions() ([]*Session, error) {
+	var out []*Session
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(_, v []byte) error {
+			var s Session
+			if err := json.Unmarshal(v, &s); err != nil {
+				return err
+			}
+			out = append(out, &s)
+			return nil
+		})
+	})
+	slices.SortStableFunc(out, func(a, b *Session) int { return cmp.Compare(a.CreatedAt, b.CreatedAt) })
+	return out, err
+}
+
+func (d *sessionDB) put(s Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put([]byte(s.Name), data)
+	})
+}
+
+// delete removes a session's record and transcript.
+func (d *sessionDB) delete(name string) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(sessionsBucket).Delete([]byte(name)); err != nil {
+			return err
+		}
+		return tx.Bucket(transcriptsBucket).Delete([]byte(name))
+	})
+}
+
+// rename moves a session's record and transcript to its new name.
+func (d *sessionDB) rename(name string, s Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		sb, tb := tx.Bucket(sessionsBucket), tx.Bucket(transcriptsBucket)
+		if err := sb.Delete([]byte(name)); err != nil {
+			return err
+		}
+		if err := sb.Put([]byte(s.Name), data); err != nil {
+			return err
+		}
+		if t := tb.Get([]byte(name)); t != nil {
+			if err := tb.Put([]byte(s.Name), append([]byte(nil), t...)); err != nil {
+				return err
+			}
+		}
+		return tb.Delete([]byte(name))
+	})
+}
+
+func (d *sessionDB) putTranscript(name, text string) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(transcriptsBucket).Put([]byte(name), []byte(text))
+	})
+}
+
+func (d *sessionDB) transcript(name string) string {
+	var text string
+	d.db.View(func(tx *bolt.Tx) error {
+		text = string(tx.Bucket(transcriptsBucket).Get([]byte(name)))
+		return nil
+	})
+	return text
+}
+
+func (d *sessionDB) webhooks() ([]Webhook, error) {
+	var out []Webhook
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(webhooksBucket).ForEach(func(_, v []byte) error {
+			var h Webhook
+			if err := json.Unmarshal(v, &h); err != nil {
+				return err
+			}
+			out = append(out, h)
+			return nil
+		})
+	})
+	slices.SortStableFunc(out, func(a, b Webhook) int { return cmp.Compare(a.CreatedAt, b.CreatedAt) })
+	return out, err
+}
+
+func (d *sessionDB) putWebhook(h Webhook) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(webhooksBucket).Put([]byte(h.ID), data)
+	})
+}
+
+func (d *sessionDB) deleteWebhook(id string) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(webhooksBucket).Delete([]byte(id))
+	})
+}
+
+// linkKey returns the key share links are signed with, making one the first
+// time.
+func (d *sessionDB) linkKey() ([]byte, error) {
+	var key []byte
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		if k := b.Get(linkKeyName); k != nil {
+			key = append([]byte(nil), k...)
+			return nil
+		}
+		key = make([]byte, 32)
+		rand.Read(key)
+		return b.Put(linkKeyName, key)
+	})
+	return key, err
+}
+
+// putAudit appends ev to the audit trail, numbering it, and drops the
+// event auditMax before it.
+func (d *sessionDB) putAudit(ev *AuditEvent) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		ev.ID = id
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(binary.BigEndian.AppendUint64(nil, id), data); err != nil {
+			return err
+		}
+		if id > auditMax {
+			return b.Delete(binary.BigEndian.AppendUint64(nil, id-auditMax))
+		}
+		return nil
+	})
+}
+
+// audit returns up to limit events that keep accepts, oldest first: the
+// first ones numbered above after, or with after 0 the latest.
+func (d *sessionDB) audit(after uint64, limit int, keep func(AuditEvent) bool) ([]AuditEvent, error) {
+	out := []AuditEvent{}
+	err := d.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(auditBucket).Cursor()
+		k, v := c.Last()
+		next := c.Prev
+		if after > 0 {
+			k, v = c.Seek(binary.BigEndian.AppendUint64(nil, after+1))
+			next = c.Next
+		}
+		for ; k != nil && len(out) < limit; k, v = next() {
+			var ev AuditEvent
+			if err := json.Unmarshal(v, &ev); err != nil {
+				return err
+			}
+			if keep(ev) {
+				out = append(out, ev)
+			}
+		}
+		return nil
+	})
+	if after == 0 {
+		slices.Reverse(out)
+	}
+	return out, err
+}
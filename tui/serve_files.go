@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxUpload is the largest file a session's working directory will take.
+const maxUpload = 512 << 20
+
+// sessionPath resolves rel against the session's working directory,
+// refusing paths that climb out of it. The check is on the path alone:
+// the session can reach anywhere its user can regardless, so this guards
+// against mistakes rather than the session.
+func sessionPath(sess Session, rel string) (string, error) {
+	if sess.Cwd == "" {
+		return "", errors.New("the session has no working directory")
+	}
+	if filepath.IsAbs(rel) {
+		return "", errors.New("path must be relative to the session's working directory")
+	}
+	p := filepath.Join(sess.Cwd, rel)
+	if r, err := filepath.Rel(sess.Cwd, p); err != nil || r == ".." || strings.HasPrefix(r, "../") {
+		return "", errors.New("path is outside the session's working directory")
+	}
+	return p, nil
+}
+
+// downloadFile serves GET /api/sessions/{name}/files?path=, a file from the
+// session's working directory.
+func (s *server) downloadFile(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	src, err := sessionPath(sess, r.URL.Query().Get("path"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f, err := os.Open(src)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, "no such file: "+src)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !st.Mode().IsRegular() {
+		writeError(w, http.StatusBadRequest, src+" is not a file")
+		return
+	}
+	s.audit(r, sess, auditDownloaded, src)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": st.Name()}))
+	http.ServeContent(w, r, st.Name(), st.ModTime(), f)
+}
+
+// uploadFile serves POST /api/sessions/{name}/files?path=, writing the
+// multipart "file" into the session's working directory. A path that names
+// a directory keeps the upload's own file name.
+func (s *server) uploadFile(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload+1<<20)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	part, err := mr.NextPart()
+	for err == nil && part.FormName() != "file" {
+		part, err = mr.NextPart()
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "no file in the form")
+		return
+	}
+	rel := r.URL.Query().Get("path")
+	name := filepath.Base(part.FileName())
+	if rel == "" || strings.HasSuffix(rel, "/") {
+		if name == "." || name == "/" {
+			writeError(w, http.StatusBadRequest, "the file has no name; give a path")
+			return
+		}
+		rel += name
+	}
+	dst, err := sessionPath(sess, rel)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if st, err := os.Stat(dst); err == nil && st.IsDir() {
+		dst = filepath.Join(dst, name)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Write alongside and rename, so nothing sees half a file.
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".upload-*")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, io.LimitReader(part, maxUpload+1))
+	if err == nil && n > maxUpload {
+		err = errors.New("file is too large")
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.audit(r, sess, auditUploaded, dst)
+	writeJSON(w, http.StatusOK, RemoteFile{Path: dst, Size: n})
+}
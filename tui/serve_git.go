@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gitTTL is how long a directory's git state is reused before git is asked
+// again, so listing doesn't run git for every session every time.
+const gitTTL = 5 * time.Second
+
+// gitTimeout bounds each git call; a huge repo just goes without.
+const gitTimeout = 2 * time.Second
+
+// gitCache remembers the git state of the directories sessions run in.
+type gitCache struct {
+	mu      sync.Mutex
+	entries map[string]gitEntry
+}
+
+type gitEntry struct {
+	info *GitInfo // nil outside a repository
+	at   time.Time
+}
+
+func newGitCache() *gitCache {
+	return &gitCache{entries: map[string]gitEntry{}}
+}
+
+// lookup returns the git state of dir, or nil if it isn't in a repository.
+func (c *gitCache) lookup(dir string) *GitInfo {
+	if dir == "" {
+		return nil
+	}
+	dir = expandHome(dir)
+	c.mu.Lock()
+	e, ok := c.entries[dir]
+	c.mu.Unlock()
+	if ok && time.Since(e.at) < gitTTL {
+		return e.info
+	}
+	info := gitStatus(dir)
+	c.mu.Lock()
+	c.entries[dir] = gitEntry{info: info, at: time.Now()}
+	c.mu.Unlock()
+	return info
+}
+
+// gitStatus asks git for dir's repository, branch and whether tracked files
+// have uncommitted changes.
+func gitStatus(dir string) *GitInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	root, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil
+	}
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain", "--branch", "--untracked-files=no").Output()
+	if err != nil {
+		return nil
+	}
+	info := &GitInfo{Repo: filepath.Base(strings.TrimSpace(string(root)))}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	// "## main...origin/main [ahead 1]", "## HEAD (no branch)" or
+	// "## No commits yet on main".
+	head := strings.TrimPrefix(lines[0], "## ")
+	head, _, _ = strings.Cut(head, "...")
+	head, _, _ = strings.Cut(head, " [")
+	switch {
+	case strings.HasPrefix(head, "HEAD "):
+		info.Branch = "detached"
+	case strings.HasPrefix(head, "No commits yet on "):
+		info.Branch = strings.TrimPrefix(head, "No commits yet on ")
+	default:
+		info.Branch = head
+	}
+	info.Dirty = len(lines) > 1
+	return info
+}
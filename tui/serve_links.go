@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Share links let someone without an account attach to one session for a
+// while. The token is the link's claims signed with a key kept in the
+// database, so links survive a restart and need no table of their own.
+const (
+	defaultLinkTTL = time.Hour
+	maxLinkTTL     = 7 * 24 * time.Hour
+)
+
+// linkClaims is what a share token grants: access to one session, on
+// behalf of whoever shared it, until it expires.
+type linkClaims struct {
+	Session string `json:"s"`
+	By      string `json:"by"`
+	Expires int64  `json:"exp"` // unix seconds
+}
+
+// signLink makes a share token from claims.
+func signLink(key []byte, c linkClaims) string {
+	payload, _ := json.Marshal(c)
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + base64.RawURLEncoding.EncodeToString(linkMAC(key, body))
+}
+
+// verifyLink checks a share token's signature and expiry, returning its
+// claims.
+func verifyLink(key []byte, token string, now time.Time) (linkClaims, error) {
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return linkClaims{}, errors.New("malformed link")
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, linkMAC(key, body)) {
+		return linkClaims{}, errors.New("bad link signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return linkClaims{}, errors.New("malformed link")
+	}
+	var c linkClaims
+	if err := json.Unmarshal(payload, &c); err != nil || c.Session == "" {
+		return linkClaims{}, errors.New("malformed link")
+	}
+	if now.Unix() >= c.Expires {
+		return linkClaims{}, errors.New("link expired")
+	}
+	return c, nil
+}
+
+func linkMAC(key []byte, body string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("claude-host link\n" + body))
+	return mac.Sum(nil)
+}
+
+// linkAllows reports whether a share link may make request r: it can list
+// its session, read its screen and attach to it, but change nothing.
+func linkAllows(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	switch path := r.URL.Path; {
+	case path == "/api/sessions", path == "/api/me", path == "/ws/events":
+		return true
+	case strings.HasPrefix(path, "/ws/sessions/"):
+		return true
+	case strings.HasPrefix(path, "/api/sessions/"):
+		return strings.HasSuffix(path, "/snapshot") || strings.HasSuffix(path, "/scrollback") || strings.HasSuffix(path, "/conversation")
+	}
+	return false
+}
+
+// linkAccount is who a request with a valid share token acts as.
+func linkAccount(c linkClaims) account {
+	return account{name: "link:" + c.By, link: c.Session}
+}
+
+// shareSession signs a link to the session, good for the requested ttl or
+// an hour.
+func (s *server) shareSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	var req ShareRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	}
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	switch {
+	case ttl == 0:
+		ttl = defaultLinkTTL
+	case ttl < 0 || ttl > maxLinkTTL:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("ttl must be between 1s and %s", shortDuration(maxLinkTTL)))
+		return
+	}
+	expires := time.Now().Add(ttl)
+	token := signLink(s.linkKey, linkClaims{Session: sess.Name, By: requestUser(r).name, Expires: expires.Unix()})
+	writeJSON(w, http.StatusCreated, ShareLink{Token: token, ExpiresAt: expires.UTC().Format(time.RFC3339)})
+}
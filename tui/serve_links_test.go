@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testLinkKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestVerifyLink(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	claims := linkClaims{Session: "auth", By: "alice", Expires: now.Add(time.Hour).Unix()}
+	token := signLink(testLinkKey, claims)
+	body, sig, _ := strings.Cut(token, ".")
+	// The same signature over claims to another session.
+	payload, _ := json.Marshal(linkClaims{Session: "prod", By: "alice", Expires: claims.Expires})
+	forged := base64.RawURLEncoding.EncodeToString(payload)
+
+	tests := []struct {
+		name  string
+		key   []byte
+		token string
+		now   time.Time
+		err   string
+	}{
+		{"valid", testLinkKey, token, now, ""},
+		{"just before expiry", testLinkKey, token, now.Add(time.Hour - time.Second), ""},
+		{"at expiry", testLinkKey, token, now.Add(time.Hour), "link expired"},
+		{"after expiry", testLinkKey, token, now.Add(2 * time.Hour), "link expired"},
+		{"another server's key", []byte("some other key"), token, now, "bad link signature"},
+		{"tampered payload", testLinkKey, forged + "." + sig, now, "bad link signature"},
+		{"tampered signature", testLinkKey, body + "." + sig[:len(sig)-2] + "AA", now, "bad link signature"},
+		{"signature not base64", testLinkKey, body + ".!!", now, "bad link signature"},
+		{"no signature", testLinkKey, body, now, "malformed link"},
+		{"empty", testLinkKey, "", now, "malformed link"},
+		{"no session", testLinkKey, signLink(testLinkKey, linkClaims{By: "alice", Expires: claims.Expires}), now, "malformed link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifyLink(tt.key, tt.token, tt.now)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("verifyLink: %v", err)
+			case tt.err == "" && got != claims:
+				t.Errorf("claims = %+v, want %+v", got, claims)
+			case tt.err != "" && (err == nil || err.Error() != tt.err):
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLinkAllows(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{"GET", "/api/sessions", true},
+		{"GET", "/api/me", true},
+		{"GET", "/ws/events", true},
+		{"GET", "/ws/sessions/auth", true},
+		{"GET", "/api/sessions/auth/snapshot", true},
+		{"GET", "/api/sessions/auth/scrollback", true},
+		{"GET", "/api/sessions/auth/conversation", true},
+		{"GET", "/api/sessions/auth/files", false},
+		{"GET", "/api/sessions/auth/events", false},
+		{"GET", "/api/events", false},
+		{"GET", "/api/webhooks", false},
+		{"POST", "/api/sessions", false},
+		{"POST", "/api/sessions/auth/input", false},
+		{"POST", "/api/sessions/auth/kill", false},
+		{"POST", "/api/sessions/auth/share", false},
+		{"POST", "/api/sessions/auth/snapshot", false},
+		{"PATCH", "/api/sessions/auth", false},
+		{"DELETE", "/api/sessions/auth", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := linkAllows(r); got != tt.want {
+			t.Errorf("%s %s: linkAllows = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLinkAuthenticate(t *testing.T) {
+	s := &server{accounts: map[string]account{"admin-token": {name: "alice", admin: true}}, linkKey: testLinkKey}
+	var user account
+	h := s.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = requestUser(r)
+	}))
+	do := func(method, path, token string) int {
+		user = account{}
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	link := signLink(testLinkKey, linkClaims{Session: "auth", By: "alice", Expires: time.Now().Add(time.Hour).Unix()})
+
+	if code := do("GET", "/api/sessions/auth/snapshot", link); code != http.StatusOK {
+		t.Fatalf("GET snapshot with a link: %d", code)
+	}
+	if user.admin || !user.canAccess(Session{Name: "auth", Owner: "alice"}) {
+		t.Errorf("a link for auth acts as %+v", user)
+	}
+	if user.canAccess(Session{Name: "prod", Owner: "alice"}) {
+		t.Error("a link for auth can reach another of its sharer's sessions")
+	}
+	if user.lists(Session{Name: "prod", Owner: "alice"}) {
+		t.Error("a link for auth lists another session")
+	}
+
+	for _, r := range []struct{ method, path string }{
+		{"POST", "/api/sessions/auth/input"},
+		{"POST", "/api/sessions/auth/share"},
+		{"DELETE", "/api/sessions/auth"},
+		{"PATCH", "/api/sessions/auth/metadata"},
+	} {
+		if code := do(r.method, r.path, link); code != http.StatusForbidden {
+			t.Errorf("%s %s with a link: %d, want 403", r.method, r.path, code)
+		}
+	}
+
+	expired := signLink(testLinkKey, linkClaims{Session: "auth", By: "alice", Expires: time.Now().Add(-time.Second).Unix()})
+	if code := do("GET", "/api/sessions/auth/snapshot", expired); code != http.StatusUnauthorized {
+		t.Errorf("GET snapshot with an expired link: %d, want 401", code)
+	}
+	if code := do("GET", "/api/sessions/auth/snapshot", "admin-token"); code != http.StatusOK || !user.admin {
+		t.Errorf("GET snapshot with the admin's token: %d as %+v", code, user)
+	}
+}
+
+func TestLinkHearsEvents(t *testing.T) {
+	link := linkAccount(linkClaims{Session: "auth", By: "alice"})
+	tests := []struct {
+		ev   SessionEvent
+		want bool
+	}{
+		{SessionEvent{Type: "exited", Name: "auth", Owner: "alice"}, true},
+		{SessionEvent{Type: "viewers", Name: "auth", Owner: "alice", Viewers: 2}, true},
+		{SessionEvent{Type: "renamed", Name: "login", From: "auth", Owner: "alice"}, true},
+		{SessionEvent{Type: "exited", Name: "prod", Owner: "alice"}, false},
+		{SessionEvent{Type: "renamed", Name: "auth2", From: "prod", Owner: "alice"}, false},
+	}
+	for _, tt := range tests {
+		if got := link.hears(tt.ev); got != tt.want {
+			t.Errorf("link for auth hears %+v = %v, want %v", tt.ev, got, tt.want)
+		}
+	}
+	owner := account{name: "alice"}
+	if !owner.hears(SessionEvent{Type: "exited", Name: "prod", Owner: "alice"}) {
+		t.Error("an owner doesn't hear of their own session")
+	}
+	if owner.hears(SessionEvent{Type: "exited", Name: "prod", Owner: "bob"}) {
+		t.Error("a user hears of someone else's session")
+	}
+}
+
+func TestLinkListsNoEnv(t *testing.T) {
+	store := &sessionStore{sessions: []*Session{
+		{Name: "auth", Owner: "alice", Env: map[string]string{"ANTHROPIC_API_KEY": "sk-secret"}},
+		{Name: "prod", Owner: "alice"},
+	}}
+	s := newServer(map[string]account{"admin-token": {name: "alice", admin: true}}, store, nil, testLinkKey)
+	h := s.handler()
+	list := func(token string) []Session {
+		r := httptest.NewRequest("GET", "/api/sessions?all=1", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		var out []Session
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatalf("%d %s: %v", w.Code, w.Body, err)
+		}
+		return out
+	}
+
+	link := signLink(testLinkKey, linkClaims{Session: "auth", By: "alice", Expires: time.Now().Add(time.Hour).Unix()})
+	got := list(link)
+	if len(got) != 1 || got[0].Name != "auth" {
+		t.Fatalf("a link for auth lists %+v", got)
+	}
+	if got[0].Env != nil {
+		t.Errorf("a link sees the session's env: %v", got[0].Env)
+	}
+	if got := list("admin-token"); len(got) != 2 || got[0].Env["ANTHROPIC_API_KEY"] != "sk-secret" {
+		t.Errorf("the owner lists %+v", got)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// summarizeBuckets are the upper bounds, in seconds, of the summarize
+// latency histogram. claude -p usually takes a few seconds.
+var summarizeBuckets = []float64{0.5, 1, 2, 5, 10, 20, 30, 60}
+
+// metrics counts what the embedded server does, for /metrics in the
+// Prometheus text format.
+type metrics struct {
+	mu       sync.Mutex
+	bytesOut map[string]uint64 // session -> terminal output streamed to attach clients
+	bytesIn  map[string]uint64 // session -> input received from them
+	clients  map[string]int    // WebSocket kind -> connected clients
+
+	summarizeCounts []uint64 // per bucket, plus +Inf last
+	summarizeSum    float64
+	summarizeErrors uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		bytesOut:        map[string]uint64{},
+		bytesIn:         map[string]uint64{},
+		clients:         map[string]int{"attach": 0, "events": 0},
+		summarizeCounts: make([]uint64, len(summarizeBuckets)+1),
+	}
+}
+
+func (mt *metrics) streamed(name string, out, in int) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.bytesOut[name] += uint64(out)
+	mt.bytesIn[name] += uint64(in)
+}
+
+// connected counts a WebSocket client of kind in, returning the func that
+// counts it out.
+func (mt *metrics) connected(kind string) func() {
+	mt.mu.Lock()
+	mt.clients[kind]++
+	mt.mu.Unlock()
+	return func() {
+		mt.mu.Lock()
+		mt.clients[kind]--
+		mt.mu.Unlock()
+	}
+}
+
+func (mt *metrics) summarized(d time.Duration, err error) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	if err != nil {
+		mt.summarizeErrors++
+		return
+	}
+	secs := d.Seconds()
+	i, _ := slices.BinarySearch(summarizeBuckets, secs)
+	mt.summarizeCounts[i]++
+	mt.summarizeSum += secs
+}
+
+// rename carries a session's counters over to its new name.
+func (mt *metrics) rename(name, newName string) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	for _, m := range []map[string]uint64{mt.bytesOut, mt.bytesIn} {
+		if v, ok := m[name]; ok {
+			m[newName] += v
+			delete(m, name)
+		}
+	}
+}
+
+// forget drops a deleted session's counters.
+func (mt *metrics) forget(name string) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	delete(mt.bytesOut, name)
+	delete(mt.bytesIn, name)
+}
+
+// write renders every metric, with session counts taken from sessions.
+func (mt *metrics) write(w io.Writer, sessions []Session) {
+	states := map[string]int{"running": 0, "exited": 0, "archived": 0}
+	for _, s := range sessions {
+		switch {
+		case s.Archived:
+			states["archived"]++
+		case s.Alive:
+			states["running"]++
+		default:
+			states["exited"]++
+		}
+	}
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	header := func(name, typ, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	header("claude_host_sessions", "gauge", "Sessions by state.")
+	for _, state := range []string{"running", "exited", "archived"} {
+		fmt.Fprintf(w, "claude_host_sessions{state=%q} %d\n", state, states[state])
+	}
+	header("claude_host_session_bytes_total", "counter", "Bytes streamed over attach WebSockets, by session and direction.")
+	for _, name := range slices.Sorted(maps.Keys(mt.bytesOut)) {
+		fmt.Fprintf(w, "claude_host_session_bytes_total{session=%q,direction=\"out\"} %d\n", name, mt.bytesOut[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(mt.bytesIn)) {
+		fmt.Fprintf(w, "claude_host_session_bytes_total{session=%q,direction=\"in\"} %d\n", name, mt.bytesIn[name])
+	}
+	header("claude_host_websocket_clients", "gauge", "Connected WebSocket clients, by kind.")
+	for _, kind := range slices.Sorted(maps.Keys(mt.clients)) {
+		fmt.Fprintf(w, "claude_host_websocket_clients{kind=%q} %d\n", kind, mt.clients[kind])
+	}
+	header("claude_host_summarize_duration_seconds", "histogram", "Time taken by successful summarize calls.")
+	var cum uint64
+	for i, le := range summarizeBuckets {
+		cum += mt.summarizeCounts[i]
+		fmt.Fprintf(w, "claude_host_summarize_duration_seconds_bucket{le=%q} %d\n", strconv.FormatFloat(le, 'g', -1, 64), cum)
+	}
+	cum += mt.summarizeCounts[len(summarizeBuckets)]
+	fmt.Fprintf(w, "claude_host_summarize_duration_seconds_bucket{le=\"+Inf\"} %d\n", cum)
+	fmt.Fprintf(w, "claude_host_summarize_duration_seconds_sum %g\n", mt.summarizeSum)
+	fmt.Fprintf(w, "claude_host_summarize_duration_seconds_count %d\n", cum)
+	header("claude_host_summarize_errors_total", "counter", "Summarize calls where claude failed or printed nothing.")
+	fmt.Fprintf(w, "claude_host_summarize_errors_total %d\n", mt.summarizeErrors)
+}
+
+// serveMetrics answers a Prometheus scrape. Session names are visible to
+// whoever can read it, so on a multi-user server only admins may.
+func (s *server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if !requestUser(r).admin {
+		writeError(w, http.StatusForbidden, "only admins can read metrics")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.write(w, s.sessions.list(true, true))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Idle actions for [serve] idle_action.
+const (
+	idleKill    = "kill"    // stop the process, keeping the record and transcript
+	idleArchive = "archive" // and hide it from the session list
+)
+
+var errSessionLimit = errors.New("session limit reached")
+
+// policy is what the embedded server enforces on its sessions, from [serve]
+// and the serve flags.
+type policy struct {
+	idle       time.Duration // reap sessions with no output for this long, 0 for never
+	idleAction string
+	max        int // running sessions allowed at once, 0 for no cap
+}
+
+func newPolicy(cfg ServeConfig) policy {
+	p := policy{
+		idle:       time.Duration(cfg.IdleHours * float64(time.Hour)),
+		idleAction: cfg.IdleAction,
+		max:        cfg.MaxSessions,
+	}
+	if p.idleAction == "" {
+		p.idleAction = idleKill
+	}
+	return p
+}
+
+// checkLimit refuses a new running session when the cap is reached.
+// Callers hold st.mu.
+func (st *sessionStore) checkLimit() error {
+	if st.policy.max <= 0 {
+		return nil
+	}
+	running := 0
+	for _, s := range st.sessions {
+		if s.Alive {
+			running++
+		}
+	}
+	if running >= st.policy.max {
+		return fmt.Errorf("%w: %d of %d running", errSessionLimit, running, st.policy.max)
+	}
+	return nil
+}
+
+// reap stops sessions that have printed nothing for the idle period. A
+// session someone is attached to is never idle.
+func (st *sessionStore) reap(now time.Time) {
+	if st.policy.idle <= 0 {
+		return
+	}
+	activity := tmuxActivity()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, s := range st.sessions {
+		last, ok := activity[s.Name]
+		if !s.Alive || !ok || now.Sub(last) < st.policy.idle {
+			continue
+		}
+		log.Printf("%s: no output for %s; reaping (%s)", s.Name, shortDuration(now.Sub(last)), st.policy.idleAction)
+		if err := st.stop(s); err != nil {
+			log.Printf("reaping %s: %v", s.Name, err)
+			continue
+		}
+		s.Archived = st.policy.idleAction == idleArchive
+		st.reaped++
+		st.save(s)
+		if s.Archived {
+			st.publish(SessionEvent{Type: "archived", Name: s.Name, Owner: s.Owner})
+		} else {
+			st.publish(SessionEvent{Type: "exited", Name: s.Name, Owner: s.Owner})
+		}
+	}
+}
+
+// policies reports the store's policies and standing.
+func (st *sessionStore) policies() Policies {
+	activity := tmuxActivity()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	p := Policies{
+		IdleHours:   st.policy.idle.Hours(),
+		IdleAction:  st.policy.idleAction,
+		MaxSessions: st.policy.max,
+		Reaped:      st.reaped,
+	}
+	var next time.Time
+	for _, s := range st.sessions {
+		if !s.Alive {
+			continue
+		}
+		p.Running++
+		last, ok := activity[s.Name]
+		if st.policy.idle <= 0 || !ok {
+			continue
+		}
+		if at := last.Add(st.policy.idle); p.NextReap == nil || at.Before(next) {
+			next = at
+			p.NextReap = &PolicyReap{Name: s.Name, At: at.UTC().Format(time.RFC3339)}
+		}
+	}
+	return p
+}
+
+func (s *server) policies(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.sessions.policies())
+}
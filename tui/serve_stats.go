@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resource usage comes from /proc, summed over the session's process and
+// everything under it, so it only works on Linux.
+
+// clockTicks is the kernel's USER_HZ, the unit of /proc CPU times. It is
+// 100 on every Linux platform Go supports.
+const clockTicks = 100
+
+// statsWindow is how long the first stats request for a session watches
+// its CPU time, when there is no earlier sample to compare with.
+const statsWindow = 250 * time.Millisecond
+
+var errNoProc = errors.New("process stats need /proc, which this server doesn't have")
+
+// procSampler keeps each session's last CPU sample, so CPU use is measured
+// between one stats request and the next.
+type procSampler struct {
+	mu   sync.Mutex
+	last map[string]cpuSample
+}
+
+type cpuSample struct {
+	at    time.Time
+	ticks int64
+}
+
+func newProcSampler() *procSampler {
+	return &procSampler{last: map[string]cpuSample{}}
+}
+
+// proc is one process's line in /proc/<pid>/stat.
+type proc struct {
+	ppid  int
+	ticks int64 // user plus system CPU time
+	rss   int64 // resident pages
+}
+
+// readProcs reads every process on the machine.
+func readProcs() (map[int]proc, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, errNoProc
+	}
+	procs := map[int]proc{}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue // exited since the listing
+		}
+		// The command name is in parentheses and may itself contain
+		// spaces or parentheses, so fields are counted from the last ')'.
+		i := strings.LastIndexByte(string(data), ')')
+		if i < 0 {
+			continue
+		}
+		f := strings.Fields(string(data[i+1:]))
+		if len(f) < 22 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(f[1])
+		utime, _ := strconv.ParseInt(f[11], 10, 64)
+		stime, _ := strconv.ParseInt(f[12], 10, 64)
+		rss, _ := strconv.ParseInt(f[21], 10, 64)
+		procs[pid] = proc{ppid: ppid, ticks: utime + stime, rss: rss}
+	}
+	return procs, nil
+}
+
+// tree totals the CPU time and memory of root and its descendants, and
+// counts the descendants.
+func tree(procs map[int]proc, root int) (ticks, rss int64, children int) {
+	kids := map[int][]int{}
+	for pid, p := range procs {
+		kids[p.ppid] = append(kids[p.ppid], pid)
+	}
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		p, ok := procs[pid]
+		if !ok {
+			continue
+		}
+		ticks += p.ticks
+		rss += p.rss
+		if pid != root {
+			children++
+		}
+		queue = append(queue, kids[pid]...)
+	}
+	return ticks, rss, children
+}
+
+// sample measures the session whose process is pid.
+func (ps *procSampler) sample(name string, pid int) (SessionStats, error) {
+	procs, err := readProcs()
+	if err != nil {
+		return SessionStats{}, err
+	}
+	now := time.Now()
+	ticks, rss, children := tree(procs, pid)
+	ps.mu.Lock()
+	prev, ok := ps.last[name]
+	ps.mu.Unlock()
+	if !ok || prev.ticks > ticks {
+		// Nothing to compare with: watch for a moment instead.
+		prev = cpuSample{at: now, ticks: ticks}
+		time.Sleep(statsWindow)
+		if procs, err = readProcs(); err != nil {
+			return SessionStats{}, err
+		}
+		now = time.Now()
+		ticks, rss, children = tree(procs, pid)
+	}
+	ps.mu.Lock()
+	ps.last[name] = cpuSample{at: now, ticks: ticks}
+	ps.mu.Unlock()
+	st := SessionStats{RSS: rss * int64(os.Getpagesize()), Processes: children}
+	if elapsed := now.Sub(prev.at).Seconds(); elapsed > 0 {
+		st.CPU = float64(ticks-prev.ticks) / clockTicks / elapsed * 100
+	}
+	return st, nil
+}
+
+// rename and forget keep samples in step with the sessions.
+func (ps *procSampler) rename(name, newName string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if s, ok := ps.last[name]; ok {
+		ps.last[newName] = s
+		delete(ps.last, name)
+	}
+}
+
+func (ps *procSampler) forget(name string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	delete(ps.last, name)
+}
+
+func (s *server) stats(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	pid := tmuxPanePID(sess.Name)
+	if !sess.Alive || pid == 0 {
+		writeError(w, http.StatusConflict, "session is not running")
+		return
+	}
+	st, err := s.procs.sample(sess.Name, pid)
+	if err != nil {
+		writeError(w, http.StatusNotImplemented, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// The server follows what each Claude session is doing from its screen,
+// which shows a spinner with "esc to interrupt" while Claude works and a
+// numbered menu while it asks permission, and from its conversation log,
+// which says whether the last thing Claude did was call a tool. The screen
+// alone can't tell a tool call from thinking, and the log lags the screen
+// and knows nothing of prompts, so each fills in for the other.
+
+// Session statuses, as Session.Status reports them.
+const (
+	statusThinking   = "thinking"   // working on a reply
+	statusTool       = "tool"       // running a tool call
+	statusPermission = "permission" // asking to use a tool
+	statusInput      = "input"      // waiting for the next prompt
+	statusDone       = "done"       // Claude has exited
+)
+
+// The last thing a conversation log records, for the status.
+const (
+	logPrompt     = "prompt"      // the user's prompt
+	logText       = "text"        // part of Claude's reply
+	logToolUse    = "tool_use"    // Claude called a tool
+	logToolResult = "tool_result" // and the tool answered
+)
+
+// busyMarker is on Claude's spinner line for as long as it works.
+const busyMarker = "esc to interrupt"
+
+// statusTailLines is how much of the bottom of the screen is read, so
+// what has scrolled up doesn't count.
+const statusTailLines = 15
+
+// quietPolls is how many refreshes in a row the spinner must be gone
+// before a busy session counts as waiting for input. It blinks out
+// between one step and the next.
+const quietPolls = 2
+
+// shells are the pane commands that mean Claude isn't in the foreground:
+// the sh its command runs under, or a shell the command started.
+var shells = map[string]bool{"bash": true, "zsh": true, "sh": true, "dash": true, "fish": true, "ksh": true}
+
+var permissionPrompt = func() []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, p := range defaultApprovePatterns {
+		res = append(res, regexp.MustCompile(p))
+	}
+	return res
+}()
+
+// claudeState is where a session's status stands.
+type claudeState struct {
+	status string
+	quiet  int // refreshes in a row without the spinner while busy
+}
+
+// next moves the status on given the screen, the conversation log's last
+// event and the pane's foreground command.
+func (c *claudeState) next(screen, last, command string) string {
+	lines := strings.Split(strings.TrimRight(screen, "\n "), "\n")
+	tail := strings.Join(lines[max(0, len(lines)-statusTailLines):], "\n")
+	busy := c.status == statusThinking || c.status == statusTool
+	switch {
+	case shells[command]:
+		// Before Claude starts, the sh that runs its command does.
+		if c.status != "" {
+			c.status = statusDone
+		}
+	case matchesAny(permissionPrompt, tail):
+		c.status = statusPermission
+	case strings.Contains(tail, busyMarker):
+		c.quiet = 0
+		c.status = statusThinking
+		if last == logToolUse {
+			c.status = statusTool
+		}
+	case busy && c.quiet+1 < quietPolls:
+		c.quiet++
+	default:
+		c.quiet = 0
+		c.status = statusInput
+	}
+	return c.status
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusTracker keeps the status of every running Claude session.
+type statusTracker struct {
+	mu     sync.Mutex
+	states map[string]*claudeState
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{states: map[string]*claudeState{}}
+}
+
+// update moves on the status of every Claude session in sessions, which
+// should be all of them, as for claudeLogs.usage.
+func (t *statusTracker) update(sessions []Session, logs *claudeLogs) {
+	commands := tmuxPaneCommands()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	seen := map[string]bool{}
+	for _, sess := range sessions {
+		if !sess.Alive || !isClaude(sess.Command) {
+			continue
+		}
+		screen, err := tmuxCapture(sess.Name, 0)
+		if err != nil {
+			continue
+		}
+		seen[sess.Name] = true
+		st := t.states[sess.Name]
+		if st == nil {
+			st = &claudeState{}
+			t.states[sess.Name] = st
+		}
+		st.next(screen, logs.last(sessions, sess.Name), commands[sess.Name])
+	}
+	for name := range t.states {
+		if !seen[name] {
+			delete(t.states, name)
+		}
+	}
+}
+
+// status is the session's status: done once a Claude session has exited,
+// and "" for other commands or before it has been seen.
+func (t *statusTracker) status(sess Session) string {
+	if !isClaude(sess.Command) {
+		return ""
+	}
+	if !sess.Alive {
+		return statusDone
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if st := t.states[sess.Name]; st != nil {
+		return st.status
+	}
+	return ""
+}
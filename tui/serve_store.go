@@ -0,0 +1,426 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	errNoSession     = errors.New("no such session")
+	errSessionExists = errors.New("a session with that name already exists")
+	errNotRunning    = errors.New("session is not running")
+)
+
+// sessionStore is the embedded server's record of its sessions, in
+// creation order. Liveness comes from tmux; everything else lives here and
+// in the database.
+type sessionStore struct {
+	mu       sync.Mutex
+	db       *sessionDB
+	sessions []*Session
+	subs     map[chan SessionEvent]bool
+	saved    map[string]uint64 // hash of each session's last stored transcript
+	policy   policy
+	reaped   int // sessions reaped for idling since the server started
+}
+
+// newSessionStore loads the stored sessions, re-adopting those whose tmux
+// session survived the server going down.
+func newSessionStore(db *sessionDB, p policy) (*sessionStore, error) {
+	sessions, err := db.sessions()
+	if err != nil {
+		return nil, err
+	}
+	running := tmuxSessions()
+	for _, s := range sessions {
+		s.Alive = running[s.Name]
+		if s.Owner == "" {
+			s.Owner = localUser
+		}
+	}
+	st := &sessionStore{db: db, sessions: sessions, subs: map[chan SessionEvent]bool{}, saved: map[string]uint64{}, policy: p}
+	return st, nil
+}
+
+// save writes a session's record to the database. Failures are logged
+// rather than failing the request: the session itself is fine.
+func (st *sessionStore) save(s *Session) {
+	if err := st.db.put(*s); err != nil {
+		log.Printf("saving %s: %v", s.Name, err)
+	}
+}
+
+func (st *sessionStore) find(name string) (int, *Session) {
+	i := slices.IndexFunc(st.sessions, func(s *Session) bool { return s.Name == name })
+	if i < 0 {
+		return -1, nil
+	}
+	return i, st.sessions[i]
+}
+
+// list returns copies of the sessions, with exited ones only if all is set
+// and archived ones only if archived is.
+func (st *sessionStore) list(all, archived bool) []Session {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	out := []Session{}
+	for _, s := range st.sessions {
+		if (s.Alive || all) && (!s.Archived || archived) {
+			out = append(out, *s)
+		}
+	}
+	return out
+}
+
+func (st *sessionStore) get(name string) (Session, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, s := st.find(name); s != nil {
+		return *s, nil
+	}
+	return Session{}, errNoSession
+}
+
+// create starts a session for owner under a fresh name.
+func (st *sessionStore) create(opts CreateOptions, owner string) (Session, error) {
+	if opts.Command == "" {
+		opts.Command = "claude"
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if err := st.checkLimit(); err != nil {
+		return Session{}, err
+	}
+	name := st.uniqueName()
+	if err := tmuxNewSession(name, opts.Cwd, opts.Env, opts.Command); err != nil {
+		return Session{}, err
+	}
+	s := &Session{
+		Name:        name,
+		CreatedAt:   time.Now().UTC().Format("2006-01-02 15:04:05"),
+		Description: opts.Description,
+		Command:     opts.Command,
+		Alive:       true,
+		Cwd:         opts.Cwd,
+		Env:         opts.Env,
+		Project:     opts.Project,
+		Owner:       owner,
+	}
+	st.sessions = append(st.sessions, s)
+	st.save(s)
+	st.publish(SessionEvent{Type: "created", Name: name, Owner: s.Owner})
+	return *s, nil
+}
+
+// uniqueName picks an adjective-noun name not in use here or in tmux.
+func (st *sessionStore) uniqueName() string {
+	running := tmuxSessions()
+	for n := 0; ; n++ {
+		name := nameAdjectives[rand.N(len(nameAdjectives))] + "-" + nameNouns[rand.N(len(nameNouns))]
+		if n >= 20 {
+			name += fmt.Sprintf("-%d", rand.N(1000))
+		}
+		_, s := st.find(name)
+		if _, taken := running[name]; s == nil && !taken {
+			return name
+		}
+	}
+}
+
+func (st *sessionStore) delete(name string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	i, s := st.find(name)
+	if s == nil {
+		return errNoSession
+	}
+	if tmuxHasSession(name) {
+		if err := tmuxKill(name); err != nil {
+			return err
+		}
+	}
+	st.sessions = slices.Delete(st.sessions, i, i+1)
+	delete(st.saved, name)
+	if err := st.db.delete(name); err != nil {
+		log.Printf("deleting %s: %v", name, err)
+	}
+	st.publish(SessionEvent{Type: "deleted", Name: name, Owner: s.Owner})
+	return nil
+}
+
+func (st *sessionStore) rename(name, newName string) error {
+	if !validSessionName.MatchString(newName) {
+		return fmt.Errorf("invalid name %q: use letters, digits, - and _", newName)
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	_, s := st.find(name)
+	if s == nil {
+		return errNoSession
+	}
+	if _, other := st.find(newName); other != nil || tmuxHasSession(newName) {
+		return errSessionExists
+	}
+	if s.Alive {
+		if err := tmuxRename(name, newName); err != nil {
+			return err
+		}
+	}
+	s.Name = newName
+	if h, ok := st.saved[name]; ok {
+		st.saved[newName] = h
+		delete(st.saved, name)
+	}
+	if err := st.db.rename(name, *s); err != nil {
+		log.Printf("renaming %s: %v", name, err)
+	}
+	st.publish(SessionEvent{Type: "renamed", Name: newName, From: name, Owner: s.Owner})
+	return nil
+}
+
+// update applies fn to a session's record.
+func (st *sessionStore) update(name string, fn func(*Session)) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	_, s := st.find(name)
+	if s == nil {
+		return errNoSession
+	}
+	fn(s)
+	st.save(s)
+	st.publish(SessionEvent{Type: "updated", Name: name, Owner: s.Owner})
+	return nil
+}
+
+// kill stops a session's process, keeping its record and transcript.
+func (st *sessionStore) kill(name string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	_, s := st.find(name)
+	if s == nil {
+		return errNoSession
+	}
+	if !s.Alive {
+		return errNotRunning
+	}
+	if err := st.stop(s); err != nil {
+		return err
+	}
+	st.save(s)
+	st.publish(SessionEvent{Type: "exited", Name: name, Owner: s.Owner})
+	return nil
+}
+
+// archive hides a session from the list, stopping it first if it is
+// running, or with on false brings it back, exited.
+func (st *sessionStore) archive(name string, on bool) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	_, s := st.find(name)
+	if s == nil {
+		return errNoSession
+	}
+	if on && s.Alive {
+		if err := st.stop(s); err != nil {
+			return err
+		}
+	}
+	s.Archived = on
+	st.save(s)
+	if on {
+		st.publish(SessionEvent{Type: "archived", Name: name, Owner: s.Owner})
+	} else {
+		st.publish(SessionEvent{Type: "updated", Name: name, Owner: s.Owner})
+	}
+	return nil
+}
+
+// stop kills a running session's tmux session, storing its output first.
+// Callers hold st.mu.
+func (st *sessionStore) stop(s *Session) error {
+	st.storeTranscript(s.Name)
+	if err := tmuxKill(s.Name); err != nil {
+		return err
+	}
+	s.Alive = false
+	return nil
+}
+
+// restart relaunches a session's command, in its old tmux session if that
+// is still there, killing it first if it is running. The scrollback is kept
+// either way.
+func (st *sessionStore) restart(name string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	_, s := st.find(name)
+	if s == nil {
+		return errNoSession
+	}
+	if !s.Alive {
+		if err := st.checkLimit(); err != nil {
+			return err
+		}
+	}
+	var err error
+	if tmuxHasSession(name) {
+		st.storeTranscript(name)
+		err = tmuxRespawn(name, s.Command)
+	} else {
+		err = tmuxNewSession(name, s.Cwd, s.Env, s.Command)
+	}
+	if err != nil {
+		return err
+	}
+	s.Alive, s.Archived, s.ExitStatus = true, false, nil
+	st.save(s)
+	st.publish(SessionEvent{Type: "updated", Name: name, Owner: s.Owner})
+	return nil
+}
+
+// refresh syncs liveness and working directories with tmux. A session
+// whose process has exited has its final output stored before its tmux
+// session is cleaned up.
+func (st *sessionStore) refresh() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	// Under the lock, so a session created meanwhile isn't taken for dead.
+	running, cwds := tmuxSessions(), tmuxPaneCwds()
+	for _, s := range st.sessions {
+		alive, exists := running[s.Name]
+		if alive {
+			if cwd := cwds[s.Name]; cwd != "" && cwd != s.Cwd {
+				s.Cwd = cwd
+				st.save(s)
+			}
+			continue
+		}
+		var status *int
+		if exists {
+			status = tmuxExitStatus(s.Name)
+			st.storeTranscript(s.Name)
+			tmuxKill(s.Name)
+		}
+		if s.Alive {
+			s.Alive, s.ExitStatus = false, status
+			st.save(s)
+			st.publish(SessionEvent{Type: "exited", Name: s.Name, Owner: s.Owner})
+		}
+	}
+}
+
+// saveTranscripts stores the output of every running session that has
+// changed since it was last stored.
+func (st *sessionStore) saveTranscripts() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, s := range st.sessions {
+		if s.Alive {
+			st.storeTranscript(s.Name)
+		}
+	}
+}
+
+// storeTranscript captures a session's scrollback into the database.
+// Callers hold st.mu.
+func (st *sessionStore) storeTranscript(name string) {
+	text, err := tmuxCapture(name, tmuxHistory)
+	if err != nil {
+		return
+	}
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	if sum := h.Sum64(); st.saved[name] != sum {
+		if err := st.db.putTranscript(name, text); err != nil {
+			log.Printf("saving transcript of %s: %v", name, err)
+			return
+		}
+		st.saved[name] = sum
+	}
+}
+
+// transcript is a session's last stored output, for once it has exited.
+func (st *sessionStore) transcript(name string) string {
+	return st.db.transcript(name)
+}
+
+// exitStatus reports whether a session's process has exited, with its exit
+// status if that's known. A session that is gone altogether has exited too.
+func (st *sessionStore) exitStatus(name string) (status *int, exited bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	_, s := st.find(name)
+	if s == nil {
+		return nil, true
+	}
+	return s.ExitStatus, !s.Alive
+}
+
+// viewersChanged announces how many clients are now attached to a session.
+func (st *sessionStore) viewersChanged(name string, n int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, s := st.find(name); s != nil {
+		st.publish(SessionEvent{Type: "viewers", Name: name, Owner: s.Owner, Viewers: n})
+	}
+}
+
+// subscribe returns a channel of session events, and a func to stop them.
+func (st *sessionStore) subscribe() (<-chan SessionEvent, func()) {
+	ch := make(chan SessionEvent, 64)
+	st.mu.Lock()
+	st.subs[ch] = true
+	st.mu.Unlock()
+	return ch, func() {
+		st.mu.Lock()
+		delete(st.subs, ch)
+		st.mu.Unlock()
+	}
+}
+
+// publish sends ev to every subscriber, dropping it for any that has
+// fallen behind. Callers hold st.mu.
+func (st *sessionStore) publish(ev SessionEvent) {
+	for ch := range st.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Session names are adjective-noun pairs, as the Node server generates.
+var nameAdjectives = []string{
+	"ancient", "bold", "calm", "daring", "eager",
+	"fierce", "gentle", "hidden", "idle", "jolly",
+	"keen", "lucid", "mellow", "noble", "odd",
+	"plucky", "quiet", "rustic", "swift", "terse",
+	"unruly", "vivid", "witty", "xenial", "young",
+	"zesty", "cosmic", "dusty", "feral", "grumpy",
+	"hazy", "icy", "jaunty", "kinetic", "lanky",
+	"mossy", "nifty", "ornery", "pesky", "quaint",
+	"roving", "stormy", "thorny", "upbeat", "vagrant",
+	"wiry", "yearly", "zealous", "brazen", "cryptic",
+	"dreamy", "elastic", "foggy", "gritty", "hollow",
+	"ironic", "jumpy", "knotty", "lunar", "murky",
+}
+
+var nameNouns = []string{
+	"anvil", "badger", "cipher", "dingo", "ember",
+	"falcon", "gopher", "heron", "ibex", "jackal",
+	"kettle", "lemur", "moose", "newt", "otter",
+	"parrot", "quokka", "raven", "stoat", "tundra",
+	"urchin", "vortex", "walrus", "xerus", "yak",
+	"zephyr", "cobalt", "dagger", "forge", "glacier",
+	"hutch", "ingot", "junco", "kraken", "lantern",
+	"mantis", "nebula", "osprey", "pebble", "quasar",
+	"riddle", "sphinx", "thorn", "umbra", "vessel",
+	"wombat", "yarrow", "zenith", "beacon", "condor",
+	"donkey", "ermine", "ferret", "goblet", "hermit",
+	"impala", "javelin", "koala", "marmot", "narwhal",
+}
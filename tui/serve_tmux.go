@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The embedded server keeps each session in its own tmux session, as the
+// Node server does, so sessions outlive the server process and snapshots
+// come straight from tmux's own screen.
+
+// tmuxHistory is the scrollback tmux keeps per session, and the most
+// /scrollback will return.
+const tmuxHistory = 50000
+
+func tmuxPath() string {
+	if p, err := exec.LookPath("tmux"); err == nil {
+		return p
+	}
+	return "tmux"
+}
+
+// tmux runs a tmux command, returning its stdout.
+func tmux(args ...string) (string, error) {
+	cmd := exec.Command(tmuxPath(), args...)
+	cmd.Env = tmuxEnv()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("tmux %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("tmux %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// tmuxEnv is the environment for tmux commands, without $TMUX so a server
+// started from inside tmux doesn't nest.
+func tmuxEnv() []string {
+	return slices.DeleteFunc(os.Environ(), func(kv string) bool { return strings.HasPrefix(kv, "TMUX=") })
+}
+
+// tmuxTarget addresses a session's current pane by exact session name; a
+// bare name would also match any session it is a prefix of.
+func tmuxTarget(name string) string { return "=" + name + ":" }
+
+func tmuxHasSession(name string) bool {
+	_, err := tmux("has-session", "-t", tmuxTarget(name))
+	return err == nil
+}
+
+// tmuxSessions maps the name of every tmux session to whether its process
+// is still running. Sessions are kept after their process exits, with
+// remain-on-exit, so the last of the output can still be captured.
+func tmuxSessions() map[string]bool {
+	out, err := tmux("list-panes", "-a", "-F", "#{pane_dead} #{session_name}")
+	if err != nil {
+		return map[string]bool{}
+	}
+	sessions := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if dead, name, ok := strings.Cut(line, " "); ok {
+			sessions[name] = dead != "1"
+		}
+	}
+	return sessions
+}
+
+// tmuxPaneCommands maps each session onto its pane's foreground command.
+func tmuxPaneCommands() map[string]string {
+	out, err := tmux("list-panes", "-a", "-F", "#{session_name} #{pane_current_command}")
+	if err != nil {
+		return map[string]string{}
+	}
+	commands := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if name, command, ok := strings.Cut(line, " "); ok {
+			commands[name] = command
+		}
+	}
+	return commands
+}
+
+// tmuxPaneCwds maps each session onto its foreground process's working
+// directory.
+func tmuxPaneCwds() map[string]string {
+	out, err := tmux("list-panes", "-a", "-F", "#{session_name} #{pane_current_path}")
+	if err != nil {
+		return map[string]string{}
+	}
+	cwds := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if name, cwd, ok := strings.Cut(line, " "); ok && cwd != "" {
+			cwds[name] = cwd
+		}
+	}
+	return cwds
+}
+
+// tmuxActivity maps each session nobody is attached to onto when its pane
+// last printed anything.
+func tmuxActivity() map[string]time.Time {
+	out, err := tmux("list-panes", "-a", "-F", "#{session_attached} #{window_activity} #{session_name}")
+	if err != nil {
+		return map[string]time.Time{}
+	}
+	activity := map[string]time.Time{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 3 || fields[0] != "0" {
+			continue
+		}
+		if secs, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			activity[fields[2]] = time.Unix(secs, 0)
+		}
+	}
+	return activity
+}
+
+// tmuxNewSession starts command in a detached session as the pane's own
+// process, run by sh, so the pane dies when it exits and its exit status
+// is the command's. remain-on-exit is set in the same tmux invocation,
+// before tmux can notice a command that exits at once, and keeps the dead
+// pane for refresh to capture.
+func tmuxNewSession(name, cwd string, env map[string]string, command string) error {
+	args := []string{"new-session", "-d", "-s", name, "-x", "200", "-y", "50"}
+	if cwd != "" {
+		args = append(args, "-c", expandHome(cwd))
+	}
+	for k, v := range env {
+		args = append(args, "-e", k+"="+v)
+	}
+	args = append(args, "sh", "-c", command, ";",
+		"set-option", "-w", "-t", tmuxTarget(name), "remain-on-exit", "on")
+	if _, err := tmux(args...); err != nil {
+		return err
+	}
+	for _, opt := range [][]string{
+		{"status", "off"},
+		{"mouse", "on"},
+		{"history-limit", strconv.Itoa(tmuxHistory)},
+		{"copy-mode-exit-on-bottom", "on"},
+	} {
+		tmux("set-option", "-t", tmuxTarget(name), opt[0], opt[1])
+	}
+	tmux("set-option", "-s", "set-clipboard", "on")
+	return nil
+}
+
+// expandHome resolves a leading ~ in path to the server's home directory,
+// which tmux won't do itself.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || (rest != "" && rest[0] != '/') {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + rest
+}
+
+func tmuxKill(name string) error {
+	_, err := tmux("kill-session", "-t", tmuxTarget(name))
+	return err
+}
+
+func tmuxRename(name, newName string) error {
+	_, err := tmux("rename-session", "-t", tmuxTarget(name), newName)
+	return err
+}
+
+// tmuxRespawn runs command afresh in the session's pane, killing what
+// is still running there.
+func tmuxRespawn(name, command string) error {
+	_, err := tmux("respawn-pane", "-k", "-t", tmuxTarget(name), "sh", "-c", command)
+	return err
+}
+
+// tmuxSendKeys types data into the session literally.
+func tmuxSendKeys(name, data string) error {
+	_, err := tmux("send-keys", "-t", tmuxTarget(name), "-l", data)
+	return err
+}
+
+// tmuxCapture returns the visible screen plus up to lines of history.
+func tmuxCapture(name string, lines int) (string, error) {
+	return tmux("capture-pane", "-t", tmuxTarget(name), "-p", "-S", "-"+strconv.Itoa(lines))
+}
+
+// tmuxExitStatus is how the session's process exited, once its pane is
+// dead: its exit code, or 128 plus the signal that killed it, as a shell
+// reports it. nil if tmux can't say.
+func tmuxExitStatus(name string) *int {
+	out, err := tmux("display-message", "-t", tmuxTarget(name), "-p", "#{pane_dead_status} #{pane_dead_signal}")
+	if err != nil {
+		return nil
+	}
+	code, sig, _ := strings.Cut(strings.TrimSpace(out), " ")
+	if n, err := strconv.Atoi(sig); err == nil && n > 0 {
+		n += 128
+		return &n
+	}
+	if n, err := strconv.Atoi(code); err == nil {
+		return &n
+	}
+	return nil
+}
+
+// tmuxRefreshClient redraws the whole screen of the tmux client with
+// process ID pid.
+func tmuxRefreshClient(pid int) error {
+	out, err := tmux("list-clients", "-F", "#{client_pid} #{client_name}")
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if p, name, ok := strings.Cut(line, " "); ok && p == strconv.Itoa(pid) {
+			_, err := tmux("refresh-client", "-t", name)
+			return err
+		}
+	}
+	return fmt.Errorf("no tmux client with pid %d", pid)
+}
+
+// tmuxPanePID is the process ID of the session's command, or 0 if its
+// pane has none.
+func tmuxPanePID(name string) int {
+	out, err := tmux("display-message", "-t", tmuxTarget(name), "-p", "#{?pane_dead,0,#{pane_pid}}")
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(out))
+	return pid
+}
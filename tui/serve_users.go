@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// localUser owns every session on a server without [serve.users], as on the
+// Node server.
+const localUser = "local"
+
+// account is who a request acts as. Users see and control only their own
+// sessions, except admins, who can reach any session by name and list
+// everyone's with ?users=all.
+type account struct {
+	name  string
+	admin bool
+	link  string // the one session a share link reaches, "" for a user
+}
+
+// canAccess reports whether u may see and act on sess.
+func (u account) canAccess(sess Session) bool {
+	if u.link != "" {
+		return sess.Name == u.link
+	}
+	return u.admin || sess.Owner == u.name
+}
+
+// hears reports whether u is told of ev: it's about a session u may
+// access, or one that was renamed away from such a name.
+func (u account) hears(ev SessionEvent) bool {
+	return u.canAccess(Session{Name: ev.Name, Owner: ev.Owner}) ||
+		ev.From != "" && u.canAccess(Session{Name: ev.From, Owner: ev.Owner})
+}
+
+// lists reports whether sess is in u's own session list.
+func (u account) lists(sess Session) bool {
+	if u.link != "" {
+		return sess.Name == u.link
+	}
+	return sess.Owner == u.name
+}
+
+// newAccounts maps each bearer token to its user: the [serve.users]
+// accounts if there are any, or else a single admin behind token.
+func newAccounts(token string, users map[string]ServeUser) map[string]account {
+	accounts := map[string]account{}
+	if len(users) == 0 {
+		if token != "" {
+			accounts[token] = account{name: localUser, admin: true}
+		}
+		return accounts
+	}
+	for name, u := range users {
+		accounts[u.Token] = account{name: name, admin: u.Admin}
+	}
+	return accounts
+}
+
+type userKey struct{}
+
+// requestUser is the account authenticate found for r.
+func requestUser(r *http.Request) account {
+	u, _ := r.Context().Value(userKey{}).(account)
+	return u
+}
+
+// authenticate resolves the bearer token to a user, or to a share link
+// limited to looking at and attaching to its session. With no accounts at
+// all, every client is the local admin.
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, ok := account{name: localUser, admin: true}, len(s.accounts) == 0
+		got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		for token, a := range s.accounts {
+			// Compare against every token so timing doesn't reveal which
+			// one nearly matched.
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+				u, ok = a, true
+			}
+		}
+		if !ok {
+			if c, err := verifyLink(s.linkKey, got, time.Now()); err == nil {
+				u, ok = linkAccount(c), true
+			}
+		}
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if u.link != "" && !linkAllows(r) {
+			writeError(w, http.StatusForbidden, "a share link can only attach to its session")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, u)))
+	})
+}
+
+func (s *server) whoami(w http.ResponseWriter, r *http.Request) {
+	u := requestUser(r)
+	writeJSON(w, http.StatusOK, Account{Name: u.name, Admin: u.admin})
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Webhook events. exited and crashed both mean the session's command has
+// exited; crashed is a non-zero exit status.
+const (
+	hookCreated = "created"
+	hookExited  = "exited"
+	hookCrashed = "crashed"
+	hookIdle    = "idle"
+	hookTest    = "test"
+)
+
+var webhookEvents = []string{hookCreated, hookExited, hookCrashed, hookIdle}
+
+// defaultHookIdle is how long a session must be quiet before an idle hook
+// fires, unless the hook says otherwise.
+const defaultHookIdle = 10 * time.Minute
+
+// webhookAttempts is how many times a delivery is tried before it is logged
+// as failed.
+const webhookAttempts = 3
+
+var errNoWebhook = errors.New("no such webhook")
+
+// webhookPayload is the JSON POSTed to a hook. Text makes it a valid Slack
+// incoming-webhook message as it stands.
+type webhookPayload struct {
+	Event   string  `json:"event"`
+	Session Session `json:"session"`
+	Time    string  `json:"time"` // RFC 3339
+	Text    string  `json:"text"`
+}
+
+// newWebhookPayload describes event about sess. The session's env is left
+// out: it often holds API keys, and hooks post to other people's servers.
+func newWebhookPayload(event string, sess Session) webhookPayload {
+	sess.Env = nil
+	return webhookPayload{
+		Event:   event,
+		Session: sess,
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Text:    webhookText(event, sess),
+	}
+}
+
+// webhooks delivers session events to the hooks users have registered.
+// Each hook hears about its owner's sessions only.
+type webhooks struct {
+	db       *sessionDB
+	sessions *sessionStore
+	client   *http.Client
+
+	mu    sync.Mutex
+	hooks []Webhook
+	idled map[string]time.Time // hook id + "/" + session -> activity already reported
+}
+
+// wants reports whether the hook subscribes to event; no events means all.
+func (h Webhook) wants(event string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, event)
+}
+
+func (h Webhook) idle() time.Duration {
+	if h.IdleMinutes > 0 {
+		return time.Duration(h.IdleMinutes) * time.Minute
+	}
+	return defaultHookIdle
+}
+
+// public is the hook as shown to clients, without its secret.
+func (h Webhook) public() Webhook {
+	h.Signed, h.Secret = h.Secret != "", ""
+	return h
+}
+
+func newWebhooks(db *sessionDB, sessions *sessionStore) (*webhooks, error) {
+	hooks, err := db.webhooks()
+	if err != nil {
+		return nil, err
+	}
+	return &webhooks{
+		db:       db,
+		sessions: sessions,
+		client:   &http.Client{Timeout: requestTimeout},
+		hooks:    hooks,
+		idled:    map[string]time.Time{},
+	}, nil
+}
+
+func (wh *webhooks) list(owner string) []Webhook {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	out := []Webhook{}
+	for _, h := range wh.hooks {
+		if h.Owner == owner {
+			out = append(out, h.public())
+		}
+	}
+	return out
+}
+
+func (wh *webhooks) add(h Webhook) (Webhook, error) {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Webhook{}, fmt.Errorf("invalid url %q: want http:// or https://", h.URL)
+	}
+	for _, ev := range h.Events {
+		if !slices.Contains(webhookEvents, ev) {
+			return Webhook{}, fmt.Errorf("unknown event %q (want %s)", ev, strings.Join(webhookEvents, ", "))
+		}
+	}
+	if h.IdleMinutes < 0 {
+		return Webhook{}, errors.New("idle_minutes must not be negative")
+	}
+	id := make([]byte, 4)
+	rand.Read(id)
+	h.ID = hex.EncodeToString(id)
+	h.CreatedAt = time.Now().UTC().Format("2006-01-02 15:04:05")
+	if err := wh.db.putWebhook(h); err != nil {
+		return Webhook{}, err
+	}
+	wh.mu.Lock()
+	wh.hooks = append(wh.hooks, h)
+	wh.mu.Unlock()
+	return h.public(), nil
+}
+
+// get finds one of owner's hooks.
+func (wh *webhooks) get(owner, id string) (Webhook, error) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	i := slices.IndexFunc(wh.hooks, func(h Webhook) bool { return h.ID == id && h.Owner == owner })
+	if i < 0 {
+		return Webhook{}, errNoWebhook
+	}
+	return wh.hooks[i], nil
+}
+
+func (wh *webhooks) remove(owner, id string) error {
+	if _, err := wh.get(owner, id); err != nil {
+		return err
+	}
+	if err := wh.db.deleteWebhook(id); err != nil {
+		return err
+	}
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	wh.hooks = slices.DeleteFunc(wh.hooks, func(h Webhook) bool { return h.ID == id })
+	return nil
+}
+
+// run delivers store events, and checks for idle sessions, until ctx ends.
+func (wh *webhooks) run(ctx context.Context) {
+	ch, stop := wh.sessions.subscribe()
+	defer stop()
+	t := time.NewTicker(serveRefreshInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-ch:
+			switch ev.Type {
+			case "created":
+				wh.fire(ev.Name, hookCreated)
+			case "exited":
+				wh.fire(ev.Name, hookExited)
+			}
+		case now := <-t.C:
+			wh.checkIdle(now)
+		}
+	}
+}
+
+// fire delivers event about a session to every hook that wants it. An exit
+// with a non-zero status is delivered as a crash.
+func (wh *webhooks) fire(name, event string) {
+	sess, err := wh.sessions.get(name)
+	if err != nil {
+		return
+	}
+	if event == hookExited && sess.ExitStatus != nil && *sess.ExitStatus != 0 {
+		event = hookCrashed
+	}
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	for _, h := range wh.hooks {
+		if h.Owner == sess.Owner && h.wants(event) {
+			go wh.deliver(h, event, sess)
+		}
+	}
+}
+
+// checkIdle fires idle hooks for sessions that have been quiet for each
+// hook's threshold, once per quiet spell.
+func (wh *webhooks) checkIdle(now time.Time) {
+	wh.mu.Lock()
+	hooks := slices.Clone(wh.hooks)
+	wh.mu.Unlock()
+	if !slices.ContainsFunc(hooks, func(h Webhook) bool { return h.wants(hookIdle) }) {
+		return
+	}
+	activity := tmuxActivity()
+	for _, sess := range wh.sessions.list(false, false) {
+		last, ok := activity[sess.Name]
+		if !ok {
+			continue
+		}
+		for _, h := range hooks {
+			if h.Owner != sess.Owner || !h.wants(hookIdle) || now.Sub(last) < h.idle() {
+				continue
+			}
+			key := h.ID + "/" + sess.Name
+			wh.mu.Lock()
+			seen := wh.idled[key].Equal(last)
+			wh.idled[key] = last
+			wh.mu.Unlock()
+			if !seen {
+				go wh.deliver(h, hookIdle, sess)
+			}
+		}
+	}
+}
+
+// deliver POSTs one event to a hook, retrying with backoff. With a secret
+// the body is signed with HMAC-SHA256 in X-Claude-Host-Signature.
+func (wh *webhooks) deliver(h Webhook, event string, sess Session) {
+	body, _ := json.Marshal(newWebhookPayload(event, sess))
+	var err error
+	for n := range webhookAttempts {
+		if n > 0 {
+			time.Sleep(time.Duration(1<<n) * time.Second)
+		}
+		if err = wh.post(h, body); err == nil {
+			return
+		}
+	}
+	log.Printf("webhook %s: %s %s: %v", h.ID, event, sess.Name, err)
+}
+
+func (wh *webhooks) post(h Webhook, body []byte) error {
+	req, err := http.NewRequest("POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "claude-host")
+	if h.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(h.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Claude-Host-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+// webhookText is the one-line summary sent alongside the event.
+func webhookText(event string, sess Session) string {
+	name := sess.Name
+	if sess.Description != "" {
+		name += " (" + sess.Description + ")"
+	}
+	switch event {
+	case hookCreated:
+		return fmt.Sprintf("claude-host: %s started: %s", name, sess.Command)
+	case hookCrashed:
+		return fmt.Sprintf("claude-host: %s crashed with status %d", name, *sess.ExitStatus)
+	case hookExited:
+		return fmt.Sprintf("claude-host: %s exited", name)
+	case hookIdle:
+		return fmt.Sprintf("claude-host: %s has gone idle", name)
+	default:
+		return fmt.Sprintf("claude-host: test event for %s", name)
+	}
+}
+
+func (s *server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.webhooks.list(requestUser(r).name))
+}
+
+func (s *server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var h Webhook
+	if err := json.NewDecoder(r.Body).Decode(&h); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	h.Owner = requestUser(r).name
+	h, err := s.webhooks.add(h)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, h)
+}
+
+func (s *server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := s.webhooks.remove(requestUser(r).name, r.PathValue("id")); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// testWebhook sends a test event about a placeholder session and reports
+// whether the hook accepted it.
+func (s *server) testWebhook(w http.ResponseWriter, r *http.Request) {
+	u := requestUser(r)
+	h, err := s.webhooks.get(u.name, r.PathValue("id"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	sess := Session{Name: "example", Command: "claude", Alive: true, Owner: u.name}
+	body, _ := json.Marshal(newWebhookPayload(hookTest, sess))
+	if err := s.webhooks.post(h, body); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errNoWebhook) {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookLeavesOutEnv(t *testing.T) {
+	bodies := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer srv.Close()
+
+	wh := &webhooks{client: srv.Client()}
+	sess := Session{Name: "auth", Command: "claude", Alive: true, Env: map[string]string{"ANTHROPIC_API_KEY": "sk-secret"}}
+	go wh.deliver(Webhook{ID: "1", URL: srv.URL}, hookCreated, sess)
+
+	select {
+	case body := <-bodies:
+		if strings.Contains(body, "ANTHROPIC_API_KEY") || strings.Contains(body, "sk-secret") || strings.Contains(body, `"env"`) {
+			t.Errorf("delivered body includes the session's env: %s", body)
+		}
+		if !strings.Contains(body, `"name":"auth"`) {
+			t.Errorf("delivered body is missing the session: %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing delivered")
+	}
+}
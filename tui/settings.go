@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Settings are addressed by their dotted config file keys, e.g.
+// "dashboard.poll_seconds" or "hosts.work.url", so that $CLAUDE_HOST_*
+// variables, --set flags and `claude-host config` all share the file's
+// names. They are found by walking Config's toml tags, so a new field is a
+// new setting with nothing else to update.
+
+// setting is one leaf of the config and where its value came from.
+type setting struct {
+	key    string
+	value  reflect.Value
+	source string // "file", "$NAME", "--set", or "" if unset
+}
+
+// envName is the environment variable that overrides key.
+func envName(key string) string {
+	return "CLAUDE_HOST_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// applyEnv sets every setting that has a $CLAUDE_HOST_* variable. Settings
+// inside tables keyed by name, like [hosts.<name>], have none; use --set.
+func (cfg *Config) applyEnv() error {
+	for _, s := range walkSettings(reflect.ValueOf(cfg).Elem(), "", false) {
+		name := envName(s.key)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setValue(s.value, v); err != nil {
+			return fmt.Errorf("$%s: %w", name, err)
+		}
+		cfg.sources[s.key] = "$" + name
+	}
+	return nil
+}
+
+// set parses value into the setting key.
+func (cfg *Config) set(key, value string) error {
+	return setPath(reflect.ValueOf(cfg).Elem(), key, strings.Split(key, "."), value)
+}
+
+// get returns the setting key.
+func (cfg *Config) get(key string) (setting, error) {
+	table := false
+	for _, s := range cfg.settings(true) {
+		if s.key == key {
+			return s, nil
+		}
+		table = table || strings.HasPrefix(s.key, key+".")
+	}
+	if table {
+		return setting{}, fmt.Errorf("%s is a table, not a setting", key)
+	}
+	return setting{}, fmt.Errorf("unknown setting %s", key)
+}
+
+// settings lists every setting in file order, including those in named
+// tables. Unset ones are left out unless all is true.
+func (cfg *Config) settings(all bool) []setting {
+	var out []setting
+	for _, s := range walkSettings(reflect.ValueOf(cfg).Elem(), "", true) {
+		s.source = cfg.sources[s.key]
+		if s.source == "" && !all {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// walkSettings lists the leaves under v, a struct, prefixing their keys
+// with prefix. Maps are only entered if maps is true, since their keys
+// aren't known until the file is read.
+func walkSettings(v reflect.Value, prefix string, maps bool) []setting {
+	var out []setting
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name := tomlName(f)
+		if name == "" {
+			continue
+		}
+		key := prefix + name
+		fv := v.Field(i)
+		switch {
+		case isTable(f.Type):
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			out = append(out, walkSettings(fv, key+".", maps)...)
+		case f.Type.Kind() == reflect.Map:
+			if !maps {
+				continue
+			}
+			names := make([]string, 0, fv.Len())
+			for _, k := range fv.MapKeys() {
+				names = append(names, k.String())
+			}
+			slices.Sort(names)
+			for _, n := range names {
+				// Map elements can't be addressed; copy them for reading.
+				elem := reflect.New(fv.Type().Elem()).Elem()
+				elem.Set(fv.MapIndex(reflect.ValueOf(n)))
+				if elem.Kind() == reflect.Struct {
+					out = append(out, walkSettings(elem, key+"."+n+".", maps)...)
+				} else {
+					out = append(out, setting{key: key + "." + n, value: elem})
+				}
+			}
+		default:
+			out = append(out, setting{key: key, value: fv})
+		}
+	}
+	return out
+}
+
+func tomlName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// isTable reports whether t is a [section] rather than a value.
+func isTable(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// setPath sets the setting at path under v; key is the whole path, for
+// errors.
+func setPath(v reflect.Value, key string, path []string, value string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setPath(v.Elem(), key, path, value)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if tomlName(v.Type().Field(i)) == path[0] {
+				return setLeaf(v.Field(i), key, path[1:], value)
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		// Map elements can't be set in place; copy, set and put back.
+		k := reflect.ValueOf(path[0])
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if old := v.MapIndex(k); old.IsValid() {
+			elem.Set(old)
+		}
+		if err := setLeaf(elem, key, path[1:], value); err != nil {
+			return err
+		}
+		v.SetMapIndex(k, elem)
+		return nil
+	}
+	return fmt.Errorf("unknown setting %s", key)
+}
+
+// setLeaf sets v if path is empty, or the setting at path under it.
+func setLeaf(v reflect.Value, key string, path []string, value string) error {
+	table := isTable(v.Type()) || v.Kind() == reflect.Map
+	switch {
+	case len(path) > 0 && table:
+		return setPath(v, key, path, value)
+	case len(path) > 0:
+		return fmt.Errorf("unknown setting %s", key)
+	case table:
+		return fmt.Errorf("%s is a table, not a setting", key)
+	}
+	if err := setValue(v, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// setValue parses s into v. Lists are comma-separated.
+func setValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", s)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can't be set from a string")
+		}
+		list := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = reflect.Append(list, reflect.ValueOf(item))
+			}
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("can't be set from a string")
+	}
+	return nil
+}
+
+// formatValue writes v as it would appear in the config file, or as --set
+// takes it if raw is true; "" if it is unset.
+func formatValue(v reflect.Value, raw bool) string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem(), raw)
+	case reflect.String:
+		if v.String() == "" || raw {
+			return v.String()
+		}
+		return strconv.Quote(v.String())
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return ""
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i), raw)
+		}
+		if raw {
+			return strings.Join(items, ",")
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// isSecret reports whether key holds a credential, which config show hides.
+func isSecret(key string) bool {
+	return key == "token" || strings.HasSuffix(key, ".token")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ShareRequest is the body of POST /api/sessions/{name}/share.
+type ShareRequest struct {
+	TTLSeconds int `json:"ttl_seconds,omitempty"` // 0 for the server's default
+}
+
+// ShareLink is a signed, time-limited token that lets its holder attach to
+// one session. URL is filled in by the client, as only it knows how it
+// reached the server.
+type ShareLink struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"` // RFC 3339
+	URL       string `json:"url,omitempty"`
+}
+
+// linkPath separates a share URL's server from its session:
+// <server>/link/<name>?token=<token>.
+const linkPath = "/link/"
+
+// Share asks the server for a link to a session that lasts ttl, or the
+// server's default when ttl is 0.
+func (a *APIClient) Share(ctx context.Context, name string, ttl time.Duration) (*ShareLink, error) {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/sessions/"+url.PathEscape(name)+"/share", ShareRequest{TTLSeconds: int(ttl.Seconds())})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var link ShareLink
+	if err := decodeResponse(resp, &link); err != nil {
+		return nil, err
+	}
+	base := a.baseURL
+	if a.socket != "" {
+		base = unixScheme + a.socket
+	}
+	link.URL = base + linkPath + url.PathEscape(name) + "?token=" + url.QueryEscape(link.Token)
+	return &link, nil
+}
+
+// parseLink splits a share URL into the server's base URL, the session
+// and the token.
+func parseLink(raw string) (base, name, token string, err error) {
+	i := strings.LastIndex(raw, linkPath)
+	if i < 0 {
+		return "", "", "", fmt.Errorf("%q is not a share link", raw)
+	}
+	base = raw[:i]
+	rest, query, _ := strings.Cut(raw[i+len(linkPath):], "?")
+	if name, err = url.PathUnescape(rest); err != nil || name == "" {
+		return "", "", "", fmt.Errorf("%q is not a share link", raw)
+	}
+	q, err := url.ParseQuery(query)
+	if err != nil || q.Get("token") == "" {
+		return "", "", "", fmt.Errorf("share link %q has no token", raw)
+	}
+	return base, name, q.Get("token"), nil
+}
+
+// WithToken returns a copy of the client that authenticates with token, as
+// when following a share link.
+func (a *APIClient) WithToken(token string) *APIClient {
+	c := *a
+	c.token = token
+	return &c
+}
+
+// share makes a link to the session, copies it to the clipboard and shows
+// it in the footer.
+func (m DashboardModel) share(name string) tea.Cmd {
+	ctx, api := m.ctx, m.api
+	return func() tea.Msg {
+		link, err := api.Share(ctx, name, 0)
+		if err != nil {
+			return errMsg{err}
+		}
+		note := "link to " + name
+		if t, err := time.Parse(time.RFC3339, link.ExpiresAt); err == nil {
+			note += ", good for " + shortDuration(time.Until(t).Round(time.Minute))
+		}
+		if copyToClipboard(link.URL) == nil {
+			note += " (copied)"
+		}
+		return noticeMsg(note + ": " + link.URL)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+)
+
+// Snapshots are fetched every few seconds for every running session, by
+// the dashboard and its watcher both, and are mostly unchanged. The client
+// keeps each session's last snapshot with the ETag the server gave it and
+// asks again with If-None-Match, so an unchanged screen costs a 304 and no
+// body. Servers that send no ETag are asked in full every time.
+
+// snapshotCacheLimit bounds how many sessions' snapshots are kept; past it
+// the cache starts over.
+const snapshotCacheLimit = 256
+
+type cachedSnapshot struct {
+	etag string
+	text string
+}
+
+// snapshotCache holds a client's last snapshot of each session. A nil
+// *snapshotCache, as on an aggregate client, caches nothing.
+type snapshotCache struct {
+	mu    sync.Mutex
+	items map[string]cachedSnapshot
+}
+
+func newSnapshotCache() *snapshotCache {
+	return &snapshotCache{items: map[string]cachedSnapshot{}}
+}
+
+func (c *snapshotCache) get(name string) (cachedSnapshot, bool) {
+	if c == nil {
+		return cachedSnapshot{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.items[name]
+	return s, ok
+}
+
+func (c *snapshotCache) put(name string, s cachedSnapshot) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[name]; !ok && len(c.items) >= snapshotCacheLimit {
+		clear(c.items)
+	}
+	c.items[name] = s
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type sortKey string
+
+const (
+	sortServer   sortKey = "" // the server's order (manual position, then newest)
+	sortName     sortKey = "name"
+	sortAge      sortKey = "age"
+	sortActivity sortKey = "activity"
+)
+
+// sortCycle is the order the sort key steps through.
+var sortCycle = []sortKey{sortServer, sortName, sortAge, sortActivity}
+
+func (k sortKey) valid() bool {
+	return slices.Contains(sortCycle, k)
+}
+
+func (k sortKey) next() sortKey {
+	i := slices.Index(sortCycle, k)
+	return sortCycle[(i+1)%len(sortCycle)]
+}
+
+// sortSessions returns a sorted copy of sessions. Activity sorts most
+// recently active first, using the watcher's record of screen changes.
+func sortSessions(sessions []Session, key sortKey, watch *watcher) []Session {
+	if key == sortServer {
+		return sessions
+	}
+	out := slices.Clone(sessions)
+	switch key {
+	case sortName:
+		slices.SortStableFunc(out, func(a, b Session) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	case sortAge:
+		slices.SortStableFunc(out, func(a, b Session) int {
+			return parseTime(b.CreatedAt).Compare(parseTime(a.CreatedAt))
+		})
+	case sortActivity:
+		slices.SortStableFunc(out, func(a, b Session) int {
+			return watch.LastChange(b.Name).Compare(watch.LastChange(a.Name))
+		})
+	}
+	return out
+}
+
+// A session counts as active if its screen changed within activeWindow.
+const activeWindow = 10 * time.Second
+
+// activityLabel renders the activity column: "● active" or "◌ idle 5m".
+func activityLabel(changed time.Time) string {
+	if changed.IsZero() {
+		return "◌ idle"
+	}
+	d := time.Since(changed)
+	if d < activeWindow {
+		return "● active"
+	}
+	return "◌ idle " + shortDuration(d)
+}
+
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
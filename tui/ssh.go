@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sshTimeout   = 10 * time.Second
+	sshKeepAlive = 30 * time.Second
+)
+
+// sshIdentities are the private keys tried after the agent, as ssh(1) does.
+// Keys protected by a passphrase are skipped; load those into the agent.
+var sshIdentities = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// sshTunnel carries connections to the server over an SSH connection, so
+// the server's port never needs to be exposed on the remote machine.
+type sshTunnel struct {
+	dest   string // as given, for messages
+	client *ssh.Client
+}
+
+// dialSSH connects to dest, given as [user@]host[:port]. The host key must
+// already be in ~/.ssh/known_hosts.
+func dialSSH(dest string) (*sshTunnel, error) {
+	login, addr, ok := strings.Cut(dest, "@")
+	if !ok {
+		addr, login = login, ""
+	}
+	if login == "" {
+		if u, err := user.Current(); err == nil {
+			login = u.Username
+		}
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("ssh: %w", err)
+	}
+	hostKeys, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("ssh: known hosts: %w", err)
+	}
+	config := &ssh.ClientConfig{
+		User:            login,
+		Auth:            sshAuth(home),
+		HostKeyCallback: hostKeys,
+		Timeout:         sshTimeout,
+	}
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return nil, fmt.Errorf("ssh %s: host key not in known_hosts; connect once with ssh to add it", dest)
+		}
+		return nil, fmt.Errorf("ssh %s: %w", dest, err)
+	}
+	t := &sshTunnel{dest: dest, client: client}
+	go t.keepAlive()
+	return t, nil
+}
+
+// sshAuth offers the agent's keys, then any unencrypted default identities.
+func sshAuth(home string) []ssh.AuthMethod {
+	var methods []ssh.AuthMethod
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	var signers []ssh.Signer
+	for _, name := range sshIdentities {
+		pem, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+		if err != nil {
+			continue
+		}
+		if s, err := ssh.ParsePrivateKey(pem); err == nil {
+			signers = append(signers, s)
+		}
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	return methods
+}
+
+// keepAlive pings the server so idle tunnels aren't dropped by NAT or
+// firewalls, and closes the tunnel once it stops answering.
+func (t *sshTunnel) keepAlive() {
+	tick := time.NewTicker(sshKeepAlive)
+	defer tick.Stop()
+	for range tick.C {
+		if _, _, err := t.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+			t.client.Close()
+			return
+		}
+	}
+}
+
+// DialContext opens a connection from the remote machine to addr, which is
+// resolved there, so localhost means the machine at the far end.
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return t.client.DialContext(ctx, network, addr)
+}
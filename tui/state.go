@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// State is remembered between runs in $XDG_STATE_HOME/claude-host/state.json.
+// Unlike the config file it is written by the TUI itself.
+type State struct {
+	Sort         sortKey  `json:"sort,omitempty"`
+	Conversation bool     `json:"conversation,omitempty"` // preview conversations, not screens
+	Grouped      bool     `json:"grouped,omitempty"`      // list sessions under their projects
+	Collapsed    []string `json:"collapsed,omitempty"`    // projects whose sessions are hidden
+}
+
+func statePath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "claude-host", "state.json")
+}
+
+// LoadState reads the state file. Anything missing or unreadable just means
+// starting from defaults.
+func LoadState() State {
+	var st State
+	data, err := os.ReadFile(statePath())
+	if err != nil {
+		return st
+	}
+	json.Unmarshal(data, &st)
+	if !st.Sort.valid() {
+		st.Sort = sortServer
+	}
+	return st
+}
+
+func SaveState(st State) error {
+	path := statePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
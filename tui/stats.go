@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SessionStats is a running session's resource use, from
+// GET /api/sessions/{name}/stats.
+type SessionStats struct {
+	CPU       float64 `json:"cpu_percent"` // since the previous request; 100 is one core
+	RSS       int64   `json:"rss_bytes"`
+	Processes int     `json:"processes"` // under the session's shell
+}
+
+// A session past either limit is highlighted as a runaway.
+const (
+	runawayCPU = 90      // percent
+	runawayRSS = 4 << 30 // bytes
+)
+
+func (st SessionStats) runaway() bool {
+	return st.CPU >= runawayCPU || st.RSS >= runawayRSS
+}
+
+// String is e.g. "12% 340M 3p".
+func (st SessionStats) String() string {
+	return fmt.Sprintf("%.0f%% %s %dp", st.CPU, formatBytes(st.RSS), st.Processes)
+}
+
+// formatBytes is a short size like 340M or 1.2G.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 10<<30:
+		return fmt.Sprintf("%dG", n>>30)
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%dM", n>>20)
+	default:
+		return fmt.Sprintf("%dK", n>>10)
+	}
+}
+
+// Stats returns a running session's CPU, memory and process count. Servers
+// without /proc answer 501, and the Node server 404.
+func (a *APIClient) Stats(ctx context.Context, name string) (*SessionStats, error) {
+	a, name = a.route(name)
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "GET", "/api/sessions/"+url.PathEscape(name)+"/stats", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var st SessionStats
+	if err := decodeResponse(resp, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+type statsMsg struct {
+	stats       map[string]*SessionStats
+	unsupported bool // no session's server can report usage; stop asking
+}
+
+// fetchStats gets the usage of every running session at once.
+func (m DashboardModel) fetchStats() tea.Cmd {
+	if m.noStats {
+		return nil
+	}
+	ctx, api := m.ctx, m.api
+	var names []string
+	for _, s := range m.all {
+		if s.Alive {
+			names = append(names, s.Name)
+		}
+	}
+	return func() tea.Msg {
+		msg := statsMsg{stats: map[string]*SessionStats{}}
+		unsupported := 0
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, name := range names {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				st, err := api.Stats(ctx, name)
+				mu.Lock()
+				defer mu.Unlock()
+				if e := asAPIError(err); e != nil && (e.NotFound() || e.Status == http.StatusNotImplemented) {
+					unsupported++
+				}
+				if err == nil {
+					msg.stats[name] = st
+				}
+			}()
+		}
+		wg.Wait()
+		msg.unsupported = len(names) > 0 && unsupported == len(names)
+		return msg
+	}
+}
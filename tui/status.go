@@ -0,0 +1,28 @@
+package main
+
+import "github.com/charmbracelet/lipgloss"
+
+// statusLabels are how the status column shows each Session.Status.
+var statusLabels = map[string]string{
+	statusThinking:   "◆ thinking",
+	statusTool:       "▶ tool",
+	statusPermission: "? permission",
+	statusInput:      "○ input",
+	statusDone:       "✓ done",
+}
+
+// statusStyle colors a status: loudest for a question, bright for work
+// going on, quiet once it's over.
+func statusStyle(status string) lipgloss.Style {
+	switch status {
+	case statusPermission:
+		return warnSty
+	case statusThinking:
+		return activeStyle
+	case statusTool:
+		return toolTurnStyle
+	case statusInput:
+		return promptSty
+	}
+	return tStyle
+}
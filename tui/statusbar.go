@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// noteTTL is how long a message stays in the attach status bar.
+const noteTTL = 5 * time.Second
+
+// freeRegion gives scrolling the whole screen back.
+const freeRegion = "\0337\033[r\0338"
+
+// attachBar is the optional status bar on the bottom row during attach
+// (ctrl-a t, or [attach] status_bar). While it's shown the session gets
+// one row fewer and a scroll region that stops above the bar, so the
+// session's output scrolls without disturbing it.
+type attachBar struct {
+	on        bool
+	name      string
+	host      string
+	latency   time.Duration // 0 until the first pong
+	viewers   int
+	recording bool
+	note      string // a transient message, shown in place of the hint
+	noteAt    time.Time
+}
+
+// rows is how many rows the session gets on a terminal h rows tall.
+func (b *attachBar) rows(h int) int {
+	if b.on && h > 1 {
+		return h - 1
+	}
+	return h
+}
+
+// region returns what confines scrolling to the rows above the bar, or
+// frees the whole screen again once it's off. Setting a scroll region
+// homes the cursor, so it's saved around it.
+func (b *attachBar) region(h int) string {
+	if !b.on || h <= 1 {
+		return freeRegion
+	}
+	return fmt.Sprintf("\0337\033[1;%dr\0338", h-1)
+}
+
+// clear returns what blanks the bar's row once the bar is turned off.
+func (b *attachBar) clear(h int) string {
+	return fmt.Sprintf("\0337\033[%d;1H\033[2K\0338", h)
+}
+
+// draw returns what paints the bar on the bottom row of a w x h terminal,
+// leaving the cursor where it was.
+func (b *attachBar) draw(w, h int, keys KeyMap) string {
+	if !b.on || h <= 1 {
+		return ""
+	}
+	parts := []string{b.name, b.host}
+	if b.latency > 0 {
+		parts = append(parts, fmt.Sprintf("%dms", b.latency.Milliseconds()))
+	}
+	if b.viewers > 1 {
+		parts = append(parts, viewersLabel(b.viewers))
+	}
+	if b.recording {
+		parts = append(parts, "● rec")
+	}
+	left := " " + strings.Join(parts, " · ")
+	right := keys.DetachHint() + " to detach "
+	if b.note != "" && time.Since(b.noteAt) < noteTTL {
+		right = b.note + " "
+	}
+	line := left
+	if gap := w - textWidth(left) - textWidth(right); gap > 0 {
+		line += strings.Repeat(" ", gap) + right
+	}
+	line = pad(truncateTail(line, w), w)
+	return fmt.Sprintf("\0337\033[%d;1H\033[0m\033[7m%s\033[0m\0338", h, line)
+}
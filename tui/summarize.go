@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SummarizeRequest is the optional body of POST /api/sessions/{name}/summarize.
+// Anything left unset gets the server's default.
+type SummarizeRequest struct {
+	Model     string `json:"model,omitempty"`      // passed to claude --model
+	Prompt    string `json:"prompt,omitempty"`     // a template; see summaryPrompt
+	MaxLength int    `json:"max_length,omitempty"` // in characters; longer summaries are cut
+}
+
+// SummarizeConfig is [summarize] in the config file, sent with every
+// summarize request.
+type SummarizeConfig struct {
+	Model string `toml:"model"` // e.g. "haiku"; default is claude's own
+	// Prompt replaces the instructions claude is given along with the
+	// screen. It is a Go template over the session's fields, plus
+	// {{.MaxLength}}.
+	Prompt    string `toml:"prompt"`
+	MaxLength int    `toml:"max_length"` // default 80
+	// Auto re-summarizes sessions in the background when their screens
+	// change a lot, no more than once per AutoMinutes (default 5).
+	Auto        bool `toml:"auto"`
+	AutoMinutes *int `toml:"auto_minutes"`
+}
+
+func (c SummarizeConfig) request() SummarizeRequest {
+	return SummarizeRequest{Model: c.Model, Prompt: c.Prompt, MaxLength: c.MaxLength}
+}
+
+// defaultSummaryLength is as long as a summary may be unless the request
+// says otherwise; it fits a dashboard row.
+const defaultSummaryLength = 80
+
+// summarizePrompt matches the Node server's once expanded, so descriptions
+// read the same whichever server wrote them.
+const summarizePrompt = "You are looking at terminal output from a coding session. " +
+	"Summarize what this session is working on in one brief sentence (max {{.MaxLength}} chars). " +
+	"Output ONLY the summary sentence, nothing else."
+
+var modelName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:\[\]-]*$`)
+
+// summaryPrompt expands req's prompt template, or the default one, for
+// sess.
+func summaryPrompt(req SummarizeRequest, sess Session) (string, error) {
+	text := req.Prompt
+	if text == "" {
+		text = summarizePrompt
+	}
+	t, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var s strings.Builder
+	data := struct {
+		Session
+		MaxLength int
+	}{sess, req.MaxLength}
+	if err := t.Execute(&s, data); err != nil {
+		return "", err
+	}
+	return s.String(), nil
+}
+
+// validate checks req and fills in its defaults.
+func (req *SummarizeRequest) validate() error {
+	if req.MaxLength == 0 {
+		req.MaxLength = defaultSummaryLength
+	}
+	if req.MaxLength < 10 || req.MaxLength > 1000 {
+		return fmt.Errorf("max_length must be between 10 and 1000")
+	}
+	if req.Model != "" && !modelName.MatchString(req.Model) {
+		return fmt.Errorf("invalid model %q", req.Model)
+	}
+	_, err := summaryPrompt(*req, Session{})
+	return err
+}
+
+// clipSummary keeps the first line of a summary and cuts it to limit
+// characters at a word boundary, since models don't always keep to the
+// length they're asked for.
+func clipSummary(s string, limit int) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= limit {
+		return string(r)
+	}
+	cut := string(r[:limit-1])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:.") + "…"
+}
+
+// summarizeConcurrency bounds how many summaries a bulk run has the server
+// working on at once; each one runs claude.
+const summarizeConcurrency = 4
+
+var errNoSummary = errors.New("no summary (not running, or claude failed)")
+
+// summaryProgress follows a bulk summarize run as its sessions finish.
+type summaryProgress struct {
+	total int
+	items []bulkItem // finished, in the order they finished
+	next  tea.Cmd    // waits for the next to finish
+}
+
+// summarizeDoneMsg ends a bulk summarize run with a fresh session list.
+type summarizeDoneMsg struct {
+	sessions []Session
+	listErr  error
+}
+
+// summarizeMany summarizes names through a bounded pool of workers,
+// reporting each one as it finishes, then lists the sessions on dctx so a
+// run cancelled through ctx still shows what it got done.
+func (m *DashboardModel) summarizeMany(names []string) tea.Cmd {
+	ctx, dctx, api, req, list := m.summarizeContext(), m.ctx, m.api, m.summarize, m.lister()
+	results := make(chan summarizeMsg, len(names))
+	m.progress = &summaryProgress{total: len(names), next: nextSummary(dctx, results, list)}
+	go func() {
+		defer close(results)
+		sem := make(chan struct{}, summarizeConcurrency)
+		var wg sync.WaitGroup
+		for _, name := range names {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if ctx.Err() != nil {
+					return
+				}
+				desc, err := api.Summarize(ctx, name, req)
+				if err == nil && desc == "" {
+					err = errNoSummary
+				}
+				results <- summarizeMsg{name: name, desc: desc, err: err}
+			}()
+		}
+		wg.Wait()
+	}()
+	return m.progress.next
+}
+
+// nextSummary waits for the next session of a bulk run to finish, or for
+// the run to end.
+func nextSummary(ctx context.Context, results <-chan summarizeMsg, list func(context.Context) ([]Session, error)) tea.Cmd {
+	return func() tea.Msg {
+		if msg, ok := <-results; ok {
+			return summaryProgressMsg(msg)
+		}
+		sessions, err := list(ctx)
+		return summarizeDoneMsg{sessions: sessions, listErr: err}
+	}
+}
+
+type summaryProgressMsg summarizeMsg
+
+// View is a progress bar with the failures so far.
+func (p summaryProgress) View(cancel string) string {
+	const width = 24
+	filled := width * len(p.items) / max(1, p.total)
+	var s strings.Builder
+	s.WriteString("  " + dimStyle.Render(fmt.Sprintf("summarizing %d/%d ", len(p.items), p.total)))
+	s.WriteString(activeStyle.Render(strings.Repeat("█", filled)) + dimStyle.Render(strings.Repeat("░", width-filled)))
+	s.WriteString("  " + dimStyle.Render(cancel+" cancel") + "\n")
+	for _, it := range p.items {
+		if it.err != nil {
+			s.WriteString("    " + errSty.Render(fmt.Sprintf("✗ %s: %v", it.name, it.err)) + "\n")
+		}
+	}
+	return s.String()
+}
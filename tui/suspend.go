@@ -0,0 +1,92 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// termModes follows the DEC private modes, like the alternate screen, mouse
+// reporting and bracketed paste, that a session's output turns on in the
+// local terminal, so that suspending attach can hand the shell back a
+// terminal without them and resuming can put them back.
+type termModes struct {
+	esc    escState
+	csi    []byte
+	on     []string // in the order they were turned on
+	hidden bool     // the cursor, which starts out shown
+}
+
+func (m *termModes) Write(p []byte) {
+	for _, b := range p {
+		switch m.esc {
+		case escStart:
+			switch b {
+			case '[':
+				m.esc, m.csi = escCSI, m.csi[:0]
+			case ']':
+				m.esc = escOSC
+			default:
+				m.esc = escNone
+			}
+		case escCSI:
+			if b < 0x40 || b > 0x7e {
+				m.csi = append(m.csi, b)
+				continue
+			}
+			m.esc = escNone
+			if (b == 'h' || b == 'l') && len(m.csi) > 1 && m.csi[0] == '?' {
+				for _, mode := range strings.Split(string(m.csi[1:]), ";") {
+					m.set(mode, b == 'h')
+				}
+			}
+		case escOSC:
+			if b == 0x07 {
+				m.esc = escNone
+			} else if b == 0x1b {
+				m.esc = escOSCEnd
+			}
+		case escOSCEnd:
+			m.esc = escNone
+		default:
+			if b == 0x1b {
+				m.esc = escStart
+			}
+		}
+	}
+}
+
+func (m *termModes) set(mode string, on bool) {
+	if mode == "25" {
+		m.hidden = !on
+		return
+	}
+	i := slices.Index(m.on, mode)
+	switch {
+	case on && i < 0:
+		m.on = append(m.on, mode)
+	case !on && i >= 0:
+		m.on = slices.Delete(m.on, i, i+1)
+	}
+}
+
+// off returns what turns the modes off, last first, and shows the cursor.
+func (m *termModes) off() string {
+	var b strings.Builder
+	for _, mode := range slices.Backward(m.on) {
+		b.WriteString("\033[?" + mode + "l")
+	}
+	b.WriteString("\033[?25h\033[0m")
+	return b.String()
+}
+
+// restore returns what turns the modes back on.
+func (m *termModes) restore() string {
+	var b strings.Builder
+	for _, mode := range m.on {
+		b.WriteString("\033[?" + mode + "h")
+	}
+	if m.hidden {
+		b.WriteString("\033[?25l")
+	}
+	return b.String()
+}
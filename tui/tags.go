@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Chip colours are picked by hashing the tag so a tag keeps its colour
+// across sessions and runs.
+func tagChip(tag string) string {
+	if len(chipStyles) == 0 {
+		return " " + tag + " "
+	}
+	h := fnv.New32a()
+	h.Write([]byte(tag))
+	return chipStyles[h.Sum32()%uint32(len(chipStyles))].Render(" " + tag + " ")
+}
+
+func renderChips(tags []string) string {
+	chips := make([]string, len(tags))
+	for i, t := range tags {
+		chips[i] = tagChip(t)
+	}
+	return strings.Join(chips, " ")
+}
+
+type tagCount struct {
+	tag   string
+	count int
+}
+
+// countTags returns every tag in use with the number of sessions carrying it,
+// most common first.
+func countTags(sessions []Session) []tagCount {
+	counts := map[string]int{}
+	for _, s := range sessions {
+		for _, t := range s.Tags {
+			counts[t]++
+		}
+	}
+	out := make([]tagCount, 0, len(counts))
+	for t, n := range counts {
+		out = append(out, tagCount{t, n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		return out[i].tag < out[j].tag
+	})
+	return out
+}
+
+func filterByTag(sessions []Session, tag string) []Session {
+	if tag == "" {
+		return sessions
+	}
+	var out []Session
+	for _, s := range sessions {
+		if slices.Contains(s.Tags, tag) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// TagMenu picks a tag to filter the dashboard by. The first entry clears the
+// filter.
+type TagMenu struct {
+	tags   []tagCount
+	total  int
+	cursor int
+}
+
+type tagFilterMsg string // "" clears the filter
+type tagMenuCloseMsg struct{}
+
+func NewTagMenu(sessions []Session, current string) TagMenu {
+	menu := TagMenu{tags: countTags(sessions), total: len(sessions)}
+	for i, t := range menu.tags {
+		if t.tag == current {
+			menu.cursor = i + 1
+		}
+	}
+	return menu
+}
+
+func (t TagMenu) Update(msg tea.KeyMsg) (TagMenu, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		return t, func() tea.Msg { return tagMenuCloseMsg{} }
+	case "up", "k":
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case "down", "j":
+		if t.cursor < len(t.tags) {
+			t.cursor++
+		}
+	case "enter":
+		tag := ""
+		if t.cursor > 0 {
+			tag = t.tags[t.cursor-1].tag
+		}
+		return t, func() tea.Msg { return tagFilterMsg(tag) }
+	}
+	return t, nil
+}
+
+func (t TagMenu) View() string {
+	var s strings.Builder
+	s.WriteString("  " + titleStyle.Render("filter by tag") + "\n\n")
+	row := func(i int, label string) {
+		prefix := "  "
+		if i == t.cursor {
+			prefix = "▸ "
+		}
+		s.WriteString("  " + prefix + label + "\n")
+	}
+	row(0, normStyle.Render("all sessions")+dimStyle.Render(fmt.Sprintf("  %d", t.total)))
+	for i, tc := range t.tags {
+		row(i+1, tagChip(tc.tag)+dimStyle.Render(fmt.Sprintf("  %d", tc.count)))
+	}
+	if len(t.tags) == 0 {
+		s.WriteString("\n  " + dimStyle.Render("No tags yet. Press t on a session to add one.") + "\n")
+	}
+	s.WriteString("\n  " + dimStyle.Render("↑↓ select  enter filter  esc close") + "\n")
+	return s.String()
+}
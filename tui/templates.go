@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Template is a preset for new sessions, e.g. [templates.yolo]. Every field
+// is optional; the command defaults to claude.
+type Template struct {
+	Command     string            `toml:"command"`
+	Args        []string          `toml:"args"` // shell-quoted onto the command
+	Cwd         string            `toml:"cwd"`  // on the server; ~ is its home
+	Env         map[string]string `toml:"env"`
+	Description string            `toml:"description"` // prefix for the session's description
+	Project     string            `toml:"project"`
+}
+
+// options is the create request the template makes, before the user adds
+// to it.
+func (t Template) options() CreateOptions {
+	cmd := t.Command
+	if cmd == "" {
+		cmd = "claude"
+	}
+	for _, a := range t.Args {
+		cmd += " " + shellQuote(a)
+	}
+	return CreateOptions{Command: cmd, Cwd: t.Cwd, Env: t.Env, Description: t.Description, Project: t.Project}
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for a POSIX shell, leaving it bare when it's safe to.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// summary describes what the template runs, for the picker.
+func (t Template) summary() string {
+	s := t.options().Command
+	if t.Cwd != "" {
+		s += " in " + t.Cwd
+	}
+	return s
+}
+
+// TemplateMenu picks the template a new session starts from, ahead of the
+// create form.
+type TemplateMenu struct {
+	names     []string // "" first, for a blank session
+	templates map[string]Template
+	cursor    int
+}
+
+type templatePickMsg CreateOptions
+type templateMenuCloseMsg struct{}
+
+func NewTemplateMenu(templates map[string]Template) TemplateMenu {
+	return TemplateMenu{names: append([]string{""}, slices.Sorted(maps.Keys(templates))...), templates: templates}
+}
+
+func (t TemplateMenu) Update(msg tea.KeyMsg) (TemplateMenu, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		return t, func() tea.Msg { return templateMenuCloseMsg{} }
+	case "up", "k":
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case "down", "j":
+		if t.cursor < len(t.names)-1 {
+			t.cursor++
+		}
+	case "enter":
+		opts := t.templates[t.names[t.cursor]].options()
+		return t, func() tea.Msg { return templatePickMsg(opts) }
+	}
+	return t, nil
+}
+
+func (t TemplateMenu) View() string {
+	var s strings.Builder
+	s.WriteString("  " + titleStyle.Render("new session from") + "\n\n")
+	width := len("blank")
+	for _, name := range t.names {
+		width = max(width, textWidth(name))
+	}
+	for i, name := range t.names {
+		prefix := "  "
+		style := normStyle
+		if i == t.cursor {
+			prefix = "▸ "
+			style = selStyle
+		}
+		label, summary := name, t.templates[name].summary()
+		if name == "" {
+			label = "blank"
+		}
+		s.WriteString(fmt.Sprintf("  %s%s  %s\n", prefix, style.Render(pad(label, width)), dimStyle.Render(summary)))
+	}
+	s.WriteString("\n  " + dimStyle.Render("↑↓ select  enter edit  esc cancel") + "\n")
+	return s.String()
+}
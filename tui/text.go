@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Text is laid out by display width, the number of terminal cells it
+// takes, rather than by bytes or runes: CJK characters and most emoji take
+// two cells, combining marks none.
+
+// textWidth is how many cells s takes.
+func textWidth(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// truncate cuts s to at most width cells, never splitting a character.
+func truncate(s string, width int) string {
+	if width <= 0 || textWidth(s) <= width {
+		return s
+	}
+	return runewidth.Truncate(s, width, "")
+}
+
+// truncateTail is truncate with an ellipsis marking the cut.
+func truncateTail(s string, width int) string {
+	if width <= 0 || textWidth(s) <= width {
+		return s
+	}
+	return runewidth.Truncate(s, width, "…")
+}
+
+// pad fills s with spaces to width cells, like %-*s but counting cells.
+// Text already that wide is left alone.
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-textWidth(s)))
+}
+
+// runeJoiner reassembles characters split across reads of a byte stream,
+// so that each chunk it passes on holds only whole characters.
+type runeJoiner struct {
+	pending []byte // the start of a character cut off by the last read
+}
+
+// Next returns p with the previous call's partial character in front,
+// holding back any partial character at its end. Bytes that aren't UTF-8
+// pass through as they are.
+func (j *runeJoiner) Next(p []byte) []byte {
+	data := append(j.pending, p...)
+	n := completeUTF8(data)
+	j.pending = append([]byte(nil), data[n:]...)
+	return data[:n]
+}
+
+// completeUTF8 returns the length of the longest prefix of p that doesn't end
+// partway through a multi-byte character.
+func completeUTF8(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(p)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// Palette is the set of colours the TUI draws with. Each is an ANSI colour
+// number, "0" to "255", or "#rrggbb".
+type Palette struct {
+	Text     string   `toml:"text"`     // session names and descriptions
+	Selected string   `toml:"selected"` // the row under the cursor
+	Dim      string   `toml:"dim"`      // hints, times and secondary detail
+	Command  string   `toml:"command"`
+	Preview  string   `toml:"preview"`  // screen contents
+	Faint    string   `toml:"faint"`    // exited sessions, borders, copy-mode selection
+	Error    string   `toml:"error"`    // errors and warnings
+	Accent   string   `toml:"accent"`   // prompts, search matches, the user's turns
+	Mark     string   `toml:"mark"`     // marked sessions, permission prompts, tool calls
+	Active   string   `toml:"active"`   // progress and busy sessions
+	Contrast string   `toml:"contrast"` // text on an accent, mark or chip background
+	Chips    []string `toml:"chips"`    // tag chip backgrounds, picked by hashing the tag
+}
+
+// ThemeConfig is [theme] in the config file.
+type ThemeConfig struct {
+	// Name is "dark", "light", "solarized", "solarized-light" or "none".
+	// The default, "auto", picks dark or light to suit the terminal's
+	// background. $NO_COLOR always means "none".
+	Name string `toml:"name"`
+	// Colors replaces the named theme's colours one by one, e.g.
+	// [theme.colors] dim = "245".
+	Colors Palette `toml:"colors"`
+}
+
+const (
+	themeAuto = "auto"
+	themeNone = "none" // attributes only: bold, reverse video and underline
+)
+
+var themes = map[string]Palette{
+	"dark": {
+		Text: "250", Selected: "15", Dim: "240", Command: "245", Preview: "248", Faint: "238",
+		Error: "1", Accent: "6", Mark: "3", Active: "2", Contrast: "0",
+		Chips: []string{"4", "5", "6", "2", "3", "1", "12", "13"},
+	},
+	"light": {
+		Text: "236", Selected: "0", Dim: "243", Command: "240", Preview: "238", Faint: "250",
+		Error: "160", Accent: "25", Mark: "130", Active: "28", Contrast: "15",
+		Chips: []string{"25", "90", "30", "28", "130", "124", "61", "31"},
+	},
+	"solarized": {
+		Text: "#93a1a1", Selected: "#fdf6e3", Dim: "#586e75", Command: "#839496", Preview: "#93a1a1", Faint: "#3b5660",
+		Error: "#dc322f", Accent: "#2aa198", Mark: "#b58900", Active: "#859900", Contrast: "#002b36",
+		Chips: []string{"#268bd2", "#d33682", "#2aa198", "#859900", "#b58900", "#dc322f", "#6c71c4", "#cb4b16"},
+	},
+	"solarized-light": {
+		Text: "#586e75", Selected: "#002b36", Dim: "#93a1a1", Command: "#657b83", Preview: "#586e75", Faint: "#c9c4b1",
+		Error: "#dc322f", Accent: "#268bd2", Mark: "#b58900", Active: "#859900", Contrast: "#fdf6e3",
+		Chips: []string{"#268bd2", "#d33682", "#2aa198", "#859900", "#b58900", "#dc322f", "#6c71c4", "#cb4b16"},
+	},
+	themeNone: {},
+}
+
+var colorValue = regexp.MustCompile(`^(#[0-9a-fA-F]{6}|[0-9]{1,3})$`)
+
+func (c ThemeConfig) validate() error {
+	if _, ok := themes[c.Name]; !ok && c.Name != "" && c.Name != themeAuto {
+		return fmt.Errorf("theme.name must be %q or one of %q", themeAuto, slices.Sorted(maps.Keys(themes)))
+	}
+	colors := append([]string{
+		c.Colors.Text, c.Colors.Selected, c.Colors.Dim, c.Colors.Command, c.Colors.Preview, c.Colors.Faint,
+		c.Colors.Error, c.Colors.Accent, c.Colors.Mark, c.Colors.Active, c.Colors.Contrast,
+	}, c.Colors.Chips...)
+	for _, col := range colors {
+		if col != "" && !colorValue.MatchString(col) {
+			return fmt.Errorf("theme.colors: %q is not a colour number or #rrggbb", col)
+		}
+	}
+	return nil
+}
+
+// The styles the TUI draws with, set by useTheme.
+var (
+	titleStyle    lipgloss.Style
+	dimStyle      lipgloss.Style
+	selStyle      lipgloss.Style
+	normStyle     lipgloss.Style
+	cmdStyle      lipgloss.Style
+	tStyle        lipgloss.Style
+	errSty        lipgloss.Style
+	warnSty       lipgloss.Style
+	promptSty     lipgloss.Style
+	previewStyle  lipgloss.Style
+	freshStyle    lipgloss.Style // preview lines new since last look
+	deadStyle     lipgloss.Style
+	markStyle     lipgloss.Style
+	activeStyle   lipgloss.Style
+	waitStyle     lipgloss.Style
+	foundStyle    lipgloss.Style
+	matchStyle    lipgloss.Style
+	userTurnStyle lipgloss.Style
+	toolTurnStyle lipgloss.Style
+	rangeStyle    lipgloss.Style // copy-mode selection
+	tileStyle     lipgloss.Style
+	tileActive    lipgloss.Style
+	tileWait      lipgloss.Style
+	chipStyles    []lipgloss.Style
+)
+
+// useTheme sets the styles from cfg, asking the terminal for its background
+// colour if the theme is left to choose.
+func useTheme(cfg ThemeConfig) {
+	name := cfg.Name
+	switch {
+	case os.Getenv("NO_COLOR") != "":
+		name = themeNone
+	case name == "" || name == themeAuto:
+		name = "dark"
+		if !lipgloss.HasDarkBackground() {
+			name = "light"
+		}
+	}
+	p := themes[name]
+	mono := name == themeNone
+	if mono {
+		// lipgloss drops bold and reverse video along with colour under
+		// NO_COLOR, but they're all a colourless theme has.
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			lipgloss.SetColorProfile(termenv.ANSI)
+		}
+	} else {
+		p = p.with(cfg.Colors)
+	}
+	setStyles(p, mono)
+}
+
+// with is p with the colours set in over replacing its own.
+func (p Palette) with(over Palette) Palette {
+	for _, f := range []struct{ dst, src *string }{
+		{&p.Text, &over.Text}, {&p.Selected, &over.Selected}, {&p.Dim, &over.Dim},
+		{&p.Command, &over.Command}, {&p.Preview, &over.Preview}, {&p.Faint, &over.Faint},
+		{&p.Error, &over.Error}, {&p.Accent, &over.Accent}, {&p.Mark, &over.Mark},
+		{&p.Active, &over.Active}, {&p.Contrast, &over.Contrast},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	if len(over.Chips) > 0 {
+		p.Chips = over.Chips
+	}
+	return p
+}
+
+func setStyles(p Palette, mono bool) {
+	fg := func(c string) lipgloss.Style { return lipgloss.NewStyle().Foreground(lipgloss.Color(c)) }
+	// A badge is text on a coloured background; without colour, reverse
+	// video marks it out instead.
+	badge := func(bg string) lipgloss.Style {
+		if mono {
+			return lipgloss.NewStyle().Reverse(true)
+		}
+		return fg(p.Contrast).Background(lipgloss.Color(bg))
+	}
+
+	titleStyle = lipgloss.NewStyle().Bold(true)
+	dimStyle = fg(p.Dim)
+	selStyle = fg(p.Selected).Bold(true)
+	normStyle = fg(p.Text)
+	cmdStyle = fg(p.Command)
+	tStyle = fg(p.Dim)
+	errSty = fg(p.Error)
+	warnSty = fg(p.Error).Bold(true)
+	promptSty = fg(p.Accent)
+	previewStyle = fg(p.Preview)
+	freshStyle = fg(p.Active)
+	deadStyle = fg(p.Faint).Strikethrough(true)
+	markStyle = fg(p.Mark)
+	activeStyle = fg(p.Active)
+	waitStyle = badge(p.Mark)
+	foundStyle = badge(p.Accent)
+	matchStyle = fg(p.Accent).Bold(true)
+	userTurnStyle = fg(p.Accent).Bold(true)
+	toolTurnStyle = fg(p.Mark)
+	rangeStyle = lipgloss.NewStyle().Background(lipgloss.Color(p.Faint))
+	tileStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color(p.Faint))
+	tileActive = tileStyle.BorderForeground(lipgloss.Color(p.Active))
+	tileWait = tileStyle.BorderForeground(lipgloss.Color(p.Mark))
+	if mono {
+		markStyle = markStyle.Bold(true)
+		freshStyle = freshStyle.Bold(true)
+		matchStyle = matchStyle.Underline(true)
+		rangeStyle = rangeStyle.Underline(true)
+		tileActive = tileActive.BorderStyle(lipgloss.ThickBorder())
+		tileWait = tileWait.BorderStyle(lipgloss.DoubleBorder())
+	}
+
+	chipStyles = chipStyles[:0]
+	for _, c := range p.Chips {
+		chipStyles = append(chipStyles, badge(c))
+	}
+	if len(chipStyles) == 0 {
+		chipStyles = append(chipStyles, badge(""))
+	}
+}
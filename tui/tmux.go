@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// `claude-host tmux` runs the dashboard inside the user's own tmux, opening
+// each attach in a window of its own instead of taking over the terminal.
+// Each window is marked with the session it shows, so attaching again
+// selects it, and the attach inside keeps the window named after its
+// session as it's renamed or switched.
+
+// tmuxSessionOption marks a window with the session attached in it.
+const tmuxSessionOption = "@claude-host-session"
+
+// inTmux reports whether this process runs inside a tmux client.
+func inTmux() bool { return os.Getenv("TMUX") != "" }
+
+// tmuxHere runs a command against the tmux server this process is inside,
+// returning its stdout. Unlike tmux, it keeps $TMUX, which is what picks
+// that server.
+func tmuxHere(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, tmuxPath(), args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("tmux %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("tmux %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// tmuxWindowFor finds the window in the current tmux session that shows
+// the session name, returning its ID or "".
+func tmuxWindowFor(ctx context.Context, name string) (string, error) {
+	out, err := tmuxHere(ctx, "list-windows", "-F", "#{window_id} #{"+tmuxSessionOption+"}")
+	if err != nil {
+		return "", err
+	}
+	for line := range strings.Lines(out) {
+		id, shown, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if shown == name {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+// openTmuxWindow shows the session name in a tmux window: the one already
+// attached to it, or a new one running argv. env is passed to the new
+// window, which otherwise gets the tmux server's environment, not ours.
+func openTmuxWindow(ctx context.Context, name string, argv, env []string) error {
+	if !inTmux() {
+		return errors.New("not inside tmux")
+	}
+	id, err := tmuxWindowFor(ctx, name)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		_, err := tmuxHere(ctx, "select-window", "-t", id)
+		return err
+	}
+	args := []string{"new-window", "-P", "-F", "#{window_id}", "-n", name}
+	for _, kv := range env {
+		args = append(args, "-e", kv)
+	}
+	out, err := tmuxHere(ctx, append(append(args, "--"), argv...)...)
+	if err != nil {
+		return err
+	}
+	_, err = tmuxHere(ctx, "set-option", "-w", "-t", strings.TrimSpace(out), tmuxSessionOption, name)
+	return err
+}
+
+// renameTmuxWindow names the window this process runs in after the session
+// it's attached to.
+func renameTmuxWindow(name string) {
+	pane := os.Getenv("TMUX_PANE")
+	if pane == "" {
+		return
+	}
+	ctx := context.Background()
+	if _, err := tmuxHere(ctx, "rename-window", "-t", pane, name); err == nil {
+		tmuxHere(ctx, "set-option", "-w", "-t", pane, tmuxSessionOption, name)
+	}
+}
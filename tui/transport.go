@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Base URLs are http(s)://host[:port], or unix:///path/to/socket for a
+// server listening on a unix domain socket. Over a socket, requests are
+// addressed to http://unix and every connection dials the socket.
+const unixScheme = "unix://"
+
+// splitSocket returns the HTTP base URL to address requests to and, for a
+// unix:// URL, the socket path.
+func splitSocket(raw string) (base, socket string) {
+	if path, ok := strings.CutPrefix(raw, unixScheme); ok {
+		return "http://unix", path
+	}
+	return raw, ""
+}
+
+// loadTLS builds the client TLS settings from cfg, or returns nil when it
+// asks for nothing beyond the defaults.
+func loadTLS(cfg TLSConfig) (*tls.Config, error) {
+	if cfg == (TLSConfig{}) {
+		return nil, nil
+	}
+	tc := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CA != "" {
+		pem, err := os.ReadFile(cfg.CA)
+		if err != nil {
+			return nil, fmt.Errorf("tls ca: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls ca: no certificates in %s", cfg.CA)
+		}
+		tc.RootCAs = pool
+	}
+	switch {
+	case cfg.Cert != "" && cfg.Key != "":
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("tls cert: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	case cfg.Cert != "" || cfg.Key != "":
+		return nil, errors.New("tls: cert and key must be given together")
+	}
+	return tc, nil
+}
+
+// ConnOptions are the connection settings shared by a client's REST calls
+// and WebSockets.
+type ConnOptions struct {
+	TLS   *tls.Config // nil for the defaults
+	SSH   *sshTunnel  // carries every connection when set
+	Proxy proxyFunc   // nil to connect directly
+	Retry retryPolicy
+	Log   *slog.Logger // the debug log, nil for none
+}
+
+type proxyFunc func(*http.Request) (*url.URL, error)
+
+// proxyDirect is the proxy setting that bypasses the environment.
+const proxyDirect = "direct"
+
+// parseProxy resolves a proxy setting: "" defers to the environment,
+// "direct" turns proxying off and anything else is the proxy's URL.
+func parseProxy(raw string) (proxyFunc, error) {
+	switch raw {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case proxyDirect:
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("proxy %q: want a URL like http://proxy:8080 or %q", raw, proxyDirect)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy %q: unsupported scheme %q", raw, u.Scheme)
+	}
+	return http.ProxyURL(u), nil
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// newDial returns how a client reaches its server, or nil for a direct TCP
+// connection.
+func newDial(socket string, tun *sshTunnel) dialFunc {
+	switch {
+	case tun != nil && socket != "":
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return tun.DialContext(ctx, "unix", socket)
+		}
+	case tun != nil:
+		return tun.DialContext
+	case socket != "":
+		return func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+	}
+	return nil
+}
+
+// newTransport builds the HTTP transport for a client.
+func newTransport(dial dialFunc, conn ConnOptions) *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = conn.TLS
+	tr.Proxy = conn.Proxy
+	if dial != nil {
+		tr.Proxy = nil
+		tr.DialContext = dial
+	}
+	return tr
+}
+
+// Dialer returns a WebSocket dialer for the host a session lives on.
+func (a *APIClient) Dialer(name string, compress bool) *websocket.Dialer {
+	a, _ = a.route(name)
+	d := &websocket.Dialer{
+		Proxy:             a.conn.Proxy,
+		HandshakeTimeout:  45 * time.Second,
+		EnableCompression: compress,
+		TLSClientConfig:   a.conn.TLS,
+	}
+	if a.dial != nil {
+		d.Proxy = nil
+		d.NetDialContext = a.dial
+	}
+	return d
+}
+
+// String is the server address as the user gave it, for messages.
+func (a *APIClient) String() string {
+	addr := a.baseURL
+	if a.socket != "" {
+		addr = unixScheme + a.socket
+	}
+	if a.conn.SSH != nil {
+		addr += " via ssh " + a.conn.SSH.dest
+	}
+	return addr
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Account is the user a client's token signs in as, from GET /api/me.
+type Account struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+// Whoami returns the account the client's token belongs to. Servers without
+// accounts answer 404.
+func (a *APIClient) Whoami(ctx context.Context) (*Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "GET", "/api/me", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var acct Account
+	if err := decodeResponse(resp, &acct); err != nil {
+		return nil, err
+	}
+	return &acct, nil
+}
+
+// SetAllUsers makes list calls return every user's sessions rather than
+// just the caller's, which only admins may ask for.
+func (a *APIClient) SetAllUsers(on bool) {
+	a.allUsers = on
+	if a.multi != nil {
+		for _, p := range a.multi.peers {
+			p.SetAllUsers(on)
+		}
+	}
+}
+
+type accountMsg struct{ acct *Account }
+
+// fetchAccount finds out who the dashboard is signed in as.
+func (m DashboardModel) fetchAccount() tea.Cmd {
+	ctx, api := m.ctx, m.api
+	return func() tea.Msg {
+		acct, err := api.Whoami(ctx)
+		if err != nil {
+			return nil
+		}
+		return accountMsg{acct}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const waitPollInterval = time.Second
+
+// waitTimeoutCode is the exit status when wait gives up, as with timeout(1).
+const waitTimeoutCode = 124
+
+// waitSession blocks until the session's process has exited or, with idle
+// > 0, until its screen hasn't changed for idle. A session that exits while
+// being watched for idleness counts as idle.
+func waitSession(ctx context.Context, api *APIClient, name string, idle time.Duration) error {
+	var last string
+	var changed time.Time
+	t := time.NewTicker(waitPollInterval)
+	defer t.Stop()
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+		sessions, err := api.ListAllSessions(ctx)
+		if err != nil {
+			if e := asAPIError(err); e != nil && e.Unreachable() {
+				continue // the server may be restarting
+			}
+			return err
+		}
+		var sess *Session
+		for i := range sessions {
+			if sessions[i].Name == name {
+				sess = &sessions[i]
+			}
+		}
+		if sess == nil {
+			if first {
+				return fmt.Errorf("no session named %s", name)
+			}
+			return nil // purged after exiting
+		}
+		if !sess.Alive {
+			return nil
+		}
+		if idle <= 0 {
+			continue
+		}
+		text, err := api.GetSnapshot(ctx, name)
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+		if first || text != last {
+			last, changed = text, now
+		} else if now.Sub(changed) >= idle {
+			return nil
+		}
+	}
+}
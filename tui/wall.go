@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Minimum tile size; the grid gets as many columns and rows as fit, up to
+// wallMaxCols x wallMaxRows.
+const (
+	wallTileMinW = 50
+	wallTileMinH = 10
+	wallMaxCols  = 3
+	wallMaxRows  = 3
+)
+
+// WallView is the "w" overlay: a grid of live previews of running sessions,
+// fed by the watcher's screens. A digit attaches to the numbered tile.
+type WallView struct {
+	page int
+}
+
+type wallCloseMsg struct{}
+
+// wallGrid is the number of tile columns and rows that fit the terminal.
+func wallGrid(width, height int) (cols, rows int) {
+	cols = max(1, min(wallMaxCols, width/wallTileMinW))
+	rows = max(1, min(wallMaxRows, (height-6)/wallTileMinH))
+	return cols, rows
+}
+
+func (w WallView) Update(msg tea.KeyMsg, k KeyMap, sessions []Session, width, height int) (WallView, tea.Cmd) {
+	cols, rows := wallGrid(width, height)
+	per := cols * rows
+	pages := max(1, (len(sessions)+per-1)/per)
+	switch s := msg.String(); {
+	case s == "esc", s == "q", k.Matches(msg, k.Wall):
+		return w, func() tea.Msg { return wallCloseMsg{} }
+	case s == "right", s == "l", s == "pgdown", k.Matches(msg, k.Down):
+		w.page = min(pages-1, w.page+1)
+	case s == "left", s == "h", s == "pgup", k.Matches(msg, k.Up):
+		w.page = max(0, w.page-1)
+	case len(s) == 1 && s[0] >= '1' && s[0] <= '9':
+		i := w.page*per + int(s[0]-'1')
+		if int(s[0]-'1') < per && i < len(sessions) {
+			name := sessions[i].Name
+			return w, func() tea.Msg { return attachMsg(name) }
+		}
+	}
+	return w, nil
+}
+
+// View lays the sessions out in a grid. sessions should only include running
+// ones; screens, waiting and the watcher come from the dashboard.
+func (w WallView) View(sessions []Session, screens screensMsg, waiting map[string]bool, watch *watcher, width, height int) string {
+	if len(sessions) == 0 {
+		return dimStyle.Render("  No running sessions.") + "\n\n  " + dimStyle.Render("esc back") + "\n"
+	}
+	cols, rows := wallGrid(width, height)
+	per := cols * rows
+	page := min(w.page, (len(sessions)-1)/per)
+	start := page * per
+	shown := sessions[start:min(len(sessions), start+per)]
+
+	// Borders take two columns and two rows of each tile.
+	tileW := max(20, (width-2)/cols-2)
+	tileH := max(4, (height-6)/rows-2)
+
+	var grid []string
+	for r := 0; r*cols < len(shown); r++ {
+		var row []string
+		for c := 0; c < cols && r*cols+c < len(shown); c++ {
+			i := r*cols + c
+			row = append(row, w.tile(i+1, shown[i], screens, waiting, watch, tileW, tileH))
+		}
+		grid = append(grid, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	}
+
+	var s strings.Builder
+	for _, line := range strings.Split(lipgloss.JoinVertical(lipgloss.Left, grid...), "\n") {
+		s.WriteString(" " + line + "\n")
+	}
+	hint := "1-9 attach  esc back"
+	if pages := (len(sessions) + per - 1) / per; pages > 1 {
+		hint = fmt.Sprintf("page %d/%d  ←→ page  %s", page+1, pages, hint)
+	}
+	s.WriteString("  " + dimStyle.Render(hint) + "\n")
+	return s.String()
+}
+
+func (w WallView) tile(n int, sess Session, screens screensMsg, waiting map[string]bool, watch *watcher, width, height int) string {
+	style := tileStyle
+	status := activityLabel(watch.LastChange(sess.Name))
+	switch {
+	case waiting[sess.Name]:
+		style, status = tileWait, "waiting"
+	case strings.HasPrefix(status, "●"):
+		style = tileActive
+	}
+
+	title := fmt.Sprintf("%d %s", n, sess.Name)
+	gap := width - textWidth(title) - textWidth(status)
+	if gap < 1 {
+		title = truncate(title, max(1, width-textWidth(status)-1))
+		gap = 1
+	}
+	lines := []string{selStyle.Render(title) + strings.Repeat(" ", max(1, gap)) + dimStyle.Render(status)}
+
+	screen, ok := screens[sess.Name]
+	if !ok {
+		lines = append(lines, dimStyle.Render("waiting for output..."))
+	} else {
+		body := strings.Split(strings.TrimRight(screen, "\n "), "\n")
+		body = body[max(0, len(body)-(height-1)):]
+		for _, line := range body {
+			lines = append(lines, previewStyle.Render(truncate(line, width)))
+		}
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return style.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultPollInterval = 3 * time.Second
+	watchFastInterval   = time.Second // while the wall is open
+	defaultIdleTimeout  = 30 * time.Second
+)
+
+// watcher polls the screen of every running session for as long as the TUI
+// runs, attached or not. It tracks when each screen last changed and sends a
+// notification when a busy session goes quiet, which usually means Claude
+// has finished and is waiting for input. It runs [hooks] as sessions go
+// quiet or exit, and its screens also drive auto-summarize.
+type watcher struct {
+	api      *APIClient
+	interval time.Duration // between polls
+	idle     time.Duration // 0 disables notifications
+	desktop  bool          // also notify via notify-send / osascript
+	hooks    map[string]HookConfig
+	auto     *autoSummarizer
+	notes    *notifications
+	updates  chan screensMsg
+
+	mu       sync.Mutex
+	sessions map[string]*activity
+	alive    map[string]bool // running at the last poll, to see exits
+	attached string          // session on screen right now; not notified about
+	fast     bool            // poll every watchFastInterval
+}
+
+type activity struct {
+	hash     uint64
+	changed  time.Time // last time the screen changed
+	active   bool      // changed since we started watching
+	notified bool      // already notified for the current quiet spell
+}
+
+func newWatcher(api *APIClient, cfg NotifyConfig, hooks map[string]HookConfig, interval time.Duration, auto *autoSummarizer, notes *notifications) *watcher {
+	w := &watcher{
+		api:      api,
+		interval: interval,
+		idle:     defaultIdleTimeout,
+		desktop:  true,
+		hooks:    hooks,
+		auto:     auto,
+		notes:    notes,
+		updates:  make(chan screensMsg, 1),
+		sessions: map[string]*activity{},
+	}
+	if cfg.IdleSeconds != nil {
+		w.idle = time.Duration(*cfg.IdleSeconds) * time.Second
+	}
+	if cfg.Desktop != nil {
+		w.desktop = *cfg.Desktop
+	}
+	return w
+}
+
+func (w *watcher) run(ctx context.Context) {
+	for {
+		w.poll(ctx)
+		w.mu.Lock()
+		interval := w.interval
+		if w.fast {
+			interval = watchFastInterval
+		}
+		w.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// SetFast switches to polling every second, for views that show screens
+// live.
+func (w *watcher) SetFast(fast bool) {
+	w.mu.Lock()
+	w.fast = fast
+	w.mu.Unlock()
+}
+
+// SetAttached records which session the user is looking at, "" for none.
+func (w *watcher) SetAttached(name string) {
+	w.mu.Lock()
+	w.attached = name
+	w.mu.Unlock()
+}
+
+// LastChange is when the session's screen last changed, or zero if it
+// hasn't since the watcher started.
+func (w *watcher) LastChange(name string) time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if a := w.sessions[name]; a != nil && a.active {
+		return a.changed
+	}
+	return time.Time{}
+}
+
+// Updates delivers the next round of screens to the dashboard, giving up
+// when ctx ends. Only the most recent round is kept if nobody is listening.
+func (w *watcher) Updates(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case screens := <-w.updates:
+			return screens
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (w *watcher) poll(ctx context.Context) {
+	listed, err := w.api.ListAllSessions(ctx)
+	if err != nil {
+		return
+	}
+	byName := make(map[string]Session, len(listed))
+	var sessions []Session
+	for _, s := range listed {
+		byName[s.Name] = s
+		if s.Alive {
+			sessions = append(sessions, s)
+		}
+	}
+	screens := make(screensMsg, len(sessions))
+	var mu sync.Mutex
+	sem := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	for _, s := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if text, err := w.api.GetSnapshot(ctx, s.Name); err == nil {
+				mu.Lock()
+				screens[s.Name] = text
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	var quiet, idle, exited []string
+	w.mu.Lock()
+	for name := range w.alive {
+		if s, ok := byName[name]; ok && !s.Alive {
+			exited = append(exited, name)
+		}
+	}
+	w.alive = make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		w.alive[s.Name] = true
+	}
+	for name := range w.sessions {
+		if _, ok := screens[name]; !ok {
+			delete(w.sessions, name)
+		}
+	}
+	for name, text := range screens {
+		h := fnv.New64a()
+		h.Write([]byte(text))
+		sum := h.Sum64()
+		a := w.sessions[name]
+		switch {
+		case a == nil:
+			w.sessions[name] = &activity{hash: sum, changed: now}
+		case a.hash != sum:
+			a.hash, a.changed, a.active, a.notified = sum, now, true, false
+		case w.idle > 0 && a.active && !a.notified && now.Sub(a.changed) >= w.idle:
+			a.notified = true
+			idle = append(idle, name)
+			if name != w.attached {
+				quiet = append(quiet, name)
+			}
+		}
+	}
+	w.mu.Unlock()
+
+	for _, name := range quiet {
+		w.notify(name)
+		w.notes.add(noteIdle, name, "%s is waiting for input", name)
+	}
+	for _, name := range idle {
+		runHook(ctx, w.hooks, byName[name], hookIdle)
+	}
+	for _, name := range exited {
+		s := byName[name]
+		runHook(ctx, w.hooks, s, exitEvent(s))
+		kind := noteExited
+		if exitEvent(s) == hookCrashed {
+			kind = noteCrashed
+		}
+		if s.ExitStatus != nil {
+			w.notes.add(kind, name, "%s exited with status %d", name, *s.ExitStatus)
+		} else {
+			w.notes.add(kind, name, "%s exited", name)
+		}
+	}
+	w.auto.observe(ctx, screens)
+
+	// Replace any round the dashboard hasn't picked up yet.
+	select {
+	case <-w.updates:
+	default:
+	}
+	w.updates <- screens
+}
+
+// notify rings the bell and raises a desktop notification: OSC 777 for
+// terminals that support it, plus notify-send or osascript if desktop is on.
+func (w *watcher) notify(name string) {
+	title, body := "claude-host", name+" is waiting for input"
+	fmt.Fprintf(os.Stdout, "\a\033]777;notify;%s;%s\a", title, body)
+	if !w.desktop {
+		return
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", body, title))
+	default:
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return
+		}
+		cmd = exec.Command("notify-send", title, body)
+	}
+	go cmd.Run()
+}
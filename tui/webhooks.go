@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net/url"
+)
+
+// Webhook is a URL the server POSTs session events to: created, exited,
+// crashed (a non-zero exit) and idle.
+type Webhook struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events,omitempty"`       // all of them if empty
+	IdleMinutes int      `json:"idle_minutes,omitempty"` // quiet time before "idle", default 10
+	Secret      string   `json:"secret,omitempty"`       // signs each body; never sent back
+	Signed      bool     `json:"signed,omitempty"`       // whether a secret is set
+	Owner       string   `json:"owner,omitempty"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+}
+
+func (a *APIClient) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "GET", "/api/webhooks", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var hooks []Webhook
+	if err := decodeResponse(resp, &hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// CreateWebhook registers a hook, returning it with its ID.
+func (a *APIClient) CreateWebhook(ctx context.Context, h Webhook) (*Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/webhooks", h)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var out Webhook
+	if err := decodeResponse(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (a *APIClient) DeleteWebhook(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "DELETE", "/api/webhooks/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// TestWebhook has the server send the hook a test event, failing if the
+// hook's URL doesn't accept it.
+func (a *APIClient) TestWebhook(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	resp, err := a.do(ctx, "POST", "/api/webhooks/"+url.PathEscape(id)+"/test", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}